@@ -21,7 +21,8 @@ type Attributed interface {
 }
 
 type Attributes struct {
-	attrs map[interface{}]interface{}
+	attrs     map[interface{}]interface{}
+	observers Observers
 }
 
 func NewAttributes() *Attributes {
@@ -39,9 +40,74 @@ func (a *Attributes) Attribute(key interface{}) interface{} {
 }
 
 func (a *Attributes) SetAttributes(attrs map[interface{}]interface{}) {
+	old := a.attrs
 	a.attrs = attrs
+	a.observers.NotifyAll(old, attrs)
 }
 
 func (a *Attributes) SetAttribute(key, value interface{}) {
+	old := a.attrs[key]
 	a.attrs[key] = value
+	a.observers.Notify(key, old, value)
+}
+
+// Observe adds an observer of changes to the attributes
+func (a *Attributes) Observe(observer AttributeObserver) func() {
+	return a.observers.Observe(observer)
+}
+
+// AttributeObserver is told of a change to an attribute of a node or edge,
+// with the attribute's old and new values.  A nil value is an absent
+// attribute
+type AttributeObserver func(key, old, new interface{})
+
+// Observable is implemented by nodes and edges that report changes made
+// through SetAttribute and SetAttributes.  Changes made directly to the
+// map returned by Attributes are not reported
+type Observable interface {
+	// Observe adds an observer, returning a function that removes it
+	Observe(AttributeObserver) func()
+}
+
+// Observers is a set of attribute observers for an attributed item to
+// notify.  The zero value is an empty set
+type Observers struct {
+	next      int
+	observers map[int]AttributeObserver
+}
+
+// Observe adds an observer, returning a function that removes it
+func (o *Observers) Observe(observer AttributeObserver) func() {
+	if o.observers == nil {
+		o.observers = make(map[int]AttributeObserver)
+	}
+	id := o.next
+	o.next++
+	o.observers[id] = observer
+	return func() {
+		delete(o.observers, id)
+	}
+}
+
+// Notify tells the observers of a change to an attribute
+func (o *Observers) Notify(key, old, new interface{}) {
+	for _, observer := range o.observers {
+		observer(key, old, new)
+	}
+}
+
+// NotifyAll tells the observers of the changes made by replacing one set
+// of attributes with another
+func (o *Observers) NotifyAll(old, new map[interface{}]interface{}) {
+	if len(o.observers) == 0 {
+		return
+	}
+	for key, value := range old {
+		o.Notify(key, value, new[key])
+	}
+	for key, value := range new {
+		if _, exists := old[key]; !exists {
+			o.Notify(key, nil, value)
+		}
+	}
 }
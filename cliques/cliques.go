@@ -0,0 +1,231 @@
+package cliques
+
+// Copyright © 2018 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"context"
+	"sort"
+
+	"github.com/wealdtech/go-graph"
+	"github.com/wealdtech/go-graph/internal/adjacency"
+)
+
+// MaximalCliques calls fn with the node IDs of each maximal clique in an
+// undirected graph, in ascending order.  It uses Bron-Kerbosch with
+// pivoting, with the outer level in degeneracy order.  Enumeration stops
+// if fn returns false, or with the context's error if it is cancelled
+func MaximalCliques(ctx context.Context, g graph.Graph, fn func(clique []int64) bool) error {
+	s := newSearch(ctx, g)
+	s.emit = func(r []int32) bool {
+		return fn(s.adj.NodeIDs(r))
+	}
+	s.run()
+	return s.err
+}
+
+// MaximalCliquesChannel streams the maximal cliques of an undirected graph
+// over a channel, which is closed when enumeration finishes.  Cancel the
+// context to stop enumeration early
+func MaximalCliquesChannel(ctx context.Context, g graph.Graph) <-chan []int64 {
+	ch := make(chan []int64)
+	go func() {
+		defer close(ch)
+		MaximalCliques(ctx, g, func(clique []int64) bool {
+			select {
+			case ch <- clique:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		})
+	}()
+	return ch
+}
+
+// MaximumClique returns the node IDs of a largest clique in an undirected
+// graph, in ascending order.  Branches that cannot beat the best clique
+// found so far are pruned.  If the context is cancelled the best clique
+// found so far is returned along with the context's error
+func MaximumClique(ctx context.Context, g graph.Graph) ([]int64, error) {
+	s := newSearch(ctx, g)
+	var best []int32
+	s.bound = func(r, p []int32) bool {
+		return len(r)+len(p) > len(best)
+	}
+	s.emit = func(r []int32) bool {
+		if len(r) > len(best) {
+			best = append([]int32(nil), r...)
+		}
+		return true
+	}
+	s.run()
+	return s.adj.NodeIDs(best), s.err
+}
+
+// search holds the state of a Bron-Kerbosch enumeration
+type search struct {
+	ctx   context.Context
+	adj   *adjacency.Lists
+	emit  func(r []int32) bool
+	bound func(r, p []int32) bool
+	err   error
+	done  bool
+}
+
+func newSearch(ctx context.Context, g graph.Graph) *search {
+	return &search{
+		ctx: ctx,
+		adj: adjacency.New(g),
+	}
+}
+
+func (s *search) run() {
+	order := s.adj.DegeneracyOrder()
+	position := make([]int, len(order))
+	for i, v := range order {
+		position[v] = i
+	}
+	for _, v := range order {
+		p := make([]int32, 0)
+		x := make([]int32, 0)
+		for _, w := range s.adj.Neighbours[v] {
+			if position[w] > position[v] {
+				p = append(p, w)
+			} else {
+				x = append(x, w)
+			}
+		}
+		s.expand([]int32{v}, p, x)
+		if s.done {
+			return
+		}
+	}
+}
+
+// expand reports all maximal cliques that extend r with nodes from p and
+// none from x
+func (s *search) expand(r, p, x []int32) {
+	select {
+	case <-s.ctx.Done():
+		s.err = s.ctx.Err()
+		s.done = true
+		return
+	default:
+	}
+	if len(p) == 0 {
+		if len(x) == 0 && !s.emit(r) {
+			s.done = true
+		}
+		return
+	}
+	if s.bound != nil && !s.bound(r, p) {
+		return
+	}
+
+	// Choose the pivot that leaves the fewest candidates to branch on
+	pivot := int32(-1)
+	pivotCount := -1
+	for _, set := range [][]int32{p, x} {
+		for _, u := range set {
+			count := intersectionSize(p, s.adj.Neighbours[u])
+			if count > pivotCount {
+				pivot = u
+				pivotCount = count
+			}
+		}
+	}
+
+	candidates := difference(p, s.adj.Neighbours[pivot])
+	for _, v := range candidates {
+		nv := s.adj.Neighbours[v]
+		s.expand(append(r[:len(r):len(r)], v), intersection(p, nv), intersection(x, nv))
+		if s.done {
+			return
+		}
+		p = remove(p, v)
+		x = insert(x, v)
+	}
+}
+
+// intersection returns the elements common to two sorted sets
+func intersection(a, b []int32) []int32 {
+	res := make([]int32, 0)
+	for len(a) > 0 && len(b) > 0 {
+		switch {
+		case a[0] < b[0]:
+			a = a[1:]
+		case a[0] > b[0]:
+			b = b[1:]
+		default:
+			res = append(res, a[0])
+			a = a[1:]
+			b = b[1:]
+		}
+	}
+	return res
+}
+
+func intersectionSize(a, b []int32) int {
+	count := 0
+	for len(a) > 0 && len(b) > 0 {
+		switch {
+		case a[0] < b[0]:
+			a = a[1:]
+		case a[0] > b[0]:
+			b = b[1:]
+		default:
+			count++
+			a = a[1:]
+			b = b[1:]
+		}
+	}
+	return count
+}
+
+// difference returns the elements of sorted set a that are not in sorted
+// set b
+func difference(a, b []int32) []int32 {
+	res := make([]int32, 0)
+	for len(a) > 0 {
+		switch {
+		case len(b) == 0 || a[0] < b[0]:
+			res = append(res, a[0])
+			a = a[1:]
+		case a[0] > b[0]:
+			b = b[1:]
+		default:
+			a = a[1:]
+			b = b[1:]
+		}
+	}
+	return res
+}
+
+func remove(set []int32, v int32) []int32 {
+	res := make([]int32, 0, len(set))
+	for _, w := range set {
+		if w != v {
+			res = append(res, w)
+		}
+	}
+	return res
+}
+
+func insert(set []int32, v int32) []int32 {
+	i := sort.Search(len(set), func(i int) bool { return set[i] >= v })
+	res := make([]int32, 0, len(set)+1)
+	res = append(res, set[:i]...)
+	res = append(res, v)
+	return append(res, set[i:]...)
+}
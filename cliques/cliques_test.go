@@ -0,0 +1,90 @@
+package cliques
+
+// Copyright © 2018 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wealdtech/go-graph/edges"
+	"github.com/wealdtech/go-graph/graphs"
+	"github.com/wealdtech/go-graph/nodes"
+)
+
+// testGraph creates a 4-clique (1-4), a triangle (4-5-6), an edge (6-7)
+// and an isolated node (8)
+func testGraph(t *testing.T) *graphs.UndirectedGraph {
+	g := graphs.NewUndirectedGraph()
+	for i := int64(1); i <= 8; i++ {
+		assert.NoError(t, g.AddNode(nodes.NewSimpleNode(i)))
+	}
+	for _, pair := range [][2]int64{{1, 2}, {1, 3}, {1, 4}, {2, 3}, {2, 4}, {3, 4}, {4, 5}, {5, 6}, {4, 6}, {6, 7}} {
+		assert.NoError(t, g.AddEdge(edges.NewUndirectedEdge(pair[0], pair[1])))
+	}
+	return g
+}
+
+func sortCliques(cliques [][]int64) {
+	sort.Slice(cliques, func(i, j int) bool { return cliques[i][0] < cliques[j][0] })
+}
+
+func TestMaximalCliques(t *testing.T) {
+	cliques := make([][]int64, 0)
+	err := MaximalCliques(context.Background(), testGraph(t), func(clique []int64) bool {
+		cliques = append(cliques, clique)
+		return true
+	})
+	assert.NoError(t, err)
+	sortCliques(cliques)
+	assert.Equal(t, [][]int64{{1, 2, 3, 4}, {4, 5, 6}, {6, 7}, {8}}, cliques)
+
+	// Stop early
+	count := 0
+	err = MaximalCliques(context.Background(), testGraph(t), func(clique []int64) bool {
+		count++
+		return false
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
+
+func TestMaximalCliquesCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := MaximalCliques(ctx, testGraph(t), func(clique []int64) bool {
+		return true
+	})
+	assert.Equal(t, context.Canceled, err)
+}
+
+func TestMaximalCliquesChannel(t *testing.T) {
+	cliques := make([][]int64, 0)
+	for clique := range MaximalCliquesChannel(context.Background(), testGraph(t)) {
+		cliques = append(cliques, clique)
+	}
+	sortCliques(cliques)
+	assert.Equal(t, [][]int64{{1, 2, 3, 4}, {4, 5, 6}, {6, 7}, {8}}, cliques)
+}
+
+func TestMaximumClique(t *testing.T) {
+	clique, err := MaximumClique(context.Background(), testGraph(t))
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{1, 2, 3, 4}, clique)
+
+	clique, err = MaximumClique(context.Background(), graphs.NewUndirectedGraph())
+	assert.NoError(t, err)
+	assert.Len(t, clique, 0)
+}
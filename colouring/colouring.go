@@ -0,0 +1,184 @@
+package colouring
+
+// Copyright © 2018 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"sort"
+
+	"github.com/wealdtech/go-graph"
+	"github.com/wealdtech/go-graph/internal/adjacency"
+)
+
+// lists are a graph's adjacency lists, with the colouring algorithms
+type lists struct {
+	*adjacency.Lists
+}
+
+func newLists(g graph.Graph) *lists {
+	return &lists{adjacency.New(g)}
+}
+
+// Strategy is the order in which greedy colouring visits nodes
+type Strategy int
+
+const (
+	// LargestFirst colours nodes in order of decreasing degree
+	LargestFirst Strategy = iota
+	// SmallestLast colours nodes in reverse degeneracy order
+	SmallestLast
+	// DSatur colours the node with the most distinctly-coloured neighbours next
+	DSatur
+)
+
+// Colouring maps node IDs to colours.  Colours run from 0
+type Colouring map[int64]int
+
+// Colours returns the number of colours used
+func (c Colouring) Colours() int {
+	count := 0
+	for _, colour := range c {
+		if colour+1 > count {
+			count = colour + 1
+		}
+	}
+	return count
+}
+
+// SetNodeAttributes writes the colour of each node in the graph to the
+// given node attribute.  Use dot.ColourNodes to render the colours
+func (c Colouring) SetNodeAttributes(g graph.Graph, key interface{}) {
+	for nid, colour := range c {
+		node := g.Node(nid)
+		if node != nil {
+			node.SetAttribute(key, colour)
+		}
+	}
+}
+
+// IsValid returns true if no two adjacent nodes in an undirected graph
+// share a colour.  Self-loops are ignored
+func IsValid(g graph.Graph, c Colouring) bool {
+	for _, node := range g.Nodes() {
+		if _, exists := c[node.Id()]; !exists {
+			return false
+		}
+		for _, edge := range g.Edges(node.Id()) {
+			if edge.From() != edge.To() && c[edge.From()] == c[edge.To()] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Greedy colours the nodes of an undirected graph, giving each node in
+// turn the lowest colour not used by its neighbours.  Self-loops are
+// ignored
+func Greedy(g graph.Graph, strategy Strategy) Colouring {
+	a := newLists(g)
+	var colours []int
+	switch strategy {
+	case SmallestLast:
+		order := a.DegeneracyOrder()
+		for i, j := 0, len(order)-1; i < j; i, j = i+1, j-1 {
+			order[i], order[j] = order[j], order[i]
+		}
+		colours = a.colourInOrder(order)
+	case DSatur:
+		colours = a.dsatur()
+	default:
+		order := make([]int32, len(a.IDs))
+		for i := range order {
+			order[i] = int32(i)
+		}
+		sort.SliceStable(order, func(i, j int) bool {
+			return len(a.Neighbours[order[i]]) > len(a.Neighbours[order[j]])
+		})
+		colours = a.colourInOrder(order)
+	}
+	return a.colouring(colours)
+}
+
+func (a *lists) colourInOrder(order []int32) []int {
+	colours := make([]int, len(a.IDs))
+	for i := range colours {
+		colours[i] = -1
+	}
+	for _, v := range order {
+		colours[v] = a.lowestFree(v, colours)
+	}
+	return colours
+}
+
+// lowestFree returns the lowest colour not used by any neighbour of v
+func (a *lists) lowestFree(v int32, colours []int) int {
+	used := make([]bool, len(a.Neighbours[v])+1)
+	for _, w := range a.Neighbours[v] {
+		if c := colours[w]; c >= 0 && c < len(used) {
+			used[c] = true
+		}
+	}
+	for c := range used {
+		if !used[c] {
+			return c
+		}
+	}
+	return len(used)
+}
+
+func (a *lists) dsatur() []int {
+	n := len(a.IDs)
+	colours := make([]int, n)
+	for i := range colours {
+		colours[i] = -1
+	}
+	saturation := make([]map[int]bool, n)
+	for i := range saturation {
+		saturation[i] = make(map[int]bool)
+	}
+	for coloured := 0; coloured < n; coloured++ {
+		v := a.mostSaturated(colours, saturation)
+		colours[v] = a.lowestFree(v, colours)
+		for _, w := range a.Neighbours[v] {
+			saturation[w][colours[v]] = true
+		}
+	}
+	return colours
+}
+
+// mostSaturated returns the uncoloured node with the most distinct colours
+// among its neighbours, breaking ties by degree and then by lowest ID
+func (a *lists) mostSaturated(colours []int, saturation []map[int]bool) int32 {
+	best := int32(-1)
+	for i := range colours {
+		if colours[i] >= 0 {
+			continue
+		}
+		v := int32(i)
+		if best < 0 ||
+			len(saturation[v]) > len(saturation[best]) ||
+			(len(saturation[v]) == len(saturation[best]) && len(a.Neighbours[v]) > len(a.Neighbours[best])) {
+			best = v
+		}
+	}
+	return best
+}
+
+func (a *lists) colouring(colours []int) Colouring {
+	res := make(Colouring, len(a.IDs))
+	for i, nid := range a.IDs {
+		res[nid] = colours[i]
+	}
+	return res
+}
@@ -0,0 +1,123 @@
+package colouring
+
+// Copyright © 2018 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wealdtech/go-graph"
+	"github.com/wealdtech/go-graph/edges"
+	"github.com/wealdtech/go-graph/graphs"
+	"github.com/wealdtech/go-graph/nodes"
+)
+
+func newGraph(t *testing.T, n int64, pairs [][2]int64) *graphs.UndirectedGraph {
+	g := graphs.NewUndirectedGraph()
+	for i := int64(1); i <= n; i++ {
+		assert.NoError(t, g.AddNode(nodes.NewSimpleNode(i)))
+	}
+	for _, pair := range pairs {
+		assert.NoError(t, g.AddEdge(edges.NewUndirectedEdge(pair[0], pair[1])))
+	}
+	return g
+}
+
+// petersen creates the Petersen graph, which has chromatic number 3 and
+// chromatic index 4
+func petersen(t *testing.T) *graphs.UndirectedGraph {
+	return newGraph(t, 10, [][2]int64{
+		{1, 2}, {2, 3}, {3, 4}, {4, 5}, {5, 1},
+		{1, 6}, {2, 7}, {3, 8}, {4, 9}, {5, 10},
+		{6, 8}, {8, 10}, {10, 7}, {7, 9}, {9, 6},
+	})
+}
+
+func TestGreedy(t *testing.T) {
+	g := newGraph(t, 7, [][2]int64{{1, 2}, {1, 3}, {1, 4}, {2, 3}, {2, 4}, {3, 4}, {4, 5}, {5, 6}, {4, 6}, {6, 7}})
+	for _, strategy := range []Strategy{LargestFirst, SmallestLast, DSatur} {
+		c := Greedy(g, strategy)
+		assert.True(t, IsValid(g, c))
+		assert.Equal(t, 4, c.Colours())
+	}
+
+	g = petersen(t)
+	for _, strategy := range []Strategy{LargestFirst, SmallestLast, DSatur} {
+		c := Greedy(g, strategy)
+		assert.True(t, IsValid(g, c))
+	}
+}
+
+func TestExact(t *testing.T) {
+	// Odd cycle
+	g := newGraph(t, 5, [][2]int64{{1, 2}, {2, 3}, {3, 4}, {4, 5}, {5, 1}})
+	c := Exact(g)
+	assert.True(t, IsValid(g, c))
+	assert.Equal(t, 3, c.Colours())
+
+	// Even cycle
+	g = newGraph(t, 6, [][2]int64{{1, 2}, {2, 3}, {3, 4}, {4, 5}, {5, 6}, {6, 1}})
+	c = Exact(g)
+	assert.True(t, IsValid(g, c))
+	assert.Equal(t, 2, c.Colours())
+
+	g = petersen(t)
+	c = Exact(g)
+	assert.True(t, IsValid(g, c))
+	assert.Equal(t, 3, c.Colours())
+
+	assert.Equal(t, 0, Exact(graphs.NewUndirectedGraph()).Colours())
+}
+
+func TestSetNodeAttributes(t *testing.T) {
+	g := newGraph(t, 2, [][2]int64{{1, 2}})
+	Greedy(g, DSatur).SetNodeAttributes(g, "colour")
+	assert.Equal(t, 0, g.Node(1).Attribute("colour"))
+	assert.Equal(t, 1, g.Node(2).Attribute("colour"))
+}
+
+func assertValidEdgeColouring(t *testing.T, g graph.Graph, c EdgeColouring) {
+	for _, node := range g.Nodes() {
+		seen := make(map[int]bool)
+		for _, edge := range g.Edges(node.Id()) {
+			colour, exists := c[edge]
+			assert.True(t, exists)
+			assert.False(t, seen[colour], "colour %d repeated at node %d", colour, node.Id())
+			seen[colour] = true
+		}
+	}
+}
+
+func TestColourEdges(t *testing.T) {
+	g := petersen(t)
+	c := ColourEdges(g)
+	assert.Len(t, c, 15)
+	assertValidEdgeColouring(t, g, c)
+	assert.Equal(t, 4, c.Colours())
+
+	// Complete graph on 6 nodes
+	pairs := make([][2]int64, 0)
+	for i := int64(1); i <= 6; i++ {
+		for j := i + 1; j <= 6; j++ {
+			pairs = append(pairs, [2]int64{i, j})
+		}
+	}
+	g = newGraph(t, 6, pairs)
+	c = ColourEdges(g)
+	assertValidEdgeColouring(t, g, c)
+	assert.True(t, c.Colours() <= 6)
+
+	c.SetEdgeAttributes("colour")
+	assert.Equal(t, c[g.Edge(1, 2)], g.Edge(1, 2).Attribute("colour"))
+}
@@ -0,0 +1,217 @@
+package colouring
+
+// Copyright © 2018 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"github.com/wealdtech/go-graph"
+)
+
+// EdgeColouring maps edges to colours.  Colours run from 0
+type EdgeColouring map[graph.Edge]int
+
+// Colours returns the number of colours used
+func (c EdgeColouring) Colours() int {
+	count := 0
+	for _, colour := range c {
+		if colour+1 > count {
+			count = colour + 1
+		}
+	}
+	return count
+}
+
+// SetEdgeAttributes writes the colour of each edge to the given edge
+// attribute.  Use dot.ColourEdges to render the colours
+func (c EdgeColouring) SetEdgeAttributes(key interface{}) {
+	for edge, colour := range c {
+		edge.SetAttribute(key, colour)
+	}
+}
+
+// ColourEdges colours the edges of an undirected graph so that no two
+// edges sharing a node have the same colour, using the Misra-Gries
+// algorithm.  This uses at most one more colour than the maximum degree.
+// Self-loops are not coloured
+func ColourEdges(g graph.Graph) EdgeColouring {
+	a := newLists(g)
+	index := make(map[int64]int32, len(a.IDs))
+	for i, nid := range a.IDs {
+		index[nid] = int32(i)
+	}
+
+	maxDegree := 0
+	for i := range a.Neighbours {
+		if len(a.Neighbours[i]) > maxDegree {
+			maxDegree = len(a.Neighbours[i])
+		}
+	}
+	mg := &misraGries{
+		colours: maxDegree + 1,
+		at:      make([]map[int]int32, len(a.IDs)),
+		edge:    make(map[[2]int32]int),
+	}
+	for i := range mg.at {
+		mg.at[i] = make(map[int]int32)
+	}
+	for u := range a.Neighbours {
+		for _, v := range a.Neighbours[u] {
+			if int32(u) < v {
+				mg.colourEdge(a, int32(u), v)
+			}
+		}
+	}
+
+	res := make(EdgeColouring)
+	for _, nid := range a.IDs {
+		for _, edge := range g.Edges(nid) {
+			x, xok := index[edge.From()]
+			y, yok := index[edge.To()]
+			if !xok || !yok || x == y {
+				continue
+			}
+			res[edge] = mg.edge[key(x, y)]
+		}
+	}
+	return res
+}
+
+// misraGries holds the state of a Misra-Gries edge colouring
+type misraGries struct {
+	colours int
+	// at maps each node's colours to the neighbour reached by that colour
+	at   []map[int]int32
+	edge map[[2]int32]int
+}
+
+func key(u, v int32) [2]int32 {
+	if u < v {
+		return [2]int32{u, v}
+	}
+	return [2]int32{v, u}
+}
+
+func (mg *misraGries) set(u, v int32, c int) {
+	mg.edge[key(u, v)] = c
+	mg.at[u][c] = v
+	mg.at[v][c] = u
+}
+
+func (mg *misraGries) unset(u, v int32) {
+	k := key(u, v)
+	c, exists := mg.edge[k]
+	if !exists {
+		return
+	}
+	delete(mg.edge, k)
+	delete(mg.at[u], c)
+	delete(mg.at[v], c)
+}
+
+func (mg *misraGries) isFree(v int32, c int) bool {
+	_, used := mg.at[v][c]
+	return !used
+}
+
+func (mg *misraGries) free(v int32) int {
+	for c := 0; c < mg.colours; c++ {
+		if mg.isFree(v, c) {
+			return c
+		}
+	}
+	// Cannot happen with maximum degree + 1 colours
+	return mg.colours
+}
+
+func (mg *misraGries) colourEdge(a *lists, u, v int32) {
+	// Build a maximal fan at u starting with v
+	fan := []int32{v}
+	inFan := map[int32]bool{v: true}
+	for extended := true; extended; {
+		extended = false
+		last := fan[len(fan)-1]
+		for _, w := range a.Neighbours[u] {
+			if inFan[w] {
+				continue
+			}
+			c, coloured := mg.edge[key(u, w)]
+			if coloured && mg.isFree(last, c) {
+				fan = append(fan, w)
+				inFan[w] = true
+				extended = true
+				break
+			}
+		}
+	}
+
+	c := mg.free(u)
+	d := mg.free(fan[len(fan)-1])
+
+	// Invert the cd-path starting at u
+	path := []int32{u}
+	for colour, x := d, u; ; {
+		y, exists := mg.at[x][colour]
+		if !exists {
+			break
+		}
+		path = append(path, y)
+		x = y
+		if colour == d {
+			colour = c
+		} else {
+			colour = d
+		}
+	}
+	if len(path) > 1 {
+		pathColours := make([]int, len(path)-1)
+		for i := 0; i < len(path)-1; i++ {
+			pathColours[i] = mg.edge[key(path[i], path[i+1])]
+			mg.unset(path[i], path[i+1])
+		}
+		for i := 0; i < len(path)-1; i++ {
+			if pathColours[i] == c {
+				mg.set(path[i], path[i+1], d)
+			} else {
+				mg.set(path[i], path[i+1], c)
+			}
+		}
+	}
+
+	// Find the end of the sub-fan that has d free
+	end := 0
+	for i := range fan {
+		if i > 0 {
+			colour, coloured := mg.edge[key(u, fan[i])]
+			if !coloured || !mg.isFree(fan[i-1], colour) {
+				break
+			}
+		}
+		if mg.isFree(fan[i], d) {
+			end = i
+			break
+		}
+	}
+
+	// Rotate the sub-fan and colour its last edge with d
+	rotated := make([]int, end)
+	for i := 0; i < end; i++ {
+		rotated[i] = mg.edge[key(u, fan[i+1])]
+	}
+	for i := 1; i <= end; i++ {
+		mg.unset(u, fan[i])
+	}
+	for i := 0; i < end; i++ {
+		mg.set(u, fan[i], rotated[i])
+	}
+	mg.set(u, fan[end], d)
+}
@@ -0,0 +1,105 @@
+package colouring
+
+// Copyright © 2018 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"github.com/wealdtech/go-graph"
+)
+
+// Exact colours the nodes of an undirected graph with the fewest possible
+// colours.  It starts from a DSatur colouring and uses backtracking to
+// look for colourings with fewer colours.  This takes exponential time in
+// the worst case, so is only suitable for small graphs
+func Exact(g graph.Graph) Colouring {
+	a := newLists(g)
+	best := a.dsatur()
+	k := 0
+	for _, c := range best {
+		if c+1 > k {
+			k = c + 1
+		}
+	}
+	for k > 1 {
+		colours := make([]int, len(a.IDs))
+		for i := range colours {
+			colours[i] = -1
+		}
+		if !a.colourWith(colours, k-1, 0, -1) {
+			break
+		}
+		best = colours
+		k--
+	}
+	return a.colouring(best)
+}
+
+// colourWith attempts to complete a partial colouring using at most k
+// colours.  New colours are only introduced in order, to avoid exploring
+// permutations of the same colouring
+func (a *lists) colourWith(colours []int, k int, coloured int, highest int) bool {
+	if coloured == len(colours) {
+		return true
+	}
+	v := a.mostConstrained(colours)
+	used := make([]bool, k)
+	for _, w := range a.Neighbours[v] {
+		if c := colours[w]; c >= 0 {
+			used[c] = true
+		}
+	}
+	limit := highest + 1
+	if limit >= k {
+		limit = k - 1
+	}
+	for c := 0; c <= limit; c++ {
+		if used[c] {
+			continue
+		}
+		colours[v] = c
+		newHighest := highest
+		if c > newHighest {
+			newHighest = c
+		}
+		if a.colourWith(colours, k, coloured+1, newHighest) {
+			return true
+		}
+	}
+	colours[v] = -1
+	return false
+}
+
+// mostConstrained returns the uncoloured node with the most distinct
+// colours among its neighbours, breaking ties by degree and then by lowest ID
+func (a *lists) mostConstrained(colours []int) int32 {
+	best := int32(-1)
+	bestSaturation := -1
+	for i := range colours {
+		if colours[i] >= 0 {
+			continue
+		}
+		v := int32(i)
+		seen := make(map[int]bool)
+		for _, w := range a.Neighbours[v] {
+			if colours[w] >= 0 {
+				seen[colours[w]] = true
+			}
+		}
+		if len(seen) > bestSaturation ||
+			(len(seen) == bestSaturation && len(a.Neighbours[v]) > len(a.Neighbours[best])) {
+			best = v
+			bestSaturation = len(seen)
+		}
+	}
+	return best
+}
@@ -0,0 +1,219 @@
+package combine
+
+// Copyright © 2018 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/wealdtech/go-graph"
+	"github.com/wealdtech/go-graph/internal/build"
+)
+
+// Policy decides the value of an attribute that two graphs give different
+// values, returning an error if they cannot be reconciled
+type Policy func(key, first, second interface{}) (interface{}, error)
+
+// KeepFirst keeps the value from the earlier graph
+func KeepFirst(key, first, second interface{}) (interface{}, error) {
+	return first, nil
+}
+
+// KeepSecond keeps the value from the later graph
+func KeepSecond(key, first, second interface{}) (interface{}, error) {
+	return second, nil
+}
+
+// Fail refuses to reconcile different values
+func Fail(key, first, second interface{}) (interface{}, error) {
+	return nil, fmt.Errorf("Attribute %v has conflicting values %v and %v", key, first, second)
+}
+
+// newGraph creates an empty graph of the same kind as the given graphs,
+// which must all be directed or all undirected
+func newGraph(gs ...graph.Graph) (build.Graph, error) {
+	if len(gs) == 0 {
+		return nil, fmt.Errorf("No graphs supplied")
+	}
+	directed := graph.IsDirected(gs[0])
+	for _, g := range gs[1:] {
+		if graph.IsDirected(g) != directed {
+			return nil, fmt.Errorf("Graphs must all be directed or all be undirected")
+		}
+	}
+	return build.New(directed), nil
+}
+
+// merge adds attributes to a set, using the policy for values that differ
+func merge(into map[interface{}]interface{}, attrs *map[interface{}]interface{}, policy Policy) error {
+	if attrs == nil {
+		return nil
+	}
+	for key, value := range *attrs {
+		existing, exists := into[key]
+		if !exists || reflect.DeepEqual(existing, value) {
+			into[key] = value
+			continue
+		}
+		resolved, err := policy(key, existing, value)
+		if err != nil {
+			return err
+		}
+		into[key] = resolved
+	}
+	return nil
+}
+
+// Union returns a graph with the nodes and edges of all of the given
+// graphs.  Nodes and edges in more than one graph, along with the graphs'
+// defaults, take the attributes of all of them, with the policy deciding
+// between different values.  A nil policy is the same as Fail
+func Union(policy Policy, gs ...graph.Graph) (graph.Graph, error) {
+	res, err := newGraph(gs...)
+	if err != nil {
+		return nil, err
+	}
+	if policy == nil {
+		policy = Fail
+	}
+	graphDefaults := make(map[interface{}]interface{})
+	nodeDefaults := make(map[interface{}]interface{})
+	edgeDefaults := make(map[interface{}]interface{})
+	for _, g := range gs {
+		if err := merge(graphDefaults, g.GraphDefaults(), policy); err != nil {
+			return nil, fmt.Errorf("Graph defaults: %v", err)
+		}
+		if err := merge(nodeDefaults, g.NodeDefaults(), policy); err != nil {
+			return nil, fmt.Errorf("Node defaults: %v", err)
+		}
+		if err := merge(edgeDefaults, g.EdgeDefaults(), policy); err != nil {
+			return nil, fmt.Errorf("Edge defaults: %v", err)
+		}
+		for _, nid := range build.NodeIDs(g) {
+			if !res.HasNode(nid) {
+				if err := build.AddNode(res, nid, build.CopyAttributes(g.Node(nid).Attributes())); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			if err := merge(*res.Node(nid).Attributes(), g.Node(nid).Attributes(), policy); err != nil {
+				return nil, fmt.Errorf("Node %v: %v", nid, err)
+			}
+		}
+		for _, nid := range build.NodeIDs(g) {
+			for _, edge := range build.OwnedEdges(g, nid) {
+				if !res.HasEdge(edge.From(), edge.To()) {
+					if err := build.AddEdge(res, edge.From(), edge.To(), build.CopyAttributes(edge.Attributes())); err != nil {
+						return nil, err
+					}
+					continue
+				}
+				if err := merge(*res.Edge(edge.From(), edge.To()).Attributes(), edge.Attributes(), policy); err != nil {
+					return nil, fmt.Errorf("Edge from %v to %v: %v", edge.From(), edge.To(), err)
+				}
+			}
+		}
+	}
+	res.SetGraphDefaults(graphDefaults)
+	res.SetNodeDefaults(nodeDefaults)
+	res.SetEdgeDefaults(edgeDefaults)
+	return res, nil
+}
+
+// Intersection returns a graph with the nodes and edges that are in both
+// graphs.  Nodes, edges and defaults take their attributes from the first
+// graph
+func Intersection(g1, g2 graph.Graph) (graph.Graph, error) {
+	res, err := newGraph(g1, g2)
+	if err != nil {
+		return nil, err
+	}
+	build.CopyDefaults(res, g1)
+	for _, nid := range build.NodeIDs(g1) {
+		if g2.HasNode(nid) {
+			if err := build.AddNode(res, nid, build.CopyAttributes(g1.Node(nid).Attributes())); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for _, nid := range build.NodeIDs(res) {
+		for _, edge := range build.OwnedEdges(g1, nid) {
+			if g2.HasEdge(edge.From(), edge.To()) {
+				if err := build.AddEdge(res, edge.From(), edge.To(), build.CopyAttributes(edge.Attributes())); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	return res, nil
+}
+
+// Difference returns a graph with all of the nodes of the first graph and
+// the edges of the first graph that are not in the second.  Nodes, edges
+// and defaults take their attributes from the first graph
+func Difference(g1, g2 graph.Graph) (graph.Graph, error) {
+	res, err := newGraph(g1, g2)
+	if err != nil {
+		return nil, err
+	}
+	build.CopyDefaults(res, g1)
+	for _, nid := range build.NodeIDs(g1) {
+		if err := build.AddNode(res, nid, build.CopyAttributes(g1.Node(nid).Attributes())); err != nil {
+			return nil, err
+		}
+	}
+	for _, nid := range build.NodeIDs(g1) {
+		for _, edge := range build.OwnedEdges(g1, nid) {
+			if !g2.HasEdge(edge.From(), edge.To()) {
+				if err := build.AddEdge(res, edge.From(), edge.To(), build.CopyAttributes(edge.Attributes())); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	return res, nil
+}
+
+// SymmetricDifference returns a graph with the nodes of both graphs and
+// the edges that are in exactly one of them.  Edges take their attributes
+// from the graph they are in; nodes and defaults take theirs from the
+// first graph where they are in both
+func SymmetricDifference(g1, g2 graph.Graph) (graph.Graph, error) {
+	res, err := newGraph(g1, g2)
+	if err != nil {
+		return nil, err
+	}
+	build.CopyDefaults(res, g1)
+	for _, g := range []graph.Graph{g1, g2} {
+		for _, nid := range build.NodeIDs(g) {
+			if !res.HasNode(nid) {
+				if err := build.AddNode(res, nid, build.CopyAttributes(g.Node(nid).Attributes())); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	for _, pair := range [][2]graph.Graph{{g1, g2}, {g2, g1}} {
+		for _, nid := range build.NodeIDs(pair[0]) {
+			for _, edge := range build.OwnedEdges(pair[0], nid) {
+				if !pair[1].HasEdge(edge.From(), edge.To()) {
+					if err := build.AddEdge(res, edge.From(), edge.To(), build.CopyAttributes(edge.Attributes())); err != nil {
+						return nil, err
+					}
+				}
+			}
+		}
+	}
+	return res, nil
+}
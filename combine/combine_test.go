@@ -0,0 +1,182 @@
+package combine
+
+// Copyright © 2018 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wealdtech/go-graph"
+	"github.com/wealdtech/go-graph/edges"
+	"github.com/wealdtech/go-graph/exporters/dot"
+	"github.com/wealdtech/go-graph/graphs"
+	"github.com/wealdtech/go-graph/nodes"
+)
+
+func directed(t *testing.T, ids []int64, links [][2]int64) *graphs.DirectedGraph {
+	g := graphs.NewDirectedGraph()
+	for _, id := range ids {
+		assert.NoError(t, g.AddNode(nodes.NewSimpleNode(id)))
+	}
+	for _, link := range links {
+		assert.NoError(t, g.AddEdge(edges.NewDirectedEdge(link[0], link[1])))
+	}
+	return g
+}
+
+func undirected(t *testing.T, ids []int64, links [][2]int64) *graphs.UndirectedGraph {
+	g := graphs.NewUndirectedGraph()
+	for _, id := range ids {
+		assert.NoError(t, g.AddNode(nodes.NewSimpleNode(id)))
+	}
+	for _, link := range links {
+		assert.NoError(t, g.AddEdge(edges.NewUndirectedEdge(link[0], link[1])))
+	}
+	return g
+}
+
+func marshal(g graph.Graph) string {
+	return string(dot.Marshal(g))
+}
+
+func TestUnion(t *testing.T) {
+	g1 := undirected(t, []int64{1, 2, 3}, [][2]int64{{1, 2}, {2, 3}})
+	g1.Node(1).SetAttribute("os", "linux")
+	g1.Node(2).SetAttribute("ip", "10.0.0.2")
+	g1.Edge(1, 2).SetAttribute("port", "22")
+	g1.SetNodeDefaults(map[interface{}]interface{}{"shape": "box"})
+	g2 := undirected(t, []int64{1, 2, 4}, [][2]int64{{2, 1}, {2, 4}})
+	g2.Node(1).SetAttribute("os", "linux")
+	g2.Node(1).SetAttribute("rack", "a1")
+	g2.Node(2).SetAttribute("ip", "10.0.0.3")
+	g2.Edge(1, 2).SetAttribute("speed", "1G")
+
+	_, err := Union(nil, g1, g2)
+	assert.EqualError(t, err, "Node 2: Attribute ip has conflicting values 10.0.0.2 and 10.0.0.3")
+
+	res, err := Union(KeepFirst, g1, g2)
+	assert.NoError(t, err)
+	assert.Equal(t, `graph g {
+  node [ shape="box" ];
+  1 [ os="linux" rack="a1" ];
+  1 -- 2 [ port="22" speed="1G" ];
+  2 [ ip="10.0.0.2" ];
+  2 -- 3;
+  2 -- 4;
+  3;
+  4;
+}`, marshal(res))
+
+	res, err = Union(KeepSecond, g1, g2)
+	assert.NoError(t, err)
+	assert.Equal(t, "10.0.0.3", res.Node(2).Attribute("ip"))
+	// The inputs are unchanged
+	assert.Equal(t, "10.0.0.2", g1.Node(2).Attribute("ip"))
+	assert.Nil(t, g1.Node(1).Attribute("rack"))
+
+	_, err = Union(nil, g1, directed(t, nil, nil))
+	assert.EqualError(t, err, "Graphs must all be directed or all be undirected")
+	_, err = Union(nil)
+	assert.EqualError(t, err, "No graphs supplied")
+}
+
+func TestSetOperations(t *testing.T) {
+	g1 := directed(t, []int64{1, 2, 3}, [][2]int64{{1, 2}, {2, 3}, {3, 1}})
+	g1.Edge(1, 2).SetAttribute("label", "first")
+	g2 := directed(t, []int64{1, 2, 4}, [][2]int64{{1, 2}, {2, 1}, {2, 4}})
+	g2.Edge(1, 2).SetAttribute("label", "second")
+
+	res, err := Intersection(g1, g2)
+	assert.NoError(t, err)
+	assert.Equal(t, `digraph g {
+  1;
+  1 -> 2 [ label="first" ];
+  2;
+}`, marshal(res))
+
+	res, err = Difference(g1, g2)
+	assert.NoError(t, err)
+	assert.Equal(t, `digraph g {
+  1;
+  2;
+  2 -> 3;
+  3;
+  3 -> 1;
+}`, marshal(res))
+
+	res, err = SymmetricDifference(g1, g2)
+	assert.NoError(t, err)
+	assert.Equal(t, `digraph g {
+  1;
+  2;
+  2 -> 1;
+  2 -> 3;
+  2 -> 4;
+  3;
+  3 -> 1;
+  4;
+}`, marshal(res))
+}
+
+func TestDisjointUnion(t *testing.T) {
+	g1 := undirected(t, []int64{10, 20}, [][2]int64{{10, 20}})
+	g1.Node(10).SetAttribute("name", "a")
+	g2 := undirected(t, []int64{10, 30, 40}, [][2]int64{{10, 40}, {30, 40}})
+
+	res, mappings, err := DisjointUnion(g1, g2)
+	assert.NoError(t, err)
+	assert.Equal(t, []map[int64]int64{{10: 1, 20: 2}, {10: 3, 30: 4, 40: 5}}, mappings)
+	assert.Equal(t, `graph g {
+  1 [ name="a" ];
+  1 -- 2;
+  2;
+  3;
+  3 -- 5;
+  4;
+  4 -- 5;
+  5;
+}`, marshal(res))
+}
+
+func TestCompose(t *testing.T) {
+	// Hosts to services, then services to teams
+	g1 := directed(t, []int64{1, 2, 10, 11}, [][2]int64{{1, 10}, {2, 10}, {2, 11}})
+	g2 := directed(t, []int64{10, 11, 20, 21}, [][2]int64{{10, 20}, {11, 20}, {11, 21}})
+	res, err := Compose(g1, g2)
+	assert.NoError(t, err)
+	assert.Equal(t, `digraph g {
+  1;
+  1 -> 20;
+  2;
+  2 -> 20;
+  2 -> 21;
+  10;
+  11;
+  20;
+  21;
+}`, marshal(res))
+
+	// Two steps along a path
+	path := undirected(t, []int64{1, 2, 3, 4}, [][2]int64{{1, 2}, {2, 3}, {3, 4}})
+	res, err = Compose(path, path)
+	assert.NoError(t, err)
+	assert.Equal(t, `graph g {
+  1;
+  1 -- 3;
+  2;
+  2 -- 4;
+  3;
+  4;
+}`, marshal(res))
+}
@@ -0,0 +1,99 @@
+package combine
+
+// Copyright © 2018 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"github.com/wealdtech/go-graph"
+	"github.com/wealdtech/go-graph/internal/build"
+)
+
+// DisjointUnion returns a graph containing a separate copy of each of the
+// given graphs.  Nodes are renumbered from 1, graph by graph in order of
+// ID, and the mapping from each graph's node IDs to the new IDs is
+// returned alongside.  Defaults are taken from the first graph
+func DisjointUnion(gs ...graph.Graph) (graph.Graph, []map[int64]int64, error) {
+	res, err := newGraph(gs...)
+	if err != nil {
+		return nil, nil, err
+	}
+	build.CopyDefaults(res, gs[0])
+	mappings := make([]map[int64]int64, len(gs))
+	next := int64(1)
+	for i, g := range gs {
+		mappings[i] = make(map[int64]int64)
+		for _, nid := range build.NodeIDs(g) {
+			mappings[i][nid] = next
+			if err := build.AddNode(res, next, build.CopyAttributes(g.Node(nid).Attributes())); err != nil {
+				return nil, nil, err
+			}
+			next++
+		}
+		for _, nid := range build.NodeIDs(g) {
+			for _, edge := range build.OwnedEdges(g, nid) {
+				if err := build.AddEdge(res, mappings[i][edge.From()], mappings[i][edge.To()], build.CopyAttributes(edge.Attributes())); err != nil {
+					return nil, nil, err
+				}
+			}
+		}
+	}
+	return res, mappings, nil
+}
+
+// Compose returns the composition of two graphs, which has an edge from a
+// to c wherever the first graph has an edge from a to some b and the
+// second has an edge from b to c.  It has the nodes of both graphs, taking
+// attributes and defaults from the first graph where they are in both.
+// Composing undirected graphs does not create self-loops, as every edge
+// would otherwise create one at each end
+func Compose(g1, g2 graph.Graph) (graph.Graph, error) {
+	res, err := newGraph(g1, g2)
+	if err != nil {
+		return nil, err
+	}
+	directed := graph.IsDirected(g1)
+	build.CopyDefaults(res, g1)
+	for _, g := range []graph.Graph{g1, g2} {
+		for _, nid := range build.NodeIDs(g) {
+			if !res.HasNode(nid) {
+				if err := build.AddNode(res, nid, build.CopyAttributes(g.Node(nid).Attributes())); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	for _, a := range build.NodeIDs(g1) {
+		for _, first := range g1.Edges(a) {
+			b := first.To()
+			if !directed && b == a {
+				b = first.From()
+			}
+			if !g2.HasNode(b) {
+				continue
+			}
+			for _, second := range g2.Edges(b) {
+				c := second.To()
+				if !directed && c == b {
+					c = second.From()
+				}
+				if (!directed && c == a) || res.HasEdge(a, c) {
+					continue
+				}
+				if err := build.AddEdge(res, a, c, make(map[interface{}]interface{})); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	return res, nil
+}
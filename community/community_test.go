@@ -0,0 +1,100 @@
+package community
+
+// Copyright © 2018 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wealdtech/go-graph/edges"
+	"github.com/wealdtech/go-graph/graphs"
+	"github.com/wealdtech/go-graph/nodes"
+)
+
+// twoCliques creates two 4-cliques (1-4 and 5-8) joined by an edge from 4 to 5
+func twoCliques(t *testing.T) *graphs.UndirectedGraph {
+	g := graphs.NewUndirectedGraph()
+	for i := int64(1); i <= 8; i++ {
+		assert.NoError(t, g.AddNode(nodes.NewSimpleNode(i)))
+	}
+	for _, base := range []int64{1, 5} {
+		for i := base; i < base+4; i++ {
+			for j := i + 1; j < base+4; j++ {
+				assert.NoError(t, g.AddEdge(edges.NewUndirectedEdge(i, j)))
+			}
+		}
+	}
+	assert.NoError(t, g.AddEdge(edges.NewUndirectedEdge(4, 5)))
+	return g
+}
+
+func assertTwoCliques(t *testing.T, res *Result) {
+	assert.Equal(t, [][]int64{{1, 2, 3, 4}, {5, 6, 7, 8}}, res.Partition.Communities())
+	assert.InDelta(t, 0.4230769, res.Modularity, 1e-6)
+}
+
+func TestModularity(t *testing.T) {
+	g := twoCliques(t)
+	p := Partition{1: 0, 2: 0, 3: 0, 4: 0, 5: 1, 6: 1, 7: 1, 8: 1}
+	assert.InDelta(t, 0.4230769, Modularity(g, p, nil, 1), 1e-6)
+
+	// All nodes in one community
+	p = Partition{1: 0, 2: 0, 3: 0, 4: 0, 5: 0, 6: 0, 7: 0, 8: 0}
+	assert.InDelta(t, 0, Modularity(g, p, nil, 1), 1e-9)
+}
+
+func TestLouvain(t *testing.T) {
+	assertTwoCliques(t, Louvain(twoCliques(t), nil, 1))
+}
+
+func TestLeiden(t *testing.T) {
+	assertTwoCliques(t, Leiden(twoCliques(t), nil, 1))
+}
+
+func TestLabelPropagation(t *testing.T) {
+	// Label propagation can flood across the bridge, so split the cliques
+	g := twoCliques(t)
+	g.RemoveEdge(4, 5)
+	res := LabelPropagation(g, nil, nil)
+	assert.Equal(t, [][]int64{{1, 2, 3, 4}, {5, 6, 7, 8}}, res.Partition.Communities())
+	assert.InDelta(t, 0.5, res.Modularity, 1e-6)
+
+	res = LabelPropagation(g, nil, rand.New(rand.NewSource(1)))
+	assert.Equal(t, [][]int64{{1, 2, 3, 4}, {5, 6, 7, 8}}, res.Partition.Communities())
+}
+
+func TestGirvanNewman(t *testing.T) {
+	assertTwoCliques(t, GirvanNewman(twoCliques(t), nil))
+}
+
+func TestNoEdges(t *testing.T) {
+	g := graphs.NewUndirectedGraph()
+	for i := int64(1); i <= 3; i++ {
+		assert.NoError(t, g.AddNode(nodes.NewSimpleNode(i)))
+	}
+	res := Louvain(g, nil, 1)
+	assert.Equal(t, [][]int64{{1}, {2}, {3}}, res.Partition.Communities())
+	assert.Equal(t, 0.0, res.Modularity)
+	res = Leiden(g, nil, 1)
+	assert.Equal(t, [][]int64{{1}, {2}, {3}}, res.Partition.Communities())
+}
+
+func TestSetNodeAttributes(t *testing.T) {
+	g := twoCliques(t)
+	res := Louvain(g, nil, 1)
+	res.Partition.SetNodeAttributes(g, "community")
+	assert.Equal(t, 0, g.Node(1).Attribute("community"))
+	assert.Equal(t, 1, g.Node(8).Attribute("community"))
+}
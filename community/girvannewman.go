@@ -0,0 +1,152 @@
+package community
+
+// Copyright © 2018 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"github.com/wealdtech/go-graph"
+)
+
+// GirvanNewman finds communities in an undirected graph by repeatedly
+// removing the edge with the highest betweenness, returning the partition
+// into connected components with the highest modularity seen along the
+// way.  Betweenness ignores weights; the weight function is used only when
+// calculating modularity, with nil treating all edges as having weight 1.
+// This is expensive, and only suitable for small graphs
+func GirvanNewman(g graph.Graph, weight graph.WeightFunc) *Result {
+	net, ids := newNetwork(g, weight)
+
+	adj := make([]map[int]bool, net.size())
+	for i := range net.links {
+		adj[i] = make(map[int]bool)
+		for _, l := range net.links[i] {
+			if l.to != i {
+				adj[i][l.to] = true
+			}
+		}
+	}
+
+	best, components := connectedComponents(adj)
+	bestModularity := net.modularity(best, 1)
+	for {
+		a, b, found := highestBetweenness(adj)
+		if !found {
+			break
+		}
+		delete(adj[a], b)
+		delete(adj[b], a)
+		community, count := connectedComponents(adj)
+		if count > components {
+			components = count
+			modularity := net.modularity(community, 1)
+			if modularity > bestModularity+epsilon {
+				best = community
+				bestModularity = modularity
+			}
+		}
+	}
+	return newResult(net, ids, best, 1)
+}
+
+// highestBetweenness returns the edge with the highest betweenness, using
+// Brandes' algorithm.  Ties are broken by lowest node indices
+func highestBetweenness(adj []map[int]bool) (int, int, bool) {
+	n := len(adj)
+	betweenness := make(map[[2]int]float64)
+	for s := 0; s < n; s++ {
+		stack := make([]int, 0, n)
+		predecessors := make([][]int, n)
+		sigma := make([]float64, n)
+		distance := make([]int, n)
+		for i := range distance {
+			distance[i] = -1
+		}
+		sigma[s] = 1
+		distance[s] = 0
+		queue := []int{s}
+		for len(queue) > 0 {
+			v := queue[0]
+			queue = queue[1:]
+			stack = append(stack, v)
+			for w := range adj[v] {
+				if distance[w] < 0 {
+					distance[w] = distance[v] + 1
+					queue = append(queue, w)
+				}
+				if distance[w] == distance[v]+1 {
+					sigma[w] += sigma[v]
+					predecessors[w] = append(predecessors[w], v)
+				}
+			}
+		}
+		delta := make([]float64, n)
+		for i := len(stack) - 1; i >= 0; i-- {
+			w := stack[i]
+			for _, v := range predecessors[w] {
+				c := sigma[v] / sigma[w] * (1 + delta[w])
+				if v < w {
+					betweenness[[2]int{v, w}] += c
+				} else {
+					betweenness[[2]int{w, v}] += c
+				}
+				delta[v] += c
+			}
+		}
+	}
+
+	var best [2]int
+	bestValue := -1.0
+	for a := 0; a < n; a++ {
+		for b := range adj[a] {
+			if b < a {
+				continue
+			}
+			key := [2]int{a, b}
+			value := betweenness[key]
+			if value > bestValue+epsilon || (value > bestValue-epsilon && (key[0] < best[0] || (key[0] == best[0] && key[1] < best[1]))) {
+				best = key
+				bestValue = value
+			}
+		}
+	}
+	return best[0], best[1], bestValue >= 0
+}
+
+// connectedComponents labels each node with its component, returning the
+// labels and number of components
+func connectedComponents(adj []map[int]bool) ([]int, int) {
+	component := make([]int, len(adj))
+	for i := range component {
+		component[i] = -1
+	}
+	count := 0
+	for s := range adj {
+		if component[s] >= 0 {
+			continue
+		}
+		component[s] = count
+		stack := []int{s}
+		for len(stack) > 0 {
+			v := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			for w := range adj[v] {
+				if component[w] < 0 {
+					component[w] = count
+					stack = append(stack, w)
+				}
+			}
+		}
+		count++
+	}
+	return component, count
+}
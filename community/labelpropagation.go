@@ -0,0 +1,73 @@
+package community
+
+// Copyright © 2018 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"math/rand"
+
+	"github.com/wealdtech/go-graph"
+)
+
+// maxLabelPropagationRounds bounds the number of rounds label propagation
+// carries out, in case of oscillation
+const maxLabelPropagationRounds = 1000
+
+// LabelPropagation finds communities in an undirected graph using
+// asynchronous label propagation.  If rng is nil nodes are visited in ID
+// order and ties are broken by lowest label, giving deterministic results;
+// otherwise both are randomised.  A nil weight function treats all edges as
+// having weight 1
+func LabelPropagation(g graph.Graph, weight graph.WeightFunc, rng *rand.Rand) *Result {
+	net, ids := newNetwork(g, weight)
+	labels := identity(net.size())
+	order := identity(net.size())
+	for round := 0; round < maxLabelPropagationRounds; round++ {
+		if rng != nil {
+			rng.Shuffle(len(order), func(i, j int) { order[i], order[j] = order[j], order[i] })
+		}
+		changed := false
+		for _, i := range order {
+			weights := net.neighbourCommunities(i, labels)
+			if len(weights) == 0 {
+				continue
+			}
+			max := 0.0
+			for _, w := range weights {
+				if w > max {
+					max = w
+				}
+			}
+			if weights[labels[i]] >= max-epsilon {
+				// Current label is already one of the most popular
+				continue
+			}
+			candidates := make([]int, 0)
+			for _, label := range sortedCommunities(weights) {
+				if weights[label] >= max-epsilon {
+					candidates = append(candidates, label)
+				}
+			}
+			if rng != nil {
+				labels[i] = candidates[rng.Intn(len(candidates))]
+			} else {
+				labels[i] = candidates[0]
+			}
+			changed = true
+		}
+		if !changed {
+			break
+		}
+	}
+	return newResult(net, ids, labels, 1)
+}
@@ -0,0 +1,217 @@
+package community
+
+// Copyright © 2018 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"github.com/wealdtech/go-graph"
+)
+
+// Leiden finds communities in an undirected graph using the Leiden
+// algorithm.  Unlike Louvain the communities it finds are guaranteed to be
+// connected.  Refinement is greedy, so results are deterministic.  A nil
+// weight function treats all edges as having weight 1; a resolution of 1
+// gives standard modularity
+func Leiden(g graph.Graph, weight graph.WeightFunc, resolution float64) *Result {
+	net, ids := newNetwork(g, weight)
+	original := net
+	membership := identity(net.size())
+	community := identity(net.size())
+	for {
+		net.fastMoveNodes(community, resolution)
+		count := renumber(community)
+		if count == net.size() {
+			break
+		}
+
+		refined := net.refine(community, resolution)
+		refinedCount := renumber(refined)
+		if refinedCount == net.size() {
+			// Refinement merged nothing so aggregate on the communities themselves
+			copy(refined, community)
+			refinedCount = count
+		}
+
+		aggregateCommunity := make([]int, refinedCount)
+		for i := range refined {
+			aggregateCommunity[refined[i]] = community[i]
+		}
+		for i := range membership {
+			membership[i] = refined[membership[i]]
+		}
+		net = net.aggregate(refined, refinedCount)
+		community = aggregateCommunity
+	}
+
+	final := make([]int, len(membership))
+	for i := range membership {
+		final[i] = community[membership[i]]
+	}
+	return newResult(original, ids, final, resolution)
+}
+
+// fastMoveNodes moves nodes to the community that gives the largest increase
+// in modularity, revisiting only the neighbours of nodes that have moved
+func (n *network) fastMoveNodes(community []int, resolution float64) {
+	if n.total == 0 {
+		return
+	}
+	totals := make([]float64, n.size())
+	sizes := make([]int, n.size())
+	for i := range community {
+		totals[community[i]] += n.degrees[i]
+		sizes[community[i]]++
+	}
+	empty := make([]int, 0)
+	for c := len(sizes) - 1; c >= 0; c-- {
+		if sizes[c] == 0 {
+			empty = append(empty, c)
+		}
+	}
+
+	queue := identity(n.size())
+	queued := make([]bool, n.size())
+	for i := range queued {
+		queued[i] = true
+	}
+	for len(queue) > 0 {
+		i := queue[0]
+		queue = queue[1:]
+		queued[i] = false
+
+		current := community[i]
+		neighbours := n.neighbourCommunities(i, community)
+		totals[current] -= n.degrees[i]
+		sizes[current]--
+		if sizes[current] == 0 {
+			empty = append(empty, current)
+		}
+
+		best := current
+		bestGain := neighbours[current] - resolution*totals[current]*n.degrees[i]/n.total
+		for _, c := range sortedCommunities(neighbours) {
+			gain := neighbours[c] - resolution*totals[c]*n.degrees[i]/n.total
+			if gain > bestGain+epsilon {
+				best = c
+				bestGain = gain
+			}
+		}
+		if sizes[current] > 0 && bestGain < -epsilon && len(empty) > 0 {
+			// Better off alone
+			best = empty[len(empty)-1]
+		}
+
+		if sizes[best] == 0 {
+			empty = removeCommunity(empty, best)
+		}
+		totals[best] += n.degrees[i]
+		sizes[best]++
+		if best != current {
+			community[i] = best
+			for _, l := range n.links[i] {
+				if l.to != i && community[l.to] != best && !queued[l.to] {
+					queue = append(queue, l.to)
+					queued[l.to] = true
+				}
+			}
+		}
+	}
+}
+
+// refine splits each community into well-connected sub-communities, by
+// greedily merging singleton nodes within the community
+func (n *network) refine(community []int, resolution float64) []int {
+	refined := identity(n.size())
+	if n.total == 0 {
+		return refined
+	}
+
+	// Total degree of each community
+	communityTotals := make(map[int]float64)
+	for i, c := range community {
+		communityTotals[c] += n.degrees[i]
+	}
+
+	// Weight of links from each node to the rest of its community
+	internal := make([]float64, n.size())
+	for i := range n.links {
+		for _, l := range n.links[i] {
+			if l.to != i && community[l.to] == community[i] {
+				internal[i] += l.weight
+			}
+		}
+	}
+
+	// Per refined community: total degree, size and weight of links to the
+	// rest of the enclosing community
+	totals := make([]float64, n.size())
+	external := make([]float64, n.size())
+	sizes := make([]int, n.size())
+	for i := range refined {
+		totals[i] = n.degrees[i]
+		external[i] = internal[i]
+		sizes[i] = 1
+	}
+
+	for i := range n.links {
+		if sizes[refined[i]] != 1 {
+			continue
+		}
+		communityTotal := communityTotals[community[i]]
+		if internal[i] < resolution*n.degrees[i]*(communityTotal-n.degrees[i])/n.total {
+			// Not well-connected
+			continue
+		}
+
+		candidates := make(map[int]float64)
+		for _, l := range n.links[i] {
+			if l.to != i && community[l.to] == community[i] {
+				candidates[refined[l.to]] += l.weight
+			}
+		}
+		current := refined[i]
+		best := current
+		bestGain := 0.0
+		for _, r := range sortedCommunities(candidates) {
+			if r == current {
+				continue
+			}
+			if external[r] < resolution*totals[r]*(communityTotal-totals[r])/n.total {
+				// Not well-connected
+				continue
+			}
+			gain := candidates[r] - resolution*n.degrees[i]*totals[r]/n.total
+			if gain >= bestGain-epsilon && (best == current || gain > bestGain+epsilon) {
+				best = r
+				bestGain = gain
+			}
+		}
+		if best != current {
+			external[best] = external[best] + internal[i] - 2*candidates[best]
+			totals[best] += n.degrees[i]
+			sizes[best]++
+			sizes[current]--
+			refined[i] = best
+		}
+	}
+	return refined
+}
+
+func removeCommunity(communities []int, c int) []int {
+	for i := range communities {
+		if communities[i] == c {
+			return append(communities[:i], communities[i+1:]...)
+		}
+	}
+	return communities
+}
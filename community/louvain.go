@@ -0,0 +1,101 @@
+package community
+
+// Copyright © 2018 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"sort"
+
+	"github.com/wealdtech/go-graph"
+)
+
+// Louvain finds communities in an undirected graph using the Louvain
+// modularity optimisation method.  A nil weight function treats all edges
+// as having weight 1; a resolution of 1 gives standard modularity
+func Louvain(g graph.Graph, weight graph.WeightFunc, resolution float64) *Result {
+	net, ids := newNetwork(g, weight)
+	original := net
+	membership := identity(net.size())
+	for {
+		community := identity(net.size())
+		if !net.moveNodes(community, resolution) {
+			break
+		}
+		count := renumber(community)
+		for i := range membership {
+			membership[i] = community[membership[i]]
+		}
+		net = net.aggregate(community, count)
+	}
+	return newResult(original, ids, membership, resolution)
+}
+
+// moveNodes repeatedly moves individual nodes to the neighbouring community
+// that gives the largest increase in modularity, until no move improves it.
+// It returns true if any node moved
+func (n *network) moveNodes(community []int, resolution float64) bool {
+	if n.total == 0 {
+		return false
+	}
+	totals := make([]float64, n.size())
+	for i := range community {
+		totals[community[i]] += n.degrees[i]
+	}
+
+	improved := false
+	for changed := true; changed; {
+		changed = false
+		for i := range n.links {
+			current := community[i]
+			neighbours := n.neighbourCommunities(i, community)
+			totals[current] -= n.degrees[i]
+			best := current
+			bestGain := neighbours[current] - resolution*totals[current]*n.degrees[i]/n.total
+			for _, c := range sortedCommunities(neighbours) {
+				gain := neighbours[c] - resolution*totals[c]*n.degrees[i]/n.total
+				if gain > bestGain+epsilon {
+					best = c
+					bestGain = gain
+				}
+			}
+			totals[best] += n.degrees[i]
+			if best != current {
+				community[i] = best
+				changed = true
+				improved = true
+			}
+		}
+	}
+	return improved
+}
+
+// neighbourCommunities returns the weight of links from a node to each of
+// its neighbouring communities, ignoring self-loops
+func (n *network) neighbourCommunities(i int, community []int) map[int]float64 {
+	neighbours := make(map[int]float64)
+	for _, l := range n.links[i] {
+		if l.to != i {
+			neighbours[community[l.to]] += l.weight
+		}
+	}
+	return neighbours
+}
+
+func sortedCommunities(neighbours map[int]float64) []int {
+	communities := make([]int, 0, len(neighbours))
+	for c := range neighbours {
+		communities = append(communities, c)
+	}
+	sort.Ints(communities)
+	return communities
+}
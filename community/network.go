@@ -0,0 +1,166 @@
+package community
+
+// Copyright © 2018 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"sort"
+
+	"github.com/wealdtech/go-graph"
+)
+
+// epsilon is the minimum gain for a move to be considered an improvement
+const epsilon = 1e-12
+
+type link struct {
+	to     int
+	weight float64
+}
+
+// network is a compact weighted undirected representation of a graph.
+// Self-loops are stored with double their weight so that the degree of a
+// node is the sum of its links
+type network struct {
+	links   [][]link
+	degrees []float64
+	total   float64
+}
+
+// newNetwork builds a network from a graph, returning it along with the
+// sorted node IDs that its indices refer to
+func newNetwork(g graph.Graph, weight graph.WeightFunc) (*network, []int64) {
+	if weight == nil {
+		weight = graph.UnitWeight
+	}
+	ids := sortedNodeIDs(g)
+	index := make(map[int64]int, len(ids))
+	for i, nid := range ids {
+		index[nid] = i
+	}
+
+	type pair struct{ from, to int64 }
+	seen := make(map[pair]bool)
+	adj := make([]map[int]float64, len(ids))
+	for i := range adj {
+		adj[i] = make(map[int]float64)
+	}
+	for _, nid := range ids {
+		for _, edge := range g.Edges(nid) {
+			p := pair{edge.From(), edge.To()}
+			if seen[p] {
+				continue
+			}
+			seen[p] = true
+			a, aok := index[edge.From()]
+			b, bok := index[edge.To()]
+			if !aok || !bok {
+				continue
+			}
+			w := weight(edge)
+			adj[a][b] += w
+			adj[b][a] += w
+		}
+	}
+	return newNetworkFromMaps(adj), ids
+}
+
+func newNetworkFromMaps(adj []map[int]float64) *network {
+	n := &network{
+		links:   make([][]link, len(adj)),
+		degrees: make([]float64, len(adj)),
+	}
+	for i := range adj {
+		n.links[i] = make([]link, 0, len(adj[i]))
+		for j, w := range adj[i] {
+			n.links[i] = append(n.links[i], link{to: j, weight: w})
+		}
+		sort.Slice(n.links[i], func(x, y int) bool { return n.links[i][x].to < n.links[i][y].to })
+		for _, l := range n.links[i] {
+			n.degrees[i] += l.weight
+		}
+		n.total += n.degrees[i]
+	}
+	return n
+}
+
+func (n *network) size() int {
+	return len(n.links)
+}
+
+// aggregate builds a network where each node is a community of this network
+func (n *network) aggregate(community []int, count int) *network {
+	adj := make([]map[int]float64, count)
+	for i := range adj {
+		adj[i] = make(map[int]float64)
+	}
+	for i := range n.links {
+		for _, l := range n.links[i] {
+			adj[community[i]][community[l.to]] += l.weight
+		}
+	}
+	return newNetworkFromMaps(adj)
+}
+
+// modularity calculates the modularity of a partition of the network
+func (n *network) modularity(community []int, resolution float64) float64 {
+	if n.total == 0 {
+		return 0
+	}
+	internal := make(map[int]float64)
+	totals := make(map[int]float64)
+	for i := range n.links {
+		totals[community[i]] += n.degrees[i]
+		for _, l := range n.links[i] {
+			if community[l.to] == community[i] {
+				internal[community[i]] += l.weight
+			}
+		}
+	}
+	q := 0.0
+	for c, tot := range totals {
+		q += internal[c]/n.total - resolution*(tot/n.total)*(tot/n.total)
+	}
+	return q
+}
+
+// renumber renumbers community labels to be 0..count-1 in order of first
+// appearance, returning the number of communities
+func renumber(community []int) int {
+	labels := make(map[int]int)
+	for i, c := range community {
+		label, exists := labels[c]
+		if !exists {
+			label = len(labels)
+			labels[c] = label
+		}
+		community[i] = label
+	}
+	return len(labels)
+}
+
+func identity(n int) []int {
+	res := make([]int, n)
+	for i := range res {
+		res[i] = i
+	}
+	return res
+}
+
+func sortedNodeIDs(g graph.Graph) []int64 {
+	ids := make([]int64, 0)
+	for _, node := range g.Nodes() {
+		ids = append(ids, node.Id())
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
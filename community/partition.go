@@ -0,0 +1,88 @@
+package community
+
+// Copyright © 2018 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"sort"
+
+	"github.com/wealdtech/go-graph"
+)
+
+// Partition maps node IDs to community IDs.  Community IDs run from 0, in
+// order of the lowest node ID in each community
+type Partition map[int64]int
+
+// Result is the outcome of a community detection algorithm
+type Result struct {
+	Partition  Partition
+	Modularity float64
+}
+
+// Communities returns the node IDs in each community, indexed by community ID
+func (p Partition) Communities() [][]int64 {
+	count := 0
+	for _, c := range p {
+		if c+1 > count {
+			count = c + 1
+		}
+	}
+	communities := make([][]int64, count)
+	for nid, c := range p {
+		communities[c] = append(communities[c], nid)
+	}
+	for _, community := range communities {
+		sort.Slice(community, func(i, j int) bool { return community[i] < community[j] })
+	}
+	return communities
+}
+
+// SetNodeAttributes writes the community ID of each node in the graph to
+// the given node attribute
+func (p Partition) SetNodeAttributes(g graph.Graph, key interface{}) {
+	for nid, c := range p {
+		node := g.Node(nid)
+		if node != nil {
+			node.SetAttribute(key, c)
+		}
+	}
+}
+
+// Modularity calculates the modularity of a partition of an undirected
+// graph.  A nil weight function treats all edges as having weight 1
+func Modularity(g graph.Graph, p Partition, weight graph.WeightFunc, resolution float64) float64 {
+	net, ids := newNetwork(g, weight)
+	community := make([]int, len(ids))
+	for i, nid := range ids {
+		c, exists := p[nid]
+		if !exists {
+			// Nodes missing from the partition are in a community of their own
+			c = -1 - i
+		}
+		community[i] = c
+	}
+	return net.modularity(community, resolution)
+}
+
+// newResult creates a result from per-index community labels
+func newResult(net *network, ids []int64, community []int, resolution float64) *Result {
+	renumber(community)
+	partition := make(Partition, len(ids))
+	for i, nid := range ids {
+		partition[nid] = community[i]
+	}
+	return &Result{
+		Partition:  partition,
+		Modularity: net.modularity(community, resolution),
+	}
+}
@@ -0,0 +1,301 @@
+package cycles
+
+// Copyright © 2018 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"container/heap"
+	"fmt"
+	"math/bits"
+	"sort"
+
+	"github.com/wealdtech/go-graph"
+	"github.com/wealdtech/go-graph/internal/queue"
+)
+
+// undirected is an undirected view of a graph with numbered edges
+type undirected struct {
+	ids        []int64
+	neighbours [][]link
+	weights    []float64
+	loops      []link
+}
+
+type link struct {
+	to   int
+	edge int
+}
+
+func newUndirected(g graph.Graph, weight graph.WeightFunc) (*undirected, error) {
+	if weight == nil {
+		weight = graph.UnitWeight
+	}
+	nodes := g.Nodes()
+	u := &undirected{
+		ids:        make([]int64, 0, len(nodes)),
+		neighbours: make([][]link, len(nodes)),
+		weights:    make([]float64, 0),
+		loops:      make([]link, 0),
+	}
+	for _, node := range nodes {
+		u.ids = append(u.ids, node.Id())
+	}
+	sort.Slice(u.ids, func(i, j int) bool { return u.ids[i] < u.ids[j] })
+	index := make(map[int64]int, len(u.ids))
+	for i, nid := range u.ids {
+		index[nid] = i
+	}
+	for v, nid := range u.ids {
+		for _, edge := range g.Edges(nid) {
+			a, aok := index[edge.From()]
+			b, bok := index[edge.To()]
+			// Each edge is seen from both of its ends
+			if !aok || !bok || a != v || b < a {
+				continue
+			}
+			cost := weight(edge)
+			if cost < 0 {
+				return nil, fmt.Errorf("Edge from %v to %v has negative weight", edge.From(), edge.To())
+			}
+			e := len(u.weights)
+			u.weights = append(u.weights, cost)
+			if a == b {
+				u.loops = append(u.loops, link{to: a, edge: e})
+				continue
+			}
+			u.neighbours[a] = append(u.neighbours[a], link{to: b, edge: e})
+			u.neighbours[b] = append(u.neighbours[b], link{to: a, edge: e})
+		}
+	}
+	for _, list := range u.neighbours {
+		sort.Slice(list, func(i, j int) bool { return list[i].to < list[j].to })
+	}
+	return u, nil
+}
+
+// components returns the number of connected components
+func (u *undirected) components() int {
+	seen := make([]bool, len(u.ids))
+	count := 0
+	for root := range u.ids {
+		if seen[root] {
+			continue
+		}
+		count++
+		seen[root] = true
+		stack := []int{root}
+		for len(stack) > 0 {
+			v := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			for _, l := range u.neighbours[v] {
+				if !seen[l.to] {
+					seen[l.to] = true
+					stack = append(stack, l.to)
+				}
+			}
+		}
+	}
+	return count
+}
+
+// candidateCycle is a cycle considered for the basis
+type candidateCycle struct {
+	nodes  []int
+	edges  []uint64
+	weight float64
+}
+
+// MinimumCycleBasis returns a set of cycles of an undirected graph with
+// the least total weight from which every cycle can be formed by symmetric
+// difference.  Each cycle is given as node IDs in order around the cycle,
+// starting at its lowest ID; a self-loop is a cycle of length 1.  Cycles
+// are in order of increasing weight.  A nil weight gives every edge a
+// weight of 1; negative weights are not allowed.
+//
+// This uses Horton's algorithm: candidate cycles are formed from shortest
+// path trees and the lightest independent candidates are kept
+func MinimumCycleBasis(g graph.Graph, weight graph.WeightFunc) ([][]int64, error) {
+	if graph.IsDirected(g) {
+		return nil, fmt.Errorf("Minimum cycle basis requires an undirected graph")
+	}
+	u, err := newUndirected(g, weight)
+	if err != nil {
+		return nil, err
+	}
+	words := (len(u.weights) + 63) / 64
+	dimension := len(u.weights) - len(u.ids) + u.components()
+
+	candidates := make([]*candidateCycle, 0)
+	for _, l := range u.loops {
+		c := &candidateCycle{
+			nodes:  []int{l.to},
+			edges:  make([]uint64, words),
+			weight: u.weights[l.edge],
+		}
+		c.edges[l.edge/64] |= 1 << uint(l.edge%64)
+		candidates = append(candidates, c)
+	}
+	for root := range u.ids {
+		candidates = append(candidates, u.hortonCandidates(root, words)...)
+	}
+	for _, c := range candidates {
+		c.nodes = canonicalCycle(c.nodes)
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].weight != candidates[j].weight {
+			return candidates[i].weight < candidates[j].weight
+		}
+		return len(candidates[i].nodes) < len(candidates[j].nodes)
+	})
+
+	// Gaussian elimination over GF(2); each basis row has a distinct pivot
+	// that is clear in every other row added after it
+	rows := make([][]uint64, 0, dimension)
+	pivots := make([]int, 0, dimension)
+	res := make([][]int64, 0, dimension)
+	for _, c := range candidates {
+		if len(rows) == dimension {
+			break
+		}
+		vector := append([]uint64(nil), c.edges...)
+		for i, row := range rows {
+			if vector[pivots[i]/64]&(1<<uint(pivots[i]%64)) != 0 {
+				for k := range vector {
+					vector[k] ^= row[k]
+				}
+			}
+		}
+		pivot := -1
+		for k, word := range vector {
+			if word != 0 {
+				pivot = k*64 + bits.TrailingZeros64(word)
+				break
+			}
+		}
+		if pivot < 0 {
+			continue
+		}
+		rows = append(rows, vector)
+		pivots = append(pivots, pivot)
+		cycle := make([]int64, 0, len(c.nodes))
+		for _, v := range c.nodes {
+			cycle = append(cycle, u.ids[v])
+		}
+		res = append(res, cycle)
+	}
+	return res, nil
+}
+
+// hortonCandidates forms a cycle from each edge outside the shortest path
+// tree of a root whose ends lie in different branches of the tree
+func (u *undirected) hortonCandidates(root int, words int) []*candidateCycle {
+	n := len(u.ids)
+	distance := make([]float64, n)
+	parent := make([]int, n)
+	via := make([]int, n)
+	branch := make([]int, n)
+	reached := make([]bool, n)
+	done := make([]bool, n)
+	for v := range parent {
+		parent[v] = -1
+		via[v] = -1
+	}
+	reached[root] = true
+	branch[root] = root
+	pq := &queue.Priority{{ID: int64(root)}}
+	for pq.Len() > 0 {
+		item := heap.Pop(pq).(queue.Item)
+		v := int(item.ID)
+		if done[v] {
+			continue
+		}
+		done[v] = true
+		if v != root {
+			branch[v] = branch[parent[v]]
+			if parent[v] == root {
+				branch[v] = v
+			}
+		}
+		for _, l := range u.neighbours[v] {
+			if done[l.to] {
+				continue
+			}
+			d := item.Distance + u.weights[l.edge]
+			if !reached[l.to] || d < distance[l.to] {
+				reached[l.to] = true
+				distance[l.to] = d
+				parent[l.to] = v
+				via[l.to] = l.edge
+				heap.Push(pq, queue.Item{ID: int64(l.to), Distance: d})
+			}
+		}
+	}
+
+	res := make([]*candidateCycle, 0)
+	for x := range u.ids {
+		if !done[x] {
+			continue
+		}
+		for _, l := range u.neighbours[x] {
+			y := l.to
+			// Consider each edge once, and only edges outside the tree
+			if y < x || via[x] == l.edge || via[y] == l.edge {
+				continue
+			}
+			if x != root && y != root && branch[x] == branch[y] {
+				continue
+			}
+			c := &candidateCycle{
+				edges:  make([]uint64, words),
+				weight: distance[x] + distance[y] + u.weights[l.edge],
+			}
+			c.edges[l.edge/64] |= 1 << uint(l.edge%64)
+			// Root to x, then back from y to the root
+			toX := make([]int, 0)
+			for v := x; v != root; v = parent[v] {
+				toX = append(toX, v)
+				c.edges[via[v]/64] |= 1 << uint(via[v]%64)
+			}
+			c.nodes = []int{root}
+			for i := len(toX) - 1; i >= 0; i-- {
+				c.nodes = append(c.nodes, toX[i])
+			}
+			for v := y; v != root; v = parent[v] {
+				c.nodes = append(c.nodes, v)
+				c.edges[via[v]/64] |= 1 << uint(via[v]%64)
+			}
+			res = append(res, c)
+		}
+	}
+	return res
+}
+
+// canonicalCycle rotates a cycle to start at its lowest node and orients
+// it so that the second node is lower than the last
+func canonicalCycle(cycle []int) []int {
+	lowest := 0
+	for i, v := range cycle {
+		if v < cycle[lowest] {
+			lowest = i
+		}
+	}
+	res := make([]int, 0, len(cycle))
+	res = append(res, cycle[lowest:]...)
+	res = append(res, cycle[:lowest]...)
+	if len(res) > 2 && res[1] > res[len(res)-1] {
+		for i, j := 1, len(res)-1; i < j; i, j = i+1, j-1 {
+			res[i], res[j] = res[j], res[i]
+		}
+	}
+	return res
+}
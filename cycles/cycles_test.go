@@ -0,0 +1,153 @@
+package cycles
+
+// Copyright © 2018 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wealdtech/go-graph"
+	"github.com/wealdtech/go-graph/edges"
+	"github.com/wealdtech/go-graph/graphs"
+	"github.com/wealdtech/go-graph/nodes"
+)
+
+// imports creates a directed graph with the cycles 1-2-3, 1-2-4-3, 2-4-2
+// and the self-loop 5, with 6 hanging off the side
+func imports(t *testing.T) *graphs.DirectedGraph {
+	g := graphs.NewDirectedGraph()
+	for i := int64(1); i <= 6; i++ {
+		assert.NoError(t, g.AddNode(nodes.NewSimpleNode(i)))
+	}
+	for _, pair := range [][2]int64{{1, 2}, {2, 3}, {3, 1}, {2, 4}, {4, 3}, {4, 2}, {5, 5}, {3, 6}} {
+		assert.NoError(t, g.AddEdge(edges.NewDirectedEdge(pair[0], pair[1])))
+	}
+	return g
+}
+
+func TestElementaryCycles(t *testing.T) {
+	g := imports(t)
+	cycles, err := FindCycles(context.Background(), g, 0, 0, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, [][]int64{{1, 2, 3}, {1, 2, 4, 3}, {2, 4}, {5}}, cycles)
+
+	// Length bounds
+	cycles, err = FindCycles(context.Background(), g, 2, 3, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, [][]int64{{1, 2, 3}, {2, 4}}, cycles)
+	cycles, err = FindCycles(context.Background(), g, 4, 0, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, [][]int64{{1, 2, 4, 3}}, cycles)
+
+	// Limit
+	cycles, err = FindCycles(context.Background(), g, 0, 0, 2)
+	assert.NoError(t, err)
+	assert.Len(t, cycles, 2)
+
+	// Streaming
+	count := 0
+	for range ElementaryCyclesChannel(context.Background(), g, 0, 0) {
+		count++
+	}
+	assert.Equal(t, 4, count)
+
+	// Cancelled
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = FindCycles(ctx, g, 0, 0, 0)
+	assert.Equal(t, context.Canceled, err)
+
+	_, err = FindCycles(context.Background(), graphs.NewUndirectedGraph(), 0, 0, 0)
+	assert.Error(t, err)
+}
+
+func TestCompleteDigraphCycles(t *testing.T) {
+	// A complete directed graph on n nodes has sum over k of C(n,k)(k-1)!
+	// elementary cycles; for n = 5 that is 10 + 20 + 30 + 24 = 84
+	g := graphs.NewDirectedGraph()
+	for i := int64(1); i <= 5; i++ {
+		assert.NoError(t, g.AddNode(nodes.NewSimpleNode(i)))
+	}
+	for i := int64(1); i <= 5; i++ {
+		for j := int64(1); j <= 5; j++ {
+			if i != j {
+				assert.NoError(t, g.AddEdge(edges.NewDirectedEdge(i, j)))
+			}
+		}
+	}
+	cycles, err := FindCycles(context.Background(), g, 0, 0, 0)
+	assert.NoError(t, err)
+	assert.Len(t, cycles, 84)
+	bounded, err := FindCycles(context.Background(), g, 0, 3, 0)
+	assert.NoError(t, err)
+	assert.Len(t, bounded, 30)
+}
+
+func undirectedGraph(t *testing.T, n int64, pairs [][2]int64) *graphs.UndirectedGraph {
+	g := graphs.NewUndirectedGraph()
+	for i := int64(1); i <= n; i++ {
+		assert.NoError(t, g.AddNode(nodes.NewSimpleNode(i)))
+	}
+	for _, pair := range pairs {
+		assert.NoError(t, g.AddEdge(edges.NewUndirectedEdge(pair[0], pair[1])))
+	}
+	return g
+}
+
+func TestMinimumCycleBasis(t *testing.T) {
+	// Two squares sharing the edge 2-5, plus a separate triangle
+	g := undirectedGraph(t, 9, [][2]int64{
+		{1, 2}, {2, 3}, {3, 6}, {6, 5}, {5, 4}, {4, 1}, {2, 5},
+		{7, 8}, {8, 9}, {9, 7},
+	})
+	basis, err := MinimumCycleBasis(g, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, [][]int64{{7, 8, 9}, {1, 2, 5, 4}, {2, 3, 6, 5}}, basis)
+
+	// Weighting the shared edge heavily makes the outer cycle cheaper
+	// than one of the squares
+	heavy := g.Edge(2, 5).(*edges.UndirectedEdge)
+	heavy.SetAttribute("weight", 10)
+	basis, err = MinimumCycleBasis(g, graph.AttributeWeight("weight"))
+	assert.NoError(t, err)
+	assert.Len(t, basis, 3)
+	assert.Equal(t, []int64{7, 8, 9}, basis[0])
+	assert.Equal(t, []int64{1, 2, 3, 6, 5, 4}, basis[1])
+
+	tree := undirectedGraph(t, 3, [][2]int64{{1, 2}, {2, 3}})
+	basis, err = MinimumCycleBasis(tree, nil)
+	assert.NoError(t, err)
+	assert.Len(t, basis, 0)
+
+	_, err = MinimumCycleBasis(imports(t), nil)
+	assert.Error(t, err)
+}
+
+func TestGirth(t *testing.T) {
+	// The Petersen graph has girth 5
+	petersen := undirectedGraph(t, 10, [][2]int64{
+		{1, 2}, {2, 3}, {3, 4}, {4, 5}, {5, 1},
+		{1, 6}, {2, 7}, {3, 8}, {4, 9}, {5, 10},
+		{6, 8}, {8, 10}, {10, 7}, {7, 9}, {9, 6},
+	})
+	assert.Equal(t, 5, Girth(petersen))
+	assert.Equal(t, 4, Girth(undirectedGraph(t, 4, [][2]int64{{1, 2}, {2, 3}, {3, 4}, {4, 1}})))
+	assert.Equal(t, 0, Girth(undirectedGraph(t, 3, [][2]int64{{1, 2}, {2, 3}})))
+
+	assert.Equal(t, 1, Girth(imports(t)))
+	d := imports(t)
+	d.RemoveEdge(5, 5)
+	assert.Equal(t, 2, Girth(d))
+}
@@ -0,0 +1,96 @@
+package cycles
+
+// Copyright © 2018 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"github.com/wealdtech/go-graph"
+)
+
+// Girth returns the number of edges in a shortest cycle of a graph, or 0
+// if the graph has no cycles.  A self-loop is a cycle of length 1.  For
+// directed graphs cycles must follow the direction of the edges
+func Girth(g graph.Graph) int {
+	if graph.IsDirected(g) {
+		return directedGirth(g)
+	}
+	u, _ := newUndirected(g, graph.UnitWeight)
+	if len(u.loops) > 0 {
+		return 1
+	}
+	best := 0
+	n := len(u.ids)
+	distance := make([]int, n)
+	parentEdge := make([]int, n)
+	for root := 0; root < n; root++ {
+		for v := range distance {
+			distance[v] = -1
+		}
+		distance[root] = 0
+		parentEdge[root] = -1
+		queue := []int{root}
+		for len(queue) > 0 {
+			v := queue[0]
+			queue = queue[1:]
+			// Longer cycles through this root cannot improve on the best
+			if best > 0 && 2*distance[v] >= best {
+				break
+			}
+			for _, l := range u.neighbours[v] {
+				if l.edge == parentEdge[v] {
+					continue
+				}
+				if distance[l.to] < 0 {
+					distance[l.to] = distance[v] + 1
+					parentEdge[l.to] = l.edge
+					queue = append(queue, l.to)
+				} else if length := distance[v] + distance[l.to] + 1; best == 0 || length < best {
+					best = length
+				}
+			}
+		}
+	}
+	return best
+}
+
+func directedGirth(g graph.Graph) int {
+	_, succ := successors(g)
+	best := 0
+	distance := make([]int, len(succ))
+	for root := range succ {
+		for v := range distance {
+			distance[v] = -1
+		}
+		distance[root] = 0
+		queue := []int{root}
+	search:
+		for len(queue) > 0 {
+			v := queue[0]
+			queue = queue[1:]
+			if best > 0 && distance[v]+1 >= best {
+				break
+			}
+			for _, w := range succ[v] {
+				if w == root {
+					best = distance[v] + 1
+					break search
+				}
+				if distance[w] < 0 {
+					distance[w] = distance[v] + 1
+					queue = append(queue, w)
+				}
+			}
+		}
+	}
+	return best
+}
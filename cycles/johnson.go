@@ -0,0 +1,302 @@
+package cycles
+
+// Copyright © 2018 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/wealdtech/go-graph"
+)
+
+// successors returns the sorted node IDs of a graph and, by index, the
+// sorted successors of each node following edges from From to To
+func successors(g graph.Graph) ([]int64, [][]int) {
+	nodes := g.Nodes()
+	ids := make([]int64, 0, len(nodes))
+	for _, node := range nodes {
+		ids = append(ids, node.Id())
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	index := make(map[int64]int, len(ids))
+	for i, nid := range ids {
+		index[nid] = i
+	}
+	res := make([][]int, len(ids))
+	for v, nid := range ids {
+		for _, edge := range g.Edges(nid) {
+			if edge.From() != nid {
+				continue
+			}
+			if w, exists := index[edge.To()]; exists {
+				res[v] = append(res[v], w)
+			}
+		}
+		list := res[v]
+		sort.Ints(list)
+	}
+	return ids, res
+}
+
+// ElementaryCycles calls fn with the node IDs of each elementary cycle of a
+// directed graph: a closed walk that visits no node twice.  Each cycle
+// starts at its lowest node ID and is given without repeating that node at
+// the end; a self-loop is a cycle of length 1.  Only cycles with at least
+// minLength and at most maxLength edges are reported, where a bound of 0
+// or less means no limit.
+//
+// Without a maximum length this uses Johnson's algorithm, which takes time
+// linear in the number of cycles found.  With a maximum length it uses a
+// depth-first search bounded by the length.  Enumeration stops if fn
+// returns false, or with the context's error if it is cancelled
+func ElementaryCycles(ctx context.Context, g graph.Graph, minLength, maxLength int, fn func(cycle []int64) bool) error {
+	if !graph.IsDirected(g) {
+		return fmt.Errorf("Elementary cycles require a directed graph")
+	}
+	ids, succ := successors(g)
+	s := &johnson{
+		ctx:       ctx,
+		ids:       ids,
+		succ:      succ,
+		minLength: minLength,
+		maxLength: maxLength,
+		fn:        fn,
+		inScope:   make([]bool, len(ids)),
+		blocked:   make([]bool, len(ids)),
+		blockers:  make([]map[int]bool, len(ids)),
+	}
+	for start := range ids {
+		s.start = start
+		if !s.scope() {
+			continue
+		}
+		if maxLength > 0 {
+			s.bounded(start)
+		} else {
+			s.circuit(start)
+		}
+		if s.done {
+			break
+		}
+	}
+	return s.err
+}
+
+// ElementaryCyclesChannel streams the elementary cycles of a directed graph
+// over a channel, which is closed when enumeration finishes.  Cancel the
+// context to stop enumeration early.  Errors give no cycles
+func ElementaryCyclesChannel(ctx context.Context, g graph.Graph, minLength, maxLength int) <-chan []int64 {
+	ch := make(chan []int64)
+	go func() {
+		defer close(ch)
+		ElementaryCycles(ctx, g, minLength, maxLength, func(cycle []int64) bool {
+			select {
+			case ch <- cycle:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		})
+	}()
+	return ch
+}
+
+// FindCycles returns up to limit elementary cycles of a directed graph,
+// where a limit of 0 or less means no limit.  See ElementaryCycles for
+// details.  If the context is cancelled the cycles found so far are
+// returned along with the context's error
+func FindCycles(ctx context.Context, g graph.Graph, minLength, maxLength, limit int) ([][]int64, error) {
+	res := make([][]int64, 0)
+	err := ElementaryCycles(ctx, g, minLength, maxLength, func(cycle []int64) bool {
+		res = append(res, cycle)
+		return limit <= 0 || len(res) < limit
+	})
+	return res, err
+}
+
+// johnson holds the state of a cycle enumeration
+type johnson struct {
+	ctx       context.Context
+	ids       []int64
+	succ      [][]int
+	minLength int
+	maxLength int
+	fn        func(cycle []int64) bool
+	err       error
+	done      bool
+	steps     int
+
+	start    int
+	inScope  []bool
+	path     []int
+	blocked  []bool
+	blockers []map[int]bool
+}
+
+// scope marks the strongly connected component containing the start node
+// in the graph induced by nodes at or after the start, and resets the
+// blocking state.  It returns false if there can be no cycle through the
+// start node
+func (s *johnson) scope() bool {
+	forward := s.reach(s.start, func(v int) []int { return s.succ[v] })
+	if !forward[s.start] {
+		return false
+	}
+	// Nodes that can reach the start are those the start reaches in the
+	// reversed graph; restrict the reversal to the forward set
+	predecessors := make(map[int][]int)
+	for v := range forward {
+		for _, w := range s.succ[v] {
+			if forward[w] {
+				predecessors[w] = append(predecessors[w], v)
+			}
+		}
+	}
+	backward := s.reach(s.start, func(v int) []int { return predecessors[v] })
+	for v := range s.inScope {
+		s.inScope[v] = forward[v] && backward[v]
+		s.blocked[v] = false
+		s.blockers[v] = nil
+	}
+	return true
+}
+
+// reach returns the nodes at or after the start reachable from it by at
+// least one edge
+func (s *johnson) reach(from int, next func(v int) []int) map[int]bool {
+	seen := make(map[int]bool)
+	stack := []int{from}
+	for len(stack) > 0 {
+		v := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		for _, w := range next(v) {
+			if w >= s.start && !seen[w] {
+				seen[w] = true
+				stack = append(stack, w)
+			}
+		}
+	}
+	return seen
+}
+
+// cancelled checks the context periodically
+func (s *johnson) cancelled() bool {
+	s.steps++
+	if s.steps%1024 == 0 {
+		if err := s.ctx.Err(); err != nil {
+			s.err = err
+			s.done = true
+		}
+	}
+	return s.done
+}
+
+func (s *johnson) emit() {
+	if len(s.path) < s.minLength || (s.maxLength > 0 && len(s.path) > s.maxLength) {
+		return
+	}
+	if err := s.ctx.Err(); err != nil {
+		s.err = err
+		s.done = true
+		return
+	}
+	cycle := make([]int64, len(s.path))
+	for i, v := range s.path {
+		cycle[i] = s.ids[v]
+	}
+	if !s.fn(cycle) {
+		s.done = true
+	}
+}
+
+// circuit is the search of Johnson's algorithm.  It returns true if a
+// cycle through the start was found from v
+func (s *johnson) circuit(v int) bool {
+	if s.cancelled() {
+		return false
+	}
+	found := false
+	s.path = append(s.path, v)
+	s.blocked[v] = true
+	for _, w := range s.succ[v] {
+		if s.done {
+			break
+		}
+		if !s.inScope[w] {
+			continue
+		}
+		if w == s.start {
+			s.emit()
+			found = true
+		} else if !s.blocked[w] && s.circuit(w) {
+			found = true
+		}
+	}
+	if found {
+		s.unblock(v)
+	} else {
+		for _, w := range s.succ[v] {
+			if s.inScope[w] {
+				if s.blockers[w] == nil {
+					s.blockers[w] = make(map[int]bool)
+				}
+				s.blockers[w][v] = true
+			}
+		}
+	}
+	s.path = s.path[:len(s.path)-1]
+	return found
+}
+
+func (s *johnson) unblock(v int) {
+	stack := []int{v}
+	for len(stack) > 0 {
+		u := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		s.blocked[u] = false
+		for w := range s.blockers[u] {
+			if s.blocked[w] {
+				stack = append(stack, w)
+			}
+		}
+		s.blockers[u] = nil
+	}
+}
+
+// bounded is a depth-first search for cycles through the start of at most
+// the maximum length.  Johnson's blocking assumes that every path is
+// followed to its end, so it cannot be used here
+func (s *johnson) bounded(v int) {
+	if s.cancelled() {
+		return
+	}
+	s.path = append(s.path, v)
+	s.blocked[v] = true
+	for _, w := range s.succ[v] {
+		if s.done {
+			break
+		}
+		if !s.inScope[w] {
+			continue
+		}
+		if w == s.start {
+			s.emit()
+		} else if !s.blocked[w] && len(s.path) < s.maxLength {
+			s.bounded(w)
+		}
+	}
+	s.blocked[v] = false
+	s.path = s.path[:len(s.path)-1]
+}
@@ -0,0 +1,182 @@
+package diff
+
+// Copyright © 2018 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/wealdtech/go-graph"
+	"github.com/wealdtech/go-graph/internal/build"
+)
+
+// Changeset describes the changes that turn one graph into another
+type Changeset struct {
+	Directed      bool              `json:"directed"`
+	GraphDefaults []AttributeChange `json:"graphDefaults,omitempty"`
+	NodeDefaults  []AttributeChange `json:"nodeDefaults,omitempty"`
+	EdgeDefaults  []AttributeChange `json:"edgeDefaults,omitempty"`
+	AddedNodes    []Node            `json:"addedNodes,omitempty"`
+	RemovedNodes  []Node            `json:"removedNodes,omitempty"`
+	ChangedNodes  []NodeChange      `json:"changedNodes,omitempty"`
+	AddedEdges    []Edge            `json:"addedEdges,omitempty"`
+	RemovedEdges  []Edge            `json:"removedEdges,omitempty"`
+	ChangedEdges  []EdgeChange      `json:"changedEdges,omitempty"`
+}
+
+// Attributes is a set of attributes, as held by a node or edge
+type Attributes map[interface{}]interface{}
+
+// Node is a node added or removed, with all of its attributes
+type Node struct {
+	ID         int64      `json:"id"`
+	Attributes Attributes `json:"attributes,omitempty"`
+}
+
+// Edge is an edge added or removed, with all of its attributes.  The ends
+// of undirected edges are given lower ID first
+type Edge struct {
+	From       int64      `json:"from"`
+	To         int64      `json:"to"`
+	Attributes Attributes `json:"attributes,omitempty"`
+}
+
+// NodeChange lists the changed attributes of a node
+type NodeChange struct {
+	ID         int64             `json:"id"`
+	Attributes []AttributeChange `json:"attributes"`
+}
+
+// EdgeChange lists the changed attributes of an edge
+type EdgeChange struct {
+	From       int64             `json:"from"`
+	To         int64             `json:"to"`
+	Attributes []AttributeChange `json:"attributes"`
+}
+
+// AttributeChange is a change to a single attribute.  An old value of nil
+// means the attribute was added and a new value of nil means it was
+// removed, so attributes set to nil are treated as missing
+type AttributeChange struct {
+	Key interface{}
+	Old interface{}
+	New interface{}
+}
+
+// Empty returns true if the changeset contains no changes
+func (c *Changeset) Empty() bool {
+	return len(c.GraphDefaults) == 0 && len(c.NodeDefaults) == 0 && len(c.EdgeDefaults) == 0 &&
+		len(c.AddedNodes) == 0 && len(c.RemovedNodes) == 0 && len(c.ChangedNodes) == 0 &&
+		len(c.AddedEdges) == 0 && len(c.RemovedEdges) == 0 && len(c.ChangedEdges) == 0
+}
+
+// Diff returns the changes that turn one graph into another.  Both graphs
+// must be directed or both undirected.  Attribute values are compared with
+// reflect.DeepEqual
+func Diff(from, to graph.Graph) (*Changeset, error) {
+	if graph.IsDirected(from) != graph.IsDirected(to) {
+		return nil, fmt.Errorf("Graphs must both be directed or both be undirected")
+	}
+	res := &Changeset{
+		Directed:      graph.IsDirected(from),
+		GraphDefaults: changes(from.GraphDefaults(), to.GraphDefaults()),
+		NodeDefaults:  changes(from.NodeDefaults(), to.NodeDefaults()),
+		EdgeDefaults:  changes(from.EdgeDefaults(), to.EdgeDefaults()),
+	}
+
+	for _, nid := range build.NodeIDs(from) {
+		node := from.Node(nid)
+		if !to.HasNode(nid) {
+			res.RemovedNodes = append(res.RemovedNodes, Node{ID: nid, Attributes: copyAttributes(node.Attributes())})
+		} else if attrs := changes(node.Attributes(), to.Node(nid).Attributes()); len(attrs) > 0 {
+			res.ChangedNodes = append(res.ChangedNodes, NodeChange{ID: nid, Attributes: attrs})
+		}
+		for _, edge := range build.OwnedEdges(from, nid) {
+			if !to.HasEdge(edge.From(), edge.To()) {
+				res.RemovedEdges = append(res.RemovedEdges, newEdge(edge))
+			} else if attrs := changes(edge.Attributes(), to.Edge(edge.From(), edge.To()).Attributes()); len(attrs) > 0 {
+				res.ChangedEdges = append(res.ChangedEdges, EdgeChange{From: edge.From(), To: edge.To(), Attributes: attrs})
+			}
+		}
+	}
+	for _, nid := range build.NodeIDs(to) {
+		if !from.HasNode(nid) {
+			res.AddedNodes = append(res.AddedNodes, Node{ID: nid, Attributes: copyAttributes(to.Node(nid).Attributes())})
+		}
+		for _, edge := range build.OwnedEdges(to, nid) {
+			if !from.HasEdge(edge.From(), edge.To()) {
+				res.AddedEdges = append(res.AddedEdges, newEdge(edge))
+			}
+		}
+	}
+	return res, nil
+}
+
+func newEdge(edge graph.Edge) Edge {
+	return Edge{
+		From:       edge.From(),
+		To:         edge.To(),
+		Attributes: copyAttributes(edge.Attributes()),
+	}
+}
+
+func copyAttributes(attrs *map[interface{}]interface{}) Attributes {
+	if attrs == nil || len(*attrs) == 0 {
+		return nil
+	}
+	res := make(Attributes, len(*attrs))
+	for key, value := range *attrs {
+		if value != nil {
+			res[key] = value
+		}
+	}
+	return res
+}
+
+// changes returns the differences between two sets of attributes, sorted
+// by key
+func changes(from, to *map[interface{}]interface{}) []AttributeChange {
+	res := make([]AttributeChange, 0)
+	for key, value := range attributes(from) {
+		if !reflect.DeepEqual(value, attributes(to)[key]) {
+			res = append(res, AttributeChange{Key: key, Old: value, New: attributes(to)[key]})
+		}
+	}
+	for key, value := range attributes(to) {
+		if _, exists := attributes(from)[key]; !exists && value != nil {
+			res = append(res, AttributeChange{Key: key, New: value})
+		}
+	}
+	if len(res) == 0 {
+		return nil
+	}
+	sortChanges(res)
+	return res
+}
+
+func attributes(attrs *map[interface{}]interface{}) map[interface{}]interface{} {
+	if attrs == nil {
+		return nil
+	}
+	return *attrs
+}
+
+func sortChanges(changes []AttributeChange) {
+	sort.Slice(changes, func(i, j int) bool { return keyOrder(changes[i].Key) < keyOrder(changes[j].Key) })
+}
+
+func keyOrder(key interface{}) string {
+	return fmt.Sprintf("%T:%v", key, key)
+}
@@ -0,0 +1,148 @@
+package diff_test
+
+// Copyright © 2018 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wealdtech/go-graph/diff"
+	"github.com/wealdtech/go-graph/edges"
+	"github.com/wealdtech/go-graph/exporters/dot"
+	"github.com/wealdtech/go-graph/graphs"
+	"github.com/wealdtech/go-graph/nodes"
+)
+
+func directed(t *testing.T, ids []int64, links [][2]int64) *graphs.DirectedGraph {
+	g := graphs.NewDirectedGraph()
+	for _, id := range ids {
+		assert.NoError(t, g.AddNode(nodes.NewSimpleNode(id)))
+	}
+	for _, link := range links {
+		assert.NoError(t, g.AddEdge(edges.NewDirectedEdge(link[0], link[1])))
+	}
+	return g
+}
+
+func undirected(t *testing.T, ids []int64, links [][2]int64) *graphs.UndirectedGraph {
+	g := graphs.NewUndirectedGraph()
+	for _, id := range ids {
+		assert.NoError(t, g.AddNode(nodes.NewSimpleNode(id)))
+	}
+	for _, link := range links {
+		assert.NoError(t, g.AddEdge(edges.NewUndirectedEdge(link[0], link[1])))
+	}
+	return g
+}
+
+func TestDiff(t *testing.T) {
+	from := directed(t, []int64{1, 2, 3}, [][2]int64{{1, 2}, {2, 3}, {3, 1}})
+	from.Node(1).SetAttribute("role", "web")
+	from.Node(2).SetAttribute("role", "db")
+	from.Edge(1, 2).SetAttribute("port", 5432)
+	to := directed(t, []int64{1, 2, 4}, [][2]int64{{1, 2}, {2, 1}, {4, 1}})
+	to.Node(1).SetAttribute("role", "proxy")
+	to.Node(1).SetAttribute("replicas", 2)
+	to.Node(4).SetAttribute("role", "cache")
+	to.Edge(1, 2).SetAttribute("port", 5433)
+	to.SetGraphDefaults(map[interface{}]interface{}{"rankdir": "LR"})
+
+	c, err := diff.Diff(from, to)
+	assert.NoError(t, err)
+	assert.True(t, c.Directed)
+	assert.Equal(t, []diff.AttributeChange{{Key: "rankdir", New: "LR"}}, c.GraphDefaults)
+	assert.Nil(t, c.NodeDefaults)
+	assert.Equal(t, []diff.Node{{ID: 4, Attributes: diff.Attributes{"role": "cache"}}}, c.AddedNodes)
+	assert.Equal(t, []diff.Node{{ID: 3}}, c.RemovedNodes)
+	assert.Equal(t, []diff.NodeChange{
+		{ID: 1, Attributes: []diff.AttributeChange{{Key: "replicas", New: 2}, {Key: "role", Old: "web", New: "proxy"}}},
+		{ID: 2, Attributes: []diff.AttributeChange{{Key: "role", Old: "db"}}},
+	}, c.ChangedNodes)
+	assert.Equal(t, []diff.Edge{{From: 2, To: 1}, {From: 4, To: 1}}, c.AddedEdges)
+	assert.Equal(t, []diff.Edge{{From: 2, To: 3}, {From: 3, To: 1}}, c.RemovedEdges)
+	assert.Equal(t, []diff.EdgeChange{{From: 1, To: 2, Attributes: []diff.AttributeChange{{Key: "port", Old: 5432, New: 5433}}}}, c.ChangedEdges)
+
+	assert.NoError(t, diff.Patch(from, c))
+	assert.Equal(t, string(dot.Marshal(to)), string(dot.Marshal(from)))
+	c, err = diff.Diff(from, to)
+	assert.NoError(t, err)
+	assert.True(t, c.Empty())
+
+	_, err = diff.Diff(from, undirected(t, nil, nil))
+	assert.EqualError(t, err, "Graphs must both be directed or both be undirected")
+}
+
+func TestJSON(t *testing.T) {
+	from := undirected(t, []int64{1, 2, 3}, [][2]int64{{1, 2}, {2, 3}})
+	to := undirected(t, []int64{1, 2, 3}, [][2]int64{{1, 2}, {1, 3}})
+	to.Node(1).SetAttribute("weight", int64(3))
+	to.Node(2).SetAttribute("ratio", float32(0.5))
+	to.Node(3).SetAttribute("tags", []interface{}{"a", "b"})
+	to.Edge(3, 1).SetAttribute("label", "new")
+	to.Edge(1, 2).SetAttribute(7, true)
+
+	c, err := diff.Diff(from, to)
+	assert.NoError(t, err)
+	data, err := json.Marshal(c)
+	assert.NoError(t, err)
+	var read diff.Changeset
+	assert.NoError(t, json.Unmarshal(data, &read))
+	assert.Equal(t, c, &read)
+
+	assert.NoError(t, diff.Patch(from, &read))
+	assert.Equal(t, string(dot.Marshal(to)), string(dot.Marshal(from)))
+	assert.Equal(t, int64(3), from.Node(1).Attribute("weight"))
+	assert.Equal(t, true, from.Edge(2, 1).Attribute(7))
+
+	assert.Error(t, json.Unmarshal([]byte(`{"addedNodes":[{"id":1,"attributes":[{"key":{"type":"complex64","value":1}}]}]}`), &read))
+
+	// Keys that would not read back as keys are refused both ways
+	to.Node(2).SetAttribute(struct{ A int }{1}, "x")
+	c, err = diff.Diff(from, to)
+	assert.NoError(t, err)
+	_, err = json.Marshal(c)
+	assert.Error(t, err)
+	err = json.Unmarshal([]byte(`{"addedNodes":[{"id":1,"attributes":[{"key":{"type":"json","value":{"A":1}},"value":{"type":"string","value":"x"}}]}]}`), &read)
+	assert.EqualError(t, err, `Attribute key {"A":1} cannot be used as a key`)
+}
+
+func TestConflicts(t *testing.T) {
+	from := undirected(t, []int64{1, 2, 3}, [][2]int64{{1, 2}, {2, 3}})
+	from.Node(1).SetAttribute("role", "web")
+	to := undirected(t, []int64{1, 2, 4}, [][2]int64{{1, 2}, {2, 4}})
+	to.Node(1).SetAttribute("role", "proxy")
+	c, err := diff.Diff(from, to)
+	assert.NoError(t, err)
+
+	// Someone else has changed the graph in the meantime
+	target := undirected(t, []int64{1, 2, 3, 4}, [][2]int64{{1, 2}, {2, 3}, {1, 3}})
+	target.Node(1).SetAttribute("role", "db")
+	before := string(dot.Marshal(target))
+	err = diff.Patch(target, c)
+	assert.IsType(t, &diff.ConflictError{}, err)
+	assert.Equal(t, []diff.Conflict{
+		{Change: "Remove node 3", Reason: "Edge from 1 to 3 would also be removed"},
+		{Change: "Add node 4", Reason: "Node already exists"},
+		{Change: "Change node 1", Reason: "Attribute role is db, expected web"},
+	}, err.(*diff.ConflictError).Conflicts)
+	assert.Equal(t, before, string(dot.Marshal(target)))
+
+	target = undirected(t, []int64{1, 2}, [][2]int64{{1, 2}})
+	target.Node(1).SetAttribute("role", "web")
+	err = diff.Patch(target, c)
+	assert.EqualError(t, err, "Patch has 2 conflicts: Remove edge from 2 to 3: Edge does not exist; Remove node 3: Node does not exist")
+
+	assert.EqualError(t, diff.Patch(directed(t, nil, nil), c), "Changeset and graph must both be directed or both be undirected")
+}
@@ -0,0 +1,194 @@
+package diff
+
+// Copyright © 2018 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// Attribute keys and values are serialised along with their types, so
+// that they read back as the same types and patches compare them exactly.
+// Strings, booleans, integers and floats keep their Go type; other values
+// are written as plain JSON and read back as the types encoding/json
+// chooses
+
+// typedValue is the JSON form of an attribute key or value
+type typedValue struct {
+	Type  string          `json:"type"`
+	Value json.RawMessage `json:"value"`
+}
+
+// basicTypes are the types that keep their Go type when serialised
+var basicTypes = map[string]reflect.Type{}
+
+func init() {
+	for _, value := range []interface{}{"", false, int(0), int8(0), int16(0), int32(0), int64(0), uint(0), uint8(0), uint16(0), uint32(0), uint64(0), float32(0), float64(0)} {
+		basicTypes[reflect.TypeOf(value).String()] = reflect.TypeOf(value)
+	}
+}
+
+func encodeValue(value interface{}) (*typedValue, error) {
+	if value == nil {
+		return nil, nil
+	}
+	name := reflect.TypeOf(value).String()
+	if _, exists := basicTypes[name]; !exists {
+		name = "json"
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	return &typedValue{Type: name, Value: data}, nil
+}
+
+func decodeValue(t *typedValue) (interface{}, error) {
+	if t == nil {
+		return nil, nil
+	}
+	if t.Type == "json" {
+		var res interface{}
+		if err := json.Unmarshal(t.Value, &res); err != nil {
+			return nil, err
+		}
+		return res, nil
+	}
+	valueType, exists := basicTypes[t.Type]
+	if !exists {
+		return nil, fmt.Errorf("Unknown attribute type %q", t.Type)
+	}
+	res := reflect.New(valueType)
+	if err := json.Unmarshal(t.Value, res.Interface()); err != nil {
+		return nil, err
+	}
+	return res.Elem().Interface(), nil
+}
+
+// encodeKey writes an attribute key.  Only keys of basic types read back
+// as keys, so others are refused
+func encodeKey(key interface{}) (*typedValue, error) {
+	if key != nil {
+		if _, exists := basicTypes[reflect.TypeOf(key).String()]; !exists {
+			return nil, fmt.Errorf("Attribute key %v of type %T cannot be serialised", key, key)
+		}
+	}
+	return encodeValue(key)
+}
+
+// decodeKey reads an attribute key, refusing values that cannot be map
+// keys
+func decodeKey(t *typedValue) (interface{}, error) {
+	key, err := decodeValue(t)
+	if err != nil {
+		return nil, err
+	}
+	if key != nil && !reflect.TypeOf(key).Comparable() {
+		return nil, fmt.Errorf("Attribute key %s cannot be used as a key", t.Value)
+	}
+	return key, nil
+}
+
+type attributeJSON struct {
+	Key   *typedValue `json:"key"`
+	Value *typedValue `json:"value"`
+}
+
+// MarshalJSON writes attributes as a list sorted by key
+func (a Attributes) MarshalJSON() ([]byte, error) {
+	keys := make([]interface{}, 0, len(a))
+	for key := range a {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keyOrder(keys[i]) < keyOrder(keys[j]) })
+	res := make([]attributeJSON, len(keys))
+	for i, key := range keys {
+		var err error
+		if res[i].Key, err = encodeKey(key); err != nil {
+			return nil, err
+		}
+		if res[i].Value, err = encodeValue(a[key]); err != nil {
+			return nil, err
+		}
+	}
+	return json.Marshal(res)
+}
+
+// UnmarshalJSON reads attributes written by MarshalJSON
+func (a *Attributes) UnmarshalJSON(data []byte) error {
+	var entries []attributeJSON
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return err
+	}
+	*a = make(Attributes, len(entries))
+	for _, entry := range entries {
+		key, err := decodeKey(entry.Key)
+		if err != nil {
+			return err
+		}
+		if key == nil {
+			return fmt.Errorf("Attribute has no key")
+		}
+		value, err := decodeValue(entry.Value)
+		if err != nil {
+			return err
+		}
+		(*a)[key] = value
+	}
+	return nil
+}
+
+type attributeChangeJSON struct {
+	Key *typedValue `json:"key"`
+	Old *typedValue `json:"old,omitempty"`
+	New *typedValue `json:"new,omitempty"`
+}
+
+// MarshalJSON writes an attribute change with typed values
+func (c AttributeChange) MarshalJSON() ([]byte, error) {
+	var res attributeChangeJSON
+	var err error
+	if res.Key, err = encodeKey(c.Key); err != nil {
+		return nil, err
+	}
+	if res.Old, err = encodeValue(c.Old); err != nil {
+		return nil, err
+	}
+	if res.New, err = encodeValue(c.New); err != nil {
+		return nil, err
+	}
+	return json.Marshal(res)
+}
+
+// UnmarshalJSON reads an attribute change written by MarshalJSON
+func (c *AttributeChange) UnmarshalJSON(data []byte) error {
+	var change attributeChangeJSON
+	if err := json.Unmarshal(data, &change); err != nil {
+		return err
+	}
+	var err error
+	if c.Key, err = decodeKey(change.Key); err != nil {
+		return err
+	}
+	if c.Key == nil {
+		return fmt.Errorf("Attribute change has no key")
+	}
+	if c.Old, err = decodeValue(change.Old); err != nil {
+		return err
+	}
+	c.New, err = decodeValue(change.New)
+	return err
+}
@@ -0,0 +1,248 @@
+package diff
+
+// Copyright © 2018 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/wealdtech/go-graph"
+	"github.com/wealdtech/go-graph/edges"
+	"github.com/wealdtech/go-graph/internal/build"
+	"github.com/wealdtech/go-graph/nodes"
+)
+
+// Conflict is a change in a changeset that does not fit the graph being
+// patched
+type Conflict struct {
+	// Change describes the change
+	Change string
+	// Reason describes why it does not fit
+	Reason string
+}
+
+func (c Conflict) String() string {
+	return fmt.Sprintf("%s: %s", c.Change, c.Reason)
+}
+
+// ConflictError is returned when a patch has conflicts
+type ConflictError struct {
+	Conflicts []Conflict
+}
+
+func (e *ConflictError) Error() string {
+	descriptions := make([]string, len(e.Conflicts))
+	for i, conflict := range e.Conflicts {
+		descriptions[i] = conflict.String()
+	}
+	return fmt.Sprintf("Patch has %d conflicts: %s", len(e.Conflicts), strings.Join(descriptions, "; "))
+}
+
+// Patch applies a changeset to a graph.  The graph must match the state
+// the changeset was made from for everything the changeset touches: nodes
+// and edges to add must not exist, those to remove must exist with the
+// recorded attributes, removed nodes must not have edges that are not
+// also removed, and changed attributes must have their old values.  If
+// there are any conflicts the graph is left unchanged and a
+// *ConflictError lists them all.  New nodes and edges are simple nodes and
+// edges.  A graph that refuses a new node or edge, such as a RootedTree
+// refusing a second parent, is left partly patched
+func Patch(g graph.Graph, c *Changeset) error {
+	m, ok := g.(build.Graph)
+	if !ok {
+		return fmt.Errorf("Graph cannot be changed")
+	}
+	if graph.IsDirected(g) != c.Directed {
+		return fmt.Errorf("Changeset and graph must both be directed or both be undirected")
+	}
+	if conflicts := findConflicts(g, c); len(conflicts) > 0 {
+		return &ConflictError{Conflicts: conflicts}
+	}
+
+	if len(c.GraphDefaults) > 0 {
+		g.SetGraphDefaults(applyChanges(g.GraphDefaults(), c.GraphDefaults))
+	}
+	if len(c.NodeDefaults) > 0 {
+		g.SetNodeDefaults(applyChanges(g.NodeDefaults(), c.NodeDefaults))
+	}
+	if len(c.EdgeDefaults) > 0 {
+		g.SetEdgeDefaults(applyChanges(g.EdgeDefaults(), c.EdgeDefaults))
+	}
+	for _, edge := range c.RemovedEdges {
+		m.RemoveEdge(edge.From, edge.To)
+	}
+	for _, node := range c.RemovedNodes {
+		m.RemoveNode(node.ID)
+	}
+	for _, node := range c.AddedNodes {
+		newNode := nodes.NewSimpleNode(node.ID)
+		newNode.SetAttributes(applyChanges(nil, additions(node.Attributes)))
+		if err := m.AddNode(newNode); err != nil {
+			return err
+		}
+	}
+	for _, edge := range c.AddedEdges {
+		var newEdge graph.Edge
+		if c.Directed {
+			newEdge = edges.NewDirectedEdge(edge.From, edge.To)
+		} else {
+			newEdge = edges.NewUndirectedEdge(edge.From, edge.To)
+		}
+		newEdge.SetAttributes(applyChanges(nil, additions(edge.Attributes)))
+		if err := m.AddEdge(newEdge); err != nil {
+			return err
+		}
+	}
+	for _, change := range c.ChangedNodes {
+		node := g.Node(change.ID)
+		node.SetAttributes(applyChanges(node.Attributes(), change.Attributes))
+	}
+	for _, change := range c.ChangedEdges {
+		edge := g.Edge(change.From, change.To)
+		edge.SetAttributes(applyChanges(edge.Attributes(), change.Attributes))
+	}
+	return nil
+}
+
+// findConflicts checks every change in a changeset against a graph
+func findConflicts(g graph.Graph, c *Changeset) []Conflict {
+	res := make([]Conflict, 0)
+	conflict := func(change, reason string, args ...interface{}) {
+		res = append(res, Conflict{Change: change, Reason: fmt.Sprintf(reason, args...)})
+	}
+	checkChanges := func(change string, attrs *map[interface{}]interface{}, changes []AttributeChange) {
+		for _, attr := range changes {
+			if current := attributes(attrs)[attr.Key]; !reflect.DeepEqual(current, attr.Old) {
+				conflict(change, "Attribute %v is %v, expected %v", attr.Key, current, attr.Old)
+			}
+		}
+	}
+	edgeKey := func(from, to int64) [2]int64 {
+		if !c.Directed && from > to {
+			return [2]int64{to, from}
+		}
+		return [2]int64{from, to}
+	}
+
+	checkChanges("Change graph defaults", g.GraphDefaults(), c.GraphDefaults)
+	checkChanges("Change node defaults", g.NodeDefaults(), c.NodeDefaults)
+	checkChanges("Change edge defaults", g.EdgeDefaults(), c.EdgeDefaults)
+
+	removedEdges := make(map[[2]int64]bool)
+	for _, edge := range c.RemovedEdges {
+		change := fmt.Sprintf("Remove edge from %v to %v", edge.From, edge.To)
+		removedEdges[edgeKey(edge.From, edge.To)] = true
+		if !g.HasEdge(edge.From, edge.To) {
+			conflict(change, "Edge does not exist")
+		} else if !sameAttributes(g.Edge(edge.From, edge.To).Attributes(), edge.Attributes) {
+			conflict(change, "Edge attributes have changed")
+		}
+	}
+	removedNodes := make(map[int64]bool)
+	for _, node := range c.RemovedNodes {
+		change := fmt.Sprintf("Remove node %v", node.ID)
+		removedNodes[node.ID] = true
+		if !g.HasNode(node.ID) {
+			conflict(change, "Node does not exist")
+		} else if !sameAttributes(g.Node(node.ID).Attributes(), node.Attributes) {
+			conflict(change, "Node attributes have changed")
+		}
+	}
+	if len(removedNodes) > 0 {
+		for _, nid := range build.NodeIDs(g) {
+			for _, edge := range build.OwnedEdges(g, nid) {
+				if removedEdges[edgeKey(edge.From(), edge.To())] {
+					continue
+				}
+				for _, end := range []int64{edge.From(), edge.To()} {
+					if removedNodes[end] {
+						conflict(fmt.Sprintf("Remove node %v", end), "Edge from %v to %v would also be removed", edge.From(), edge.To())
+						break
+					}
+				}
+			}
+		}
+	}
+
+	exists := func(nid int64) bool {
+		return g.HasNode(nid) && !removedNodes[nid]
+	}
+	addedNodes := make(map[int64]bool)
+	for _, node := range c.AddedNodes {
+		addedNodes[node.ID] = true
+		if exists(node.ID) {
+			conflict(fmt.Sprintf("Add node %v", node.ID), "Node already exists")
+		}
+	}
+	for _, edge := range c.AddedEdges {
+		change := fmt.Sprintf("Add edge from %v to %v", edge.From, edge.To)
+		switch {
+		case !exists(edge.From) && !addedNodes[edge.From]:
+			conflict(change, "Node %v does not exist", edge.From)
+		case !exists(edge.To) && !addedNodes[edge.To]:
+			conflict(change, "Node %v does not exist", edge.To)
+		case g.HasEdge(edge.From, edge.To) && !removedEdges[edgeKey(edge.From, edge.To)]:
+			conflict(change, "Edge already exists")
+		}
+	}
+	for _, node := range c.ChangedNodes {
+		change := fmt.Sprintf("Change node %v", node.ID)
+		if !exists(node.ID) {
+			conflict(change, "Node does not exist")
+			continue
+		}
+		checkChanges(change, g.Node(node.ID).Attributes(), node.Attributes)
+	}
+	for _, edge := range c.ChangedEdges {
+		change := fmt.Sprintf("Change edge from %v to %v", edge.From, edge.To)
+		if !g.HasEdge(edge.From, edge.To) || removedEdges[edgeKey(edge.From, edge.To)] {
+			conflict(change, "Edge does not exist")
+			continue
+		}
+		checkChanges(change, g.Edge(edge.From, edge.To).Attributes(), edge.Attributes)
+	}
+	return res
+}
+
+// sameAttributes returns true if a set of attributes matches the recorded
+// attributes
+func sameAttributes(attrs *map[interface{}]interface{}, recorded Attributes) bool {
+	return reflect.DeepEqual(copyAttributes(attrs), copyAttributes((*map[interface{}]interface{})(&recorded)))
+}
+
+// additions returns the changes that add a set of attributes
+func additions(attrs Attributes) []AttributeChange {
+	res := make([]AttributeChange, 0, len(attrs))
+	for key, value := range attrs {
+		res = append(res, AttributeChange{Key: key, New: value})
+	}
+	return res
+}
+
+// applyChanges returns a copy of a set of attributes with changes applied
+func applyChanges(attrs *map[interface{}]interface{}, changes []AttributeChange) map[interface{}]interface{} {
+	res := make(map[interface{}]interface{})
+	for key, value := range attributes(attrs) {
+		res[key] = value
+	}
+	for _, change := range changes {
+		if change.New == nil {
+			delete(res, change.Key)
+		} else {
+			res[change.Key] = change.New
+		}
+	}
+	return res
+}
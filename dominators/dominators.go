@@ -0,0 +1,362 @@
+package dominators
+
+// Copyright © 2018 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/wealdtech/go-graph"
+	"github.com/wealdtech/go-graph/edges"
+	"github.com/wealdtech/go-graph/graphs"
+	"github.com/wealdtech/go-graph/internal/build"
+)
+
+// Tree is the dominator tree of a directed flow graph.  Only nodes that are
+// reachable from the root are part of the tree
+type Tree struct {
+	g            graph.Graph
+	root         int64
+	idom         map[int64]int64
+	children     map[int64][]int64
+	predecessors map[int64][]int64
+	pre          map[int64]int
+	post         map[int64]int
+	// frontiers is calculated on first use
+	frontiers map[int64][]int64
+}
+
+// Find calculates the dominators of a directed graph from an entry node
+// using the Lengauer-Tarjan algorithm.  A node a dominates a node b if
+// every path from the entry to b passes through a
+func Find(g graph.Graph, entry int64) (*Tree, error) {
+	if !graph.IsDirected(g) {
+		return nil, fmt.Errorf("Dominators require a directed graph")
+	}
+	if !g.HasNode(entry) {
+		return nil, fmt.Errorf("Unknown entry node %v", entry)
+	}
+	successors, predecessors := adjacency(g)
+	return newTree(g, entry, successors, predecessors), nil
+}
+
+// FindPost calculates the post-dominators of a directed graph to an exit
+// node.  A node a post-dominates a node b if every path from b to the exit
+// passes through a.  This is the dominator tree of the reversed graph
+func FindPost(g graph.Graph, exit int64) (*Tree, error) {
+	if !graph.IsDirected(g) {
+		return nil, fmt.Errorf("Post-dominators require a directed graph")
+	}
+	if !g.HasNode(exit) {
+		return nil, fmt.Errorf("Unknown exit node %v", exit)
+	}
+	successors, predecessors := adjacency(g)
+	return newTree(g, exit, predecessors, successors), nil
+}
+
+// adjacency returns the sorted successors and predecessors of each node
+func adjacency(g graph.Graph) (map[int64][]int64, map[int64][]int64) {
+	successors := make(map[int64][]int64)
+	predecessors := make(map[int64][]int64)
+	for _, node := range g.Nodes() {
+		for _, edge := range g.Edges(node.Id()) {
+			if edge.From() != node.Id() {
+				continue
+			}
+			successors[edge.From()] = append(successors[edge.From()], edge.To())
+			predecessors[edge.To()] = append(predecessors[edge.To()], edge.From())
+		}
+	}
+	for _, lists := range []map[int64][]int64{successors, predecessors} {
+		for _, list := range lists {
+			sort.Slice(list, func(i, j int) bool { return list[i] < list[j] })
+		}
+	}
+	return successors, predecessors
+}
+
+// newTree calculates the dominator tree of a graph from a root, following
+// the given successors
+func newTree(g graph.Graph, root int64, successors map[int64][]int64, predecessors map[int64][]int64) *Tree {
+	// Depth-first numbering from the root
+	vertex := make([]int64, 0)
+	number := make(map[int64]int)
+	parent := make([]int, 0)
+	type frame struct {
+		nid   int64
+		child int
+	}
+	number[root] = 0
+	vertex = append(vertex, root)
+	parent = append(parent, -1)
+	stack := []frame{{nid: root}}
+	for len(stack) > 0 {
+		f := &stack[len(stack)-1]
+		if f.child >= len(successors[f.nid]) {
+			stack = stack[:len(stack)-1]
+			continue
+		}
+		w := successors[f.nid][f.child]
+		f.child++
+		if _, seen := number[w]; !seen {
+			number[w] = len(vertex)
+			vertex = append(vertex, w)
+			parent = append(parent, number[f.nid])
+			stack = append(stack, frame{nid: w})
+		}
+	}
+
+	n := len(vertex)
+	semi := make([]int, n)
+	idom := make([]int, n)
+	ancestor := make([]int, n)
+	label := make([]int, n)
+	buckets := make([][]int, n)
+	for v := range semi {
+		semi[v] = v
+		ancestor[v] = -1
+		label[v] = v
+	}
+
+	eval := func(v int) int {
+		if ancestor[v] < 0 {
+			return v
+		}
+		// Compress the path from v towards the root of its forest tree
+		path := make([]int, 0)
+		for u := v; ancestor[ancestor[u]] >= 0; u = ancestor[u] {
+			path = append(path, u)
+		}
+		for i := len(path) - 1; i >= 0; i-- {
+			u := path[i]
+			a := ancestor[u]
+			if semi[label[a]] < semi[label[u]] {
+				label[u] = label[a]
+			}
+			ancestor[u] = ancestor[a]
+		}
+		return label[v]
+	}
+
+	for w := n - 1; w > 0; w-- {
+		for _, pid := range predecessors[vertex[w]] {
+			v, reachable := number[pid]
+			if !reachable {
+				continue
+			}
+			if u := eval(v); semi[u] < semi[w] {
+				semi[w] = semi[u]
+			}
+		}
+		buckets[semi[w]] = append(buckets[semi[w]], w)
+		ancestor[w] = parent[w]
+		p := parent[w]
+		for _, v := range buckets[p] {
+			if u := eval(v); semi[u] < semi[v] {
+				idom[v] = u
+			} else {
+				idom[v] = p
+			}
+		}
+		buckets[p] = nil
+	}
+	for w := 1; w < n; w++ {
+		if idom[w] != semi[w] {
+			idom[w] = idom[idom[w]]
+		}
+	}
+
+	t := &Tree{
+		g:            g,
+		root:         root,
+		idom:         make(map[int64]int64, n),
+		children:     make(map[int64][]int64, n),
+		predecessors: predecessors,
+		pre:          make(map[int64]int, n),
+		post:         make(map[int64]int, n),
+	}
+	for w := 1; w < n; w++ {
+		t.idom[vertex[w]] = vertex[idom[w]]
+		t.children[vertex[idom[w]]] = append(t.children[vertex[idom[w]]], vertex[w])
+	}
+	for _, list := range t.children {
+		sort.Slice(list, func(i, j int) bool { return list[i] < list[j] })
+	}
+	t.number()
+	return t
+}
+
+// number assigns pre- and post-order numbers to the dominator tree so that
+// dominance can be checked in constant time
+func (t *Tree) number() {
+	counter := 0
+	type frame struct {
+		nid   int64
+		child int
+	}
+	t.pre[t.root] = counter
+	counter++
+	stack := []frame{{nid: t.root}}
+	for len(stack) > 0 {
+		f := &stack[len(stack)-1]
+		if f.child >= len(t.children[f.nid]) {
+			t.post[f.nid] = counter
+			counter++
+			stack = stack[:len(stack)-1]
+			continue
+		}
+		child := t.children[f.nid][f.child]
+		f.child++
+		t.pre[child] = counter
+		counter++
+		stack = append(stack, frame{nid: child})
+	}
+}
+
+// Root returns the entry node, or the exit node for post-dominators
+func (t *Tree) Root() int64 {
+	return t.root
+}
+
+// Contains returns true if the node is part of the tree, that is it can
+// be reached from the entry, or reach the exit for post-dominators
+func (t *Tree) Contains(nid int64) bool {
+	_, exists := t.pre[nid]
+	return exists
+}
+
+// ImmediateDominator returns the immediate dominator of a node.  The root
+// and nodes not in the tree have no immediate dominator
+func (t *Tree) ImmediateDominator(nid int64) (int64, bool) {
+	idom, exists := t.idom[nid]
+	return idom, exists
+}
+
+// Children returns the nodes immediately dominated by a node, in ascending
+// order
+func (t *Tree) Children(nid int64) []int64 {
+	return append([]int64{}, t.children[nid]...)
+}
+
+// Dominates returns true if a dominates b.  Every node in the tree
+// dominates itself
+func (t *Tree) Dominates(a, b int64) bool {
+	aPre, aok := t.pre[a]
+	bPre, bok := t.pre[b]
+	if !aok || !bok {
+		return false
+	}
+	return aPre <= bPre && t.post[b] <= t.post[a]
+}
+
+// Dominators returns all of the dominators of a node, from the node itself
+// up to the root
+func (t *Tree) Dominators(nid int64) []int64 {
+	if !t.Contains(nid) {
+		return []int64{}
+	}
+	res := []int64{nid}
+	for {
+		idom, exists := t.idom[nid]
+		if !exists {
+			return res
+		}
+		res = append(res, idom)
+		nid = idom
+	}
+}
+
+// Graph returns the dominator tree as a directed graph, with an edge from
+// each immediate dominator to the nodes it immediately dominates.  The
+// nodes are copies of those of the original graph, with their attributes
+func (t *Tree) Graph() (*graphs.DirectedGraph, error) {
+	res := graphs.NewDirectedGraph()
+	for nid := range t.pre {
+		if err := build.AddNode(res, nid, build.CopyAttributes(t.g.Node(nid).Attributes())); err != nil {
+			return nil, err
+		}
+	}
+	for nid, idom := range t.idom {
+		if err := res.AddEdge(edges.NewDirectedEdge(idom, nid)); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+// Frontier returns the dominance frontier of a node: the nodes where its
+// dominance ends.  These are the nodes not strictly dominated by it that
+// have a predecessor that it dominates.  IDs are in ascending order
+func (t *Tree) Frontier(nid int64) []int64 {
+	list, exists := t.frontierSets()[nid]
+	if !exists {
+		return nil
+	}
+	return append([]int64{}, list...)
+}
+
+// Frontiers returns the dominance frontier of every node in the tree,
+// using the algorithm of Cytron et al.  Nodes with an empty frontier are
+// present with an empty slice
+func (t *Tree) Frontiers() map[int64][]int64 {
+	res := make(map[int64][]int64, len(t.pre))
+	for nid, list := range t.frontierSets() {
+		res[nid] = append([]int64{}, list...)
+	}
+	return res
+}
+
+// frontierSets returns the dominance frontiers, calculating them once
+func (t *Tree) frontierSets() map[int64][]int64 {
+	if t.frontiers != nil {
+		return t.frontiers
+	}
+	sets := make(map[int64]map[int64]bool, len(t.pre))
+	for nid := range t.pre {
+		sets[nid] = make(map[int64]bool)
+	}
+	for b := range t.pre {
+		idom, exists := t.idom[b]
+		preds := make([]int64, 0)
+		for _, p := range t.predecessors[b] {
+			if t.Contains(p) {
+				preds = append(preds, p)
+			}
+		}
+		if len(preds) < 2 && (exists || len(preds) == 0) {
+			continue
+		}
+		for _, runner := range preds {
+			for t.Contains(runner) && (!exists || runner != idom) {
+				sets[runner][b] = true
+				next, hasIdom := t.idom[runner]
+				if !hasIdom {
+					break
+				}
+				runner = next
+			}
+		}
+	}
+	res := make(map[int64][]int64, len(sets))
+	for nid, set := range sets {
+		list := make([]int64, 0, len(set))
+		for b := range set {
+			list = append(list, b)
+		}
+		sort.Slice(list, func(i, j int) bool { return list[i] < list[j] })
+		res[nid] = list
+	}
+	t.frontiers = res
+	return res
+}
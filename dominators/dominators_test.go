@@ -0,0 +1,116 @@
+package dominators
+
+// Copyright © 2018 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wealdtech/go-graph/edges"
+	"github.com/wealdtech/go-graph/graphs"
+	"github.com/wealdtech/go-graph/nodes"
+)
+
+// flowGraph creates a loop 2 -> {3, 4} -> 5 -> 2 entered from 1 and
+// exiting to 6, with 7 unreachable from the entry
+func flowGraph(t *testing.T) *graphs.DirectedGraph {
+	g := graphs.NewDirectedGraph()
+	for i := int64(1); i <= 7; i++ {
+		assert.NoError(t, g.AddNode(nodes.NewSimpleNode(i)))
+	}
+	for _, pair := range [][2]int64{{1, 2}, {2, 3}, {2, 4}, {3, 5}, {4, 5}, {5, 6}, {5, 2}, {7, 5}} {
+		assert.NoError(t, g.AddEdge(edges.NewDirectedEdge(pair[0], pair[1])))
+	}
+	return g
+}
+
+func TestDominators(t *testing.T) {
+	g := flowGraph(t)
+	tree, err := Find(g, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), tree.Root())
+
+	expected := map[int64]int64{2: 1, 3: 2, 4: 2, 5: 2, 6: 5}
+	for nid, idom := range expected {
+		actual, exists := tree.ImmediateDominator(nid)
+		assert.True(t, exists)
+		assert.Equal(t, idom, actual, "immediate dominator of %d", nid)
+	}
+	_, exists := tree.ImmediateDominator(1)
+	assert.False(t, exists)
+	assert.False(t, tree.Contains(7))
+
+	assert.Equal(t, []int64{3, 4, 5}, tree.Children(2))
+	assert.Equal(t, []int64{6, 5, 2, 1}, tree.Dominators(6))
+	assert.True(t, tree.Dominates(2, 6))
+	assert.True(t, tree.Dominates(5, 5))
+	assert.False(t, tree.Dominates(3, 5))
+	assert.False(t, tree.Dominates(1, 7))
+
+	g.Node(2).SetAttribute("label", "entry")
+	dt, err := tree.Graph()
+	assert.NoError(t, err)
+	assert.Len(t, dt.Nodes(), 6)
+	assert.Equal(t, "entry", dt.Node(2).Attribute("label"))
+	dt.Node(2).SetAttribute("label", "changed")
+	assert.Equal(t, "entry", g.Node(2).Attribute("label"))
+	assert.True(t, dt.HasEdge(2, 5))
+	assert.True(t, dt.HasEdge(5, 6))
+	assert.False(t, dt.HasEdge(3, 5))
+
+	_, err = Find(g, 8)
+	assert.Error(t, err)
+}
+
+func TestFrontiers(t *testing.T) {
+	tree, err := Find(flowGraph(t), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, map[int64][]int64{
+		1: {},
+		2: {2},
+		3: {5},
+		4: {5},
+		5: {2},
+		6: {},
+	}, tree.Frontiers())
+	assert.Equal(t, []int64{5}, tree.Frontier(3))
+
+	// Frontiers are kept, so changing the results changes nothing
+	tree.Frontier(3)[0] = 0
+	tree.Frontiers()[2][0] = 0
+	assert.Equal(t, []int64{5}, tree.Frontier(3))
+	assert.Equal(t, []int64{2}, tree.Frontier(2))
+}
+
+func TestPostDominators(t *testing.T) {
+	tree, err := FindPost(flowGraph(t), 6)
+	assert.NoError(t, err)
+	expected := map[int64]int64{1: 2, 2: 5, 3: 5, 4: 5, 5: 6, 7: 5}
+	for nid, ipdom := range expected {
+		actual, exists := tree.ImmediateDominator(nid)
+		assert.True(t, exists)
+		assert.Equal(t, ipdom, actual, "immediate post-dominator of %d", nid)
+	}
+	assert.True(t, tree.Dominates(5, 1))
+	assert.False(t, tree.Dominates(3, 2))
+}
+
+func TestUndirected(t *testing.T) {
+	g := graphs.NewUndirectedGraph()
+	assert.NoError(t, g.AddNode(nodes.NewSimpleNode(1)))
+	_, err := Find(g, 1)
+	assert.EqualError(t, err, "Dominators require a directed graph")
+	_, err = FindPost(g, 1)
+	assert.EqualError(t, err, "Post-dominators require a directed graph")
+}
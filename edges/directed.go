@@ -13,10 +13,15 @@ package edges
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
+import (
+	"github.com/wealdtech/go-graph"
+)
+
 type DirectedEdge struct {
-	from  int64
-	to    int64
-	attrs map[interface{}]interface{}
+	from      int64
+	to        int64
+	attrs     map[interface{}]interface{}
+	observers graph.Observers
 }
 
 func NewDirectedEdge(from, to int64) *DirectedEdge {
@@ -44,9 +49,18 @@ func (e *DirectedEdge) Attribute(key interface{}) interface{} {
 }
 
 func (e *DirectedEdge) SetAttributes(attrs map[interface{}]interface{}) {
+	old := e.attrs
 	e.attrs = attrs
+	e.observers.NotifyAll(old, attrs)
 }
 
 func (e *DirectedEdge) SetAttribute(key, value interface{}) {
+	old := e.attrs[key]
 	e.attrs[key] = value
+	e.observers.Notify(key, old, value)
+}
+
+// Observe adds an observer of changes to the edge's attributes
+func (e *DirectedEdge) Observe(observer graph.AttributeObserver) func() {
+	return e.observers.Observe(observer)
 }
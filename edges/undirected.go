@@ -13,10 +13,15 @@ package edges
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
+import (
+	"github.com/wealdtech/go-graph"
+)
+
 type UndirectedEdge struct {
-	from  int64
-	to    int64
-	attrs map[interface{}]interface{}
+	from      int64
+	to        int64
+	attrs     map[interface{}]interface{}
+	observers graph.Observers
 }
 
 func NewUndirectedEdge(from, to int64) *UndirectedEdge {
@@ -50,9 +55,18 @@ func (e *UndirectedEdge) Attribute(key interface{}) interface{} {
 }
 
 func (e *UndirectedEdge) SetAttributes(attrs map[interface{}]interface{}) {
+	old := e.attrs
 	e.attrs = attrs
+	e.observers.NotifyAll(old, attrs)
 }
 
 func (e *UndirectedEdge) SetAttribute(key, value interface{}) {
+	old := e.attrs[key]
 	e.attrs[key] = value
+	e.observers.Notify(key, old, value)
+}
+
+// Observe adds an observer of changes to the edge's attributes
+func (e *UndirectedEdge) Observe(observer graph.AttributeObserver) func() {
+	return e.observers.Observe(observer)
 }
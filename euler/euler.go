@@ -0,0 +1,221 @@
+package euler
+
+// Copyright © 2018 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/wealdtech/go-graph"
+)
+
+// HasCircuit returns true if the graph has an Eulerian circuit: a closed
+// walk that traverses every edge exactly once
+func HasCircuit(g graph.Graph) bool {
+	_, err := Circuit(g)
+	return err == nil
+}
+
+// HasPath returns true if the graph has an Eulerian path: a walk that
+// traverses every edge exactly once.  Every Eulerian circuit is also an
+// Eulerian path
+func HasPath(g graph.Graph) bool {
+	_, err := Path(g)
+	return err == nil
+}
+
+// Circuit returns the node IDs visited by an Eulerian circuit of the graph
+// using Hierholzer's algorithm.  The circuit starts and ends at the lowest
+// node ID with edges
+func Circuit(g graph.Graph) ([]int64, error) {
+	m := newMultigraph(g)
+	for _, nid := range m.ids {
+		if m.balance(nid) != 0 {
+			return nil, fmt.Errorf("Node %v is unbalanced so there is no Eulerian circuit", nid)
+		}
+	}
+	start, found := m.firstWithEdges()
+	if !found {
+		return []int64{}, nil
+	}
+	return m.tour(start)
+}
+
+// Path returns the node IDs visited by an Eulerian path of the graph using
+// Hierholzer's algorithm.  If the graph also has an Eulerian circuit the
+// path is that circuit
+func Path(g graph.Graph) ([]int64, error) {
+	m := newMultigraph(g)
+	start, found := m.firstWithEdges()
+	if !found {
+		return []int64{}, nil
+	}
+	unbalanced := make([]int64, 0)
+	for _, nid := range m.ids {
+		if m.balance(nid) != 0 {
+			unbalanced = append(unbalanced, nid)
+		}
+	}
+	switch len(unbalanced) {
+	case 0:
+	case 2:
+		if m.directed {
+			// Must start at the node with one more outgoing than incoming edge
+			a, b := m.balance(unbalanced[0]), m.balance(unbalanced[1])
+			switch {
+			case a == 1 && b == -1:
+				start = unbalanced[0]
+			case a == -1 && b == 1:
+				start = unbalanced[1]
+			default:
+				return nil, fmt.Errorf("Nodes %v and %v are too unbalanced for an Eulerian path", unbalanced[0], unbalanced[1])
+			}
+		} else {
+			start = unbalanced[0]
+		}
+	default:
+		return nil, fmt.Errorf("%d nodes are unbalanced so there is no Eulerian path", len(unbalanced))
+	}
+	return m.tour(start)
+}
+
+// multigraph holds the edges of a graph in a form that allows edges to be
+// duplicated
+type multigraph struct {
+	directed bool
+	ids      []int64
+	ends     [][2]int64
+	edges    []graph.Edge
+	adj      map[int64][]int
+	in       map[int64]int
+}
+
+func newMultigraph(g graph.Graph) *multigraph {
+	m := &multigraph{
+		directed: graph.IsDirected(g),
+		ids:      make([]int64, 0),
+		adj:      make(map[int64][]int),
+		in:       make(map[int64]int),
+	}
+	for _, node := range g.Nodes() {
+		m.ids = append(m.ids, node.Id())
+	}
+	sort.Slice(m.ids, func(i, j int) bool { return m.ids[i] < m.ids[j] })
+	seen := make(map[graph.Edge]bool)
+	for _, nid := range m.ids {
+		for _, edge := range g.Edges(nid) {
+			if !seen[edge] {
+				seen[edge] = true
+				m.add(edge)
+			}
+		}
+	}
+	for _, nid := range m.ids {
+		m.sortAdjacent(nid)
+	}
+	return m
+}
+
+// add adds a copy of an edge to the multigraph, returning its index
+func (m *multigraph) add(edge graph.Edge) int {
+	e := len(m.ends)
+	m.ends = append(m.ends, [2]int64{edge.From(), edge.To()})
+	m.edges = append(m.edges, edge)
+	m.adj[edge.From()] = append(m.adj[edge.From()], e)
+	if m.directed {
+		m.in[edge.To()]++
+	} else if edge.From() != edge.To() {
+		m.adj[edge.To()] = append(m.adj[edge.To()], e)
+	}
+	return e
+}
+
+func (m *multigraph) sortAdjacent(nid int64) {
+	list := m.adj[nid]
+	sort.SliceStable(list, func(i, j int) bool {
+		return m.other(list[i], nid) < m.other(list[j], nid)
+	})
+}
+
+// other returns the node at the other end of an edge from the given node
+func (m *multigraph) other(e int, nid int64) int64 {
+	if m.ends[e][0] == nid {
+		return m.ends[e][1]
+	}
+	return m.ends[e][0]
+}
+
+// balance returns outgoing minus incoming edges for a directed graph, or
+// the parity of the degree for an undirected graph
+func (m *multigraph) balance(nid int64) int {
+	if m.directed {
+		return len(m.adj[nid]) - m.in[nid]
+	}
+	degree := 0
+	for _, e := range m.adj[nid] {
+		if m.ends[e][0] == m.ends[e][1] {
+			degree += 2
+		} else {
+			degree++
+		}
+	}
+	return degree % 2
+}
+
+func (m *multigraph) firstWithEdges() (int64, bool) {
+	for _, nid := range m.ids {
+		if len(m.adj[nid]) > 0 {
+			return nid, true
+		}
+	}
+	return 0, false
+}
+
+// tour carries out Hierholzer's algorithm from a start node
+func (m *multigraph) tour(start int64) ([]int64, error) {
+	used := make([]bool, len(m.ends))
+	next := make(map[int64]int)
+	stack := []int64{start}
+	path := make([]int64, 0, len(m.ends)+1)
+	for len(stack) > 0 {
+		v := stack[len(stack)-1]
+		advanced := false
+		for next[v] < len(m.adj[v]) {
+			e := m.adj[v][next[v]]
+			next[v]++
+			if used[e] {
+				continue
+			}
+			used[e] = true
+			if m.directed {
+				stack = append(stack, m.ends[e][1])
+			} else {
+				stack = append(stack, m.other(e, v))
+			}
+			advanced = true
+			break
+		}
+		if !advanced {
+			stack = stack[:len(stack)-1]
+			path = append(path, v)
+		}
+	}
+	if len(path) != len(m.ends)+1 {
+		return nil, fmt.Errorf("Edges are not all connected")
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path, nil
+}
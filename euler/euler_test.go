@@ -0,0 +1,150 @@
+package euler
+
+// Copyright © 2018 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wealdtech/go-graph"
+	"github.com/wealdtech/go-graph/edges"
+	"github.com/wealdtech/go-graph/graphs"
+	"github.com/wealdtech/go-graph/nodes"
+)
+
+func undirected(t *testing.T, n int64, pairs [][2]int64) *graphs.UndirectedGraph {
+	g := graphs.NewUndirectedGraph()
+	for i := int64(1); i <= n; i++ {
+		assert.NoError(t, g.AddNode(nodes.NewSimpleNode(i)))
+	}
+	for _, pair := range pairs {
+		assert.NoError(t, g.AddEdge(edges.NewUndirectedEdge(pair[0], pair[1])))
+	}
+	return g
+}
+
+func directed(t *testing.T, n int64, pairs [][2]int64) *graphs.DirectedGraph {
+	g := graphs.NewDirectedGraph()
+	for i := int64(1); i <= n; i++ {
+		assert.NoError(t, g.AddNode(nodes.NewSimpleNode(i)))
+	}
+	for _, pair := range pairs {
+		assert.NoError(t, g.AddEdge(edges.NewDirectedEdge(pair[0], pair[1])))
+	}
+	return g
+}
+
+// assertWalk checks that a walk has the given number of steps, each along
+// an edge of the graph
+func assertWalk(t *testing.T, g graph.Graph, walk []int64, length int) {
+	assert.Len(t, walk, length+1)
+	for i := 0; i < len(walk)-1; i++ {
+		assert.True(t, g.HasEdge(walk[i], walk[i+1]), "no edge from %d to %d", walk[i], walk[i+1])
+	}
+}
+
+func TestUndirectedCircuit(t *testing.T) {
+	// Two triangles sharing node 1
+	g := undirected(t, 5, [][2]int64{{1, 2}, {2, 3}, {3, 1}, {1, 4}, {4, 5}, {5, 1}})
+	assert.True(t, HasCircuit(g))
+	circuit, err := Circuit(g)
+	assert.NoError(t, err)
+	assertWalk(t, g, circuit, 6)
+	assert.Equal(t, int64(1), circuit[0])
+	assert.Equal(t, int64(1), circuit[6])
+
+	// Remove an edge to leave a path from 1 to 5
+	g.RemoveEdge(5, 1)
+	assert.False(t, HasCircuit(g))
+	assert.True(t, HasPath(g))
+	path, err := Path(g)
+	assert.NoError(t, err)
+	assertWalk(t, g, path, 5)
+	assert.Equal(t, int64(1), path[0])
+	assert.Equal(t, int64(5), path[5])
+
+	// Star with three leaves has no path
+	g = undirected(t, 4, [][2]int64{{1, 2}, {1, 3}, {1, 4}})
+	assert.False(t, HasPath(g))
+
+	// Disconnected edges have no circuit
+	g = undirected(t, 6, [][2]int64{{1, 2}, {2, 3}, {3, 1}, {4, 5}, {5, 6}, {6, 4}})
+	_, err = Circuit(g)
+	assert.Error(t, err)
+}
+
+func TestDirectedCircuit(t *testing.T) {
+	g := directed(t, 4, [][2]int64{{1, 2}, {2, 3}, {3, 1}, {3, 4}, {4, 3}})
+	circuit, err := Circuit(g)
+	assert.NoError(t, err)
+	assertWalk(t, g, circuit, 5)
+
+	// 1 -> 2 -> 3 -> 1 -> 4 has a path from 1 to 4
+	g = directed(t, 4, [][2]int64{{1, 2}, {2, 3}, {3, 1}, {1, 4}})
+	assert.False(t, HasCircuit(g))
+	path, err := Path(g)
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{1, 2, 3, 1, 4}, path)
+
+	// Two sources
+	g = directed(t, 3, [][2]int64{{1, 3}, {2, 3}})
+	assert.False(t, HasPath(g))
+}
+
+func TestUndirectedPostman(t *testing.T) {
+	// Square with a diagonal: 2 and 4 are odd
+	g := undirected(t, 4, [][2]int64{{1, 2}, {2, 3}, {3, 4}, {4, 1}, {2, 4}})
+	route, err := ChinesePostman(g, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 6.0, route.Cost)
+	assert.Len(t, route.Duplicated, 1)
+	assert.Equal(t, g.Edge(2, 4), route.Duplicated[0])
+	assertWalk(t, g, route.Nodes, 6)
+	assert.Equal(t, route.Nodes[0], route.Nodes[6])
+
+	// Weighted, so the cheaper detour via 1 is duplicated instead of the diagonal
+	g.Edge(2, 4).SetAttribute("weight", 5)
+	route, err = ChinesePostman(g, graph.AttributeWeight("weight"))
+	assert.NoError(t, err)
+	assert.Equal(t, 11.0, route.Cost)
+	assert.Len(t, route.Duplicated, 2)
+
+	// Already Eulerian
+	g = undirected(t, 3, [][2]int64{{1, 2}, {2, 3}, {3, 1}})
+	route, err = ChinesePostman(g, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 3.0, route.Cost)
+	assert.Len(t, route.Duplicated, 0)
+
+	// Negative weights are rejected
+	g.Edge(2, 3).SetAttribute("weight", -1)
+	_, err = ChinesePostman(g, graph.AttributeWeight("weight"))
+	assert.EqualError(t, err, "Edge from 2 to 3 has negative weight")
+}
+
+func TestDirectedPostman(t *testing.T) {
+	// 1 -> 2 -> 3 -> 1 plus 1 -> 3 needs 3 -> 1 duplicated
+	g := directed(t, 3, [][2]int64{{1, 2}, {2, 3}, {3, 1}, {1, 3}})
+	route, err := ChinesePostman(g, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 5.0, route.Cost)
+	assert.Len(t, route.Duplicated, 1)
+	assert.Equal(t, g.Edge(3, 1), route.Duplicated[0])
+	assertWalk(t, g, route.Nodes, 5)
+
+	// Not strongly connected
+	g = directed(t, 2, [][2]int64{{1, 2}})
+	_, err = ChinesePostman(g, nil)
+	assert.Error(t, err)
+}
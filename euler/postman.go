@@ -0,0 +1,259 @@
+package euler
+
+// Copyright © 2018 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"container/heap"
+	"fmt"
+	"math"
+
+	"github.com/wealdtech/go-graph"
+	"github.com/wealdtech/go-graph/internal/flow"
+	"github.com/wealdtech/go-graph/internal/queue"
+)
+
+// maxOddNodes is the largest number of odd-degree nodes for which an
+// undirected postman route is calculated, as the matching is exponential
+const maxOddNodes = 20
+
+// Route is a closed walk that covers every edge of a graph
+type Route struct {
+	// Nodes are the IDs of the nodes visited, starting and ending at the same node
+	Nodes []int64
+	// Cost is the total weight of the edges traversed
+	Cost float64
+	// Duplicated are the edges traversed more than once, repeated for each extra traversal
+	Duplicated []graph.Edge
+}
+
+// ChinesePostman returns a minimum-cost closed walk that traverses every
+// edge of the graph at least once, duplicating edges where required.
+// Weights must be non-negative; a nil weight function treats all edges as
+// having weight 1.  Undirected graphs are limited to 20 odd-degree nodes
+func ChinesePostman(g graph.Graph, weight graph.WeightFunc) (*Route, error) {
+	if weight == nil {
+		weight = graph.UnitWeight
+	}
+	m := newMultigraph(g)
+	for _, edge := range m.edges {
+		if weight(edge) < 0 {
+			return nil, fmt.Errorf("Edge from %v to %v has negative weight", edge.From(), edge.To())
+		}
+	}
+	original := len(m.ends)
+
+	var err error
+	if m.directed {
+		err = m.balanceDirected(weight)
+	} else {
+		err = m.pairOddNodes(weight)
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, nid := range m.ids {
+		m.sortAdjacent(nid)
+	}
+
+	route := &Route{
+		Nodes:      []int64{},
+		Duplicated: make([]graph.Edge, 0),
+	}
+	start, found := m.firstWithEdges()
+	if !found {
+		return route, nil
+	}
+	route.Nodes, err = m.tour(start)
+	if err != nil {
+		return nil, err
+	}
+	for e, edge := range m.edges {
+		route.Cost += weight(edge)
+		if e >= original {
+			route.Duplicated = append(route.Duplicated, edge)
+		}
+	}
+	return route, nil
+}
+
+// pairOddNodes duplicates the shortest paths between a minimum-cost
+// perfect matching of the odd-degree nodes of an undirected graph
+func (m *multigraph) pairOddNodes(weight graph.WeightFunc) error {
+	odd := make([]int64, 0)
+	for _, nid := range m.ids {
+		if m.balance(nid) != 0 {
+			odd = append(odd, nid)
+		}
+	}
+	if len(odd) == 0 {
+		return nil
+	}
+	if len(odd) > maxOddNodes {
+		return fmt.Errorf("Too many odd-degree nodes (%d) to find a route", len(odd))
+	}
+
+	paths := make([]*shortestPaths, len(odd))
+	for i, nid := range odd {
+		paths[i] = m.dijkstra(nid, weight)
+	}
+
+	// Minimum-cost perfect matching over subsets of the odd nodes
+	size := 1 << uint(len(odd))
+	cost := make([]float64, size)
+	choice := make([]int, size)
+	for mask := 1; mask < size; mask++ {
+		cost[mask] = math.Inf(1)
+		i := 0
+		for mask&(1<<uint(i)) == 0 {
+			i++
+		}
+		for j := i + 1; j < len(odd); j++ {
+			if mask&(1<<uint(j)) == 0 {
+				continue
+			}
+			d, reachable := paths[i].distance[odd[j]]
+			if !reachable {
+				continue
+			}
+			rest := mask &^ (1 << uint(i)) &^ (1 << uint(j))
+			if c := cost[rest] + d; c < cost[mask] {
+				cost[mask] = c
+				choice[mask] = j
+			}
+		}
+	}
+	full := size - 1
+	if math.IsInf(cost[full], 1) {
+		return fmt.Errorf("Edges are not all connected")
+	}
+	for mask := full; mask != 0; {
+		i := 0
+		for mask&(1<<uint(i)) == 0 {
+			i++
+		}
+		j := choice[mask]
+		m.duplicatePath(paths[i], odd[j])
+		mask = mask &^ (1 << uint(i)) &^ (1 << uint(j))
+	}
+	return nil
+}
+
+// balanceDirected duplicates shortest paths from nodes with surplus
+// incoming edges to nodes with surplus outgoing edges, choosing the
+// minimum-cost combination with a min-cost flow
+func (m *multigraph) balanceDirected(weight graph.WeightFunc) error {
+	sources := make([]int64, 0)
+	sinks := make([]int64, 0)
+	for _, nid := range m.ids {
+		b := m.balance(nid)
+		if b < 0 {
+			sources = append(sources, nid)
+		} else if b > 0 {
+			sinks = append(sinks, nid)
+		}
+	}
+	if len(sources) == 0 {
+		return nil
+	}
+
+	paths := make([]*shortestPaths, len(sources))
+	for i, nid := range sources {
+		paths[i] = m.dijkstra(nid, weight)
+	}
+
+	// Transportation network: 0 is the source, 1..S the sources, S+1..S+T
+	// the sinks and S+T+1 the sink
+	f := flow.New(len(sources) + len(sinks) + 2)
+	sink := len(sources) + len(sinks) + 1
+	required := 0
+	for i, nid := range sources {
+		f.AddArc(0, 1+i, -m.balance(nid), 0)
+		required += -m.balance(nid)
+	}
+	for j, nid := range sinks {
+		f.AddArc(1+len(sources)+j, sink, m.balance(nid), 0)
+	}
+	transfers := make(map[int][2]int)
+	for i := range sources {
+		for j, nid := range sinks {
+			d, reachable := paths[i].distance[nid]
+			if !reachable {
+				continue
+			}
+			arc := f.AddArc(1+i, 1+len(sources)+j, required, d)
+			transfers[arc] = [2]int{i, j}
+		}
+	}
+	if f.MinCostFlow(0, sink) < required {
+		return fmt.Errorf("Graph is not strongly connected")
+	}
+	for arc, transfer := range transfers {
+		for k := 0; k < f.Arcs[arc].Flow; k++ {
+			m.duplicatePath(paths[transfer[0]], sinks[transfer[1]])
+		}
+	}
+	return nil
+}
+
+// shortestPaths holds the results of a single-source shortest path search
+type shortestPaths struct {
+	distance map[int64]float64
+	via      map[int64]int
+}
+
+// dijkstra finds the shortest paths from a node over the multigraph's edges
+func (m *multigraph) dijkstra(source int64, weight graph.WeightFunc) *shortestPaths {
+	res := &shortestPaths{
+		distance: map[int64]float64{source: 0},
+		via:      make(map[int64]int),
+	}
+	done := make(map[int64]bool)
+	pq := &queue.Priority{{ID: source}}
+	for pq.Len() > 0 {
+		item := heap.Pop(pq).(queue.Item)
+		if done[item.ID] {
+			continue
+		}
+		done[item.ID] = true
+		for _, e := range m.adj[item.ID] {
+			next := m.other(e, item.ID)
+			if m.directed {
+				next = m.ends[e][1]
+			}
+			d := item.Distance + weight(m.edges[e])
+			if current, exists := res.distance[next]; !exists || d < current {
+				res.distance[next] = d
+				res.via[next] = e
+				heap.Push(pq, queue.Item{ID: next, Distance: d})
+			}
+		}
+	}
+	return res
+}
+
+// duplicatePath adds a copy of each edge on the shortest path to a node
+func (m *multigraph) duplicatePath(paths *shortestPaths, to int64) {
+	for {
+		e, exists := paths.via[to]
+		if !exists {
+			return
+		}
+		from := m.other(e, to)
+		if m.directed {
+			from = m.ends[e][0]
+		}
+		m.add(m.edges[e])
+		to = from
+	}
+}
@@ -0,0 +1,71 @@
+package dot
+
+// Copyright © 2018 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"github.com/wealdtech/go-graph"
+)
+
+// Palette is the list of colours used to render classes of nodes
+var Palette = []string{
+	"lightblue",
+	"lightcoral",
+	"palegreen",
+	"gold",
+	"plum",
+	"lightsalmon",
+	"paleturquoise",
+	"khaki",
+	"thistle",
+	"lightpink",
+	"darkseagreen",
+	"wheat",
+}
+
+// Colour returns the palette colour for a class
+func Colour(class int) string {
+	if class < 0 {
+		class = -class
+	}
+	return Palette[class%len(Palette)]
+}
+
+// ColourNodes fills each node with the palette colour for the integer class
+// held in the given node attribute, for example a community ID.  Nodes
+// without an integer value for the attribute are left unchanged
+func ColourNodes(g graph.Graph, key interface{}) {
+	for _, node := range g.Nodes() {
+		class, ok := node.Attribute(key).(int)
+		if !ok {
+			continue
+		}
+		node.SetAttribute("style", "filled")
+		node.SetAttribute("fillcolor", Colour(class))
+	}
+}
+
+// ColourEdges colours each edge with the palette colour for the integer
+// class held in the given edge attribute, for example an edge colouring.
+// Edges without an integer value for the attribute are left unchanged
+func ColourEdges(g graph.Graph, key interface{}) {
+	for _, node := range g.Nodes() {
+		for _, edge := range g.Edges(node.Id()) {
+			class, ok := edge.Attribute(key).(int)
+			if !ok {
+				continue
+			}
+			edge.SetAttribute("color", Colour(class))
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package dot
+
+// Copyright © 2018 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/wealdtech/go-graph"
+	"github.com/wealdtech/go-graph/diff"
+)
+
+// DiffStyles are the attributes that MarshalDiff adds to changed nodes
+// and edges.  They override the nodes' and edges' own attributes
+type DiffStyles struct {
+	Added   map[string]string
+	Removed map[string]string
+	Changed map[string]string
+	// ChangeAttribute is the attribute that lists attribute changes, for
+	// example "tooltip", "label" or "xlabel".  If empty, changes are not
+	// listed
+	ChangeAttribute string
+}
+
+// DefaultDiffStyles returns styles that show added nodes and edges in
+// green, removed ones in red and dashed, and changed ones in orange with
+// the changes in a tooltip
+func DefaultDiffStyles() *DiffStyles {
+	return &DiffStyles{
+		Added:           map[string]string{"color": "green", "fontcolor": "green"},
+		Removed:         map[string]string{"color": "red", "fontcolor": "red", "style": "dashed"},
+		Changed:         map[string]string{"color": "orange"},
+		ChangeAttribute: "tooltip",
+	}
+}
+
+// MarshalDiff renders two graphs overlaid, marking the nodes and edges
+// added, removed and changed going from the first graph to the second
+// with the given styles, or the default styles if nil.  Removed nodes and
+// edges keep their attributes from the first graph and the rest take
+// theirs from the second, as do the defaults
+func MarshalDiff(from, to graph.Graph, styles *DiffStyles) ([]byte, error) {
+	changes, err := diff.Diff(from, to)
+	if err != nil {
+		return nil, err
+	}
+	if styles == nil {
+		styles = DefaultDiffStyles()
+	}
+
+	added := make(map[int64]bool)
+	for _, node := range changes.AddedNodes {
+		added[node.ID] = true
+	}
+	removed := make(map[int64]bool)
+	for _, node := range changes.RemovedNodes {
+		removed[node.ID] = true
+	}
+	changed := make(map[int64][]diff.AttributeChange)
+	for _, node := range changes.ChangedNodes {
+		changed[node.ID] = node.Attributes
+	}
+	addedEdges := make(map[[2]int64]bool)
+	for _, edge := range changes.AddedEdges {
+		addedEdges[[2]int64{edge.From, edge.To}] = true
+	}
+	removedEdges := make(map[[2]int64]bool)
+	for _, edge := range changes.RemovedEdges {
+		removedEdges[[2]int64{edge.From, edge.To}] = true
+	}
+	changedEdges := make(map[[2]int64][]diff.AttributeChange)
+	for _, edge := range changes.ChangedEdges {
+		changedEdges[[2]int64{edge.From, edge.To}] = edge.Attributes
+	}
+
+	var buffer bytes.Buffer
+	if changes.Directed {
+		buffer.WriteString("digraph g {\n")
+	} else {
+		buffer.WriteString("graph g {\n")
+	}
+	graphDefaults(to, &buffer)
+	nodeDefaults(to, &buffer)
+	edgeDefaults(to, &buffer)
+
+	var sortedNodeKeys []int64
+	for _, node := range to.Nodes() {
+		sortedNodeKeys = append(sortedNodeKeys, node.Id())
+	}
+	for nid := range removed {
+		sortedNodeKeys = append(sortedNodeKeys, nid)
+	}
+	sort.Slice(sortedNodeKeys, func(i, j int) bool { return sortedNodeKeys[i] < sortedNodeKeys[j] })
+	for _, nid := range sortedNodeKeys {
+		var node graph.Node
+		var attrs *map[interface{}]interface{}
+		switch {
+		case removed[nid]:
+			node = from.Node(nid)
+			attrs = styled(node.Attributes(), styles.Removed, "", nil)
+		case added[nid]:
+			node = to.Node(nid)
+			attrs = styled(node.Attributes(), styles.Added, "", nil)
+		case changed[nid] != nil:
+			node = to.Node(nid)
+			attrs = styled(node.Attributes(), styles.Changed, styles.ChangeAttribute, changed[nid])
+		default:
+			node = to.Node(nid)
+			attrs = node.Attributes()
+		}
+		buffer.WriteString(fmt.Sprintf("  %d", nid))
+		writeAttrs(to, attrs, &buffer)
+		buffer.WriteString(";\n")
+
+		var sortedEdgeKeys []int64
+		for _, edge := range to.Edges(nid) {
+			if edge.From() == nid {
+				sortedEdgeKeys = append(sortedEdgeKeys, edge.To())
+			}
+		}
+		for _, edge := range from.Edges(nid) {
+			if edge.From() == nid && removedEdges[[2]int64{nid, edge.To()}] {
+				sortedEdgeKeys = append(sortedEdgeKeys, edge.To())
+			}
+		}
+		sort.Slice(sortedEdgeKeys, func(i, j int) bool { return sortedEdgeKeys[i] < sortedEdgeKeys[j] })
+		for _, bid := range sortedEdgeKeys {
+			key := [2]int64{nid, bid}
+			var edge graph.Edge
+			switch {
+			case removedEdges[key]:
+				edge = from.Edge(nid, bid)
+				attrs = styled(edge.Attributes(), styles.Removed, "", nil)
+			case addedEdges[key]:
+				edge = to.Edge(nid, bid)
+				attrs = styled(edge.Attributes(), styles.Added, "", nil)
+			case changedEdges[key] != nil:
+				edge = to.Edge(nid, bid)
+				attrs = styled(edge.Attributes(), styles.Changed, styles.ChangeAttribute, changedEdges[key])
+			default:
+				edge = to.Edge(nid, bid)
+				attrs = edge.Attributes()
+			}
+			if changes.Directed {
+				buffer.WriteString(fmt.Sprintf("  %d -> %d", edge.From(), edge.To()))
+			} else {
+				buffer.WriteString(fmt.Sprintf("  %d -- %d", edge.From(), edge.To()))
+			}
+			writeAttrs(to, attrs, &buffer)
+			buffer.WriteString(";\n")
+		}
+	}
+
+	buffer.WriteString("}")
+	return buffer.Bytes(), nil
+}
+
+// styled returns a copy of a set of attributes with a style applied and,
+// if an attribute is given, the changes listed in it
+func styled(attrs *map[interface{}]interface{}, style map[string]string, attribute string, changes []diff.AttributeChange) *map[interface{}]interface{} {
+	res := make(map[interface{}]interface{})
+	if attrs != nil {
+		for key, value := range *attrs {
+			res[key] = value
+		}
+	}
+	for key, value := range style {
+		res[key] = value
+	}
+	if attribute != "" && len(changes) > 0 {
+		descriptions := make([]string, len(changes))
+		for i, change := range changes {
+			descriptions[i] = fmt.Sprintf("%v: %v -> %v", change.Key, describe(change.Old), describe(change.New))
+		}
+		res[attribute] = strings.Join(descriptions, `\n`)
+	}
+	return &res
+}
+
+func describe(value interface{}) string {
+	if value == nil {
+		return "(none)"
+	}
+	return fmt.Sprintf("%v", value)
+}
@@ -19,18 +19,11 @@ import (
 	"sort"
 
 	"github.com/wealdtech/go-graph"
-	"github.com/wealdtech/go-graph/graphs"
 )
 
 func Marshal(g graph.Graph) []byte {
 	var buffer bytes.Buffer
-	var directed bool
-	switch g.(type) {
-	case *graphs.UndirectedGraph:
-		directed = false
-	case *graphs.DirectedGraph:
-		directed = true
-	}
+	directed := graph.IsDirected(g)
 
 	if directed {
 		buffer.WriteString("digraph g {\n")
@@ -112,3 +112,122 @@ func TestNodeOrdering(t *testing.T) {
   10;
 }`, string(output))
 }
+
+func TestColourNodes(t *testing.T) {
+	g := graphs.NewUndirectedGraph()
+	node1 := nodes.NewSimpleNode(1)
+	node1.SetAttribute("class", 0)
+	err := g.AddNode(node1)
+	assert.NoError(t, err)
+	node2 := nodes.NewSimpleNode(2)
+	node2.SetAttribute("class", 1)
+	err = g.AddNode(node2)
+	assert.NoError(t, err)
+	err = g.AddNode(nodes.NewSimpleNode(3))
+	assert.NoError(t, err)
+
+	ColourNodes(g, "class")
+	output := Marshal(g)
+	assert.Equal(t, `graph g {
+  1 [ class="0" fillcolor="lightblue" style="filled" ];
+  2 [ class="1" fillcolor="lightcoral" style="filled" ];
+  3;
+}`, string(output))
+}
+
+func TestColourEdges(t *testing.T) {
+	g := graphs.NewUndirectedGraph()
+	for i := int64(1); i <= 3; i++ {
+		err := g.AddNode(nodes.NewSimpleNode(i))
+		assert.NoError(t, err)
+	}
+	edge12 := edges.NewUndirectedEdge(1, 2)
+	edge12.SetAttribute("class", 2)
+	err := g.AddEdge(edge12)
+	assert.NoError(t, err)
+	err = g.AddEdge(edges.NewUndirectedEdge(2, 3))
+	assert.NoError(t, err)
+
+	ColourEdges(g, "class")
+	output := Marshal(g)
+	assert.Equal(t, `graph g {
+  1;
+  1 -- 2 [ class="2" color="palegreen" ];
+  2;
+  2 -- 3;
+  3;
+}`, string(output))
+}
+
+func TestMarshalDiff(t *testing.T) {
+	from := graphs.NewDirectedGraph()
+	to := graphs.NewDirectedGraph()
+	for i := int64(1); i <= 3; i++ {
+		assert.NoError(t, from.AddNode(nodes.NewSimpleNode(i)))
+	}
+	for _, i := range []int64{1, 2, 4} {
+		assert.NoError(t, to.AddNode(nodes.NewSimpleNode(i)))
+	}
+	from.Node(1).SetAttribute("label", "web")
+	to.Node(1).SetAttribute("label", "proxy")
+	to.Node(1).SetAttribute("shape", "box")
+	assert.NoError(t, from.AddEdge(edges.NewDirectedEdge(1, 2)))
+	assert.NoError(t, from.AddEdge(edges.NewDirectedEdge(2, 3)))
+	assert.NoError(t, to.AddEdge(edges.NewDirectedEdge(1, 2)))
+	assert.NoError(t, to.AddEdge(edges.NewDirectedEdge(1, 4)))
+	to.Edge(1, 2).SetAttribute("weight", 2)
+
+	output, err := MarshalDiff(from, to, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, `digraph g {
+  1 [ color="orange" label="proxy" shape="box" tooltip="label: web -> proxy\nshape: (none) -> box" ];
+  1 -> 2 [ color="orange" tooltip="weight: (none) -> 2" weight="2" ];
+  1 -> 4 [ color="green" fontcolor="green" ];
+  2;
+  2 -> 3 [ color="red" fontcolor="red" style="dashed" ];
+  3 [ color="red" fontcolor="red" style="dashed" ];
+  4 [ color="green" fontcolor="green" ];
+}`, string(output))
+
+	styles := &DiffStyles{
+		Added:           map[string]string{"penwidth": "2"},
+		ChangeAttribute: "xlabel",
+	}
+	output, err = MarshalDiff(from, to, styles)
+	assert.NoError(t, err)
+	assert.Equal(t, `digraph g {
+  1 [ label="proxy" shape="box" xlabel="label: web -> proxy\nshape: (none) -> box" ];
+  1 -> 2 [ weight="2" xlabel="weight: (none) -> 2" ];
+  1 -> 4 [ penwidth="2" ];
+  2;
+  2 -> 3;
+  3;
+  4 [ penwidth="2" ];
+}`, string(output))
+
+	_, err = MarshalDiff(from, graphs.NewUndirectedGraph(), nil)
+	assert.EqualError(t, err, "Graphs must both be directed or both be undirected")
+}
+
+func TestMarshalDiffUndirected(t *testing.T) {
+	from := graphs.NewUndirectedGraph()
+	to := graphs.NewUndirectedGraph()
+	for i := int64(1); i <= 3; i++ {
+		assert.NoError(t, from.AddNode(nodes.NewSimpleNode(i)))
+		assert.NoError(t, to.AddNode(nodes.NewSimpleNode(i)))
+	}
+	assert.NoError(t, from.AddEdge(edges.NewUndirectedEdge(3, 1)))
+	assert.NoError(t, to.AddEdge(edges.NewUndirectedEdge(2, 1)))
+	to.SetEdgeDefaults(map[interface{}]interface{}{"arrowhead": "none"})
+
+	output, err := MarshalDiff(from, to, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, `graph g {
+  edge [ arrowhead="none" ];
+  1;
+  1 -- 2 [ color="green" fontcolor="green" ];
+  1 -- 3 [ color="red" fontcolor="red" style="dashed" ];
+  2;
+  3;
+}`, string(output))
+}
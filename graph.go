@@ -62,6 +62,21 @@ type Graph interface {
 	Edges(nid int64) []Edge
 }
 
+// Directional is implemented by graphs that know whether their edges are
+// directed
+type Directional interface {
+	IsDirected() bool
+}
+
+// IsDirected returns true if a graph's edges are directed.  Graphs that do
+// not implement Directional are taken to be undirected
+func IsDirected(g Graph) bool {
+	if d, ok := g.(Directional); ok {
+		return d.IsDirected()
+	}
+	return false
+}
+
 type NodeManager interface {
 	AddNode(node Node) error
 	RemoveNode(nid int64) Node
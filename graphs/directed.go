@@ -22,21 +22,30 @@ import (
 type DirectedGraph struct {
 	nodes         map[int64]graph.Node
 	edges         map[int64]map[int64]graph.Edge
+	incoming      map[int64]map[int64]graph.Edge
 	graphDefaults map[interface{}]interface{}
 	nodeDefaults  map[interface{}]interface{}
 	edgeDefaults  map[interface{}]interface{}
+	indexes       indexes
+	watchers      graph.Watchers
 }
 
 func NewDirectedGraph() *DirectedGraph {
 	return &DirectedGraph{
 		nodes:         make(map[int64]graph.Node),
 		edges:         make(map[int64]map[int64]graph.Edge),
+		incoming:      make(map[int64]map[int64]graph.Edge),
 		graphDefaults: make(map[interface{}]interface{}),
 		nodeDefaults:  make(map[interface{}]interface{}),
 		edgeDefaults:  make(map[interface{}]interface{}),
 	}
 }
 
+// IsDirected returns true, as edges have a direction
+func (g *DirectedGraph) IsDirected() bool {
+	return true
+}
+
 func (g *DirectedGraph) HasNode(nid int64) bool {
 	_, ok := g.nodes[nid]
 	return ok
@@ -71,6 +80,15 @@ func (g *DirectedGraph) Edges(nid int64) []graph.Edge {
 	return edges
 }
 
+// IncomingEdges returns the edges that terminate at a node
+func (g *DirectedGraph) IncomingEdges(nid int64) []graph.Edge {
+	edges := make([]graph.Edge, 0, len(g.incoming[nid]))
+	for _, edge := range g.incoming[nid] {
+		edges = append(edges, edge)
+	}
+	return edges
+}
+
 func (g *DirectedGraph) ConnectedNodes(nid int64, distance int64) []graph.Node {
 	nidMap := make(map[int64]bool)
 	g.connectedNodes(nid, distance, &nidMap)
@@ -105,6 +123,7 @@ func (g *DirectedGraph) GraphDefault(key interface{}) interface{} {
 
 func (g *DirectedGraph) SetGraphDefaults(defaults map[interface{}]interface{}) {
 	g.graphDefaults = defaults
+	g.watchers.Notify(graph.DefaultsChanged, 0, 0)
 }
 
 func (g *DirectedGraph) NodeDefaults() *map[interface{}]interface{} {
@@ -117,6 +136,7 @@ func (g *DirectedGraph) NodeDefault(key interface{}) interface{} {
 
 func (g *DirectedGraph) SetNodeDefaults(defaults map[interface{}]interface{}) {
 	g.nodeDefaults = defaults
+	g.watchers.Notify(graph.DefaultsChanged, 0, 0)
 }
 
 func (g *DirectedGraph) EdgeDefaults() *map[interface{}]interface{} {
@@ -129,6 +149,7 @@ func (g *DirectedGraph) EdgeDefault(key interface{}) interface{} {
 
 func (g *DirectedGraph) SetEdgeDefaults(defaults map[interface{}]interface{}) {
 	g.edgeDefaults = defaults
+	g.watchers.Notify(graph.DefaultsChanged, 0, 0)
 }
 
 // NodeManager
@@ -138,25 +159,37 @@ func (g *DirectedGraph) AddNode(node graph.Node) error {
 	}
 	g.nodes[node.Id()] = node
 	g.edges[node.Id()] = make(map[int64]graph.Edge)
+	g.incoming[node.Id()] = make(map[int64]graph.Edge)
+	g.indexes.addNode(node)
+	g.watchers.Notify(graph.NodeAdded, node.Id(), node.Id())
 	return nil
 }
 
 func (g *DirectedGraph) RemoveNode(nid int64) graph.Node {
 	node := g.Node(nid)
 	delete(g.nodes, nid)
+	removed := make([]graph.Edge, 0, len(g.edges[nid])+len(g.incoming[nid]))
 	// Delete edges that start at this node
-	for bid, _ := range g.edges[nid] {
-		delete(g.edges[bid], nid)
+	for bid, edge := range g.edges[nid] {
+		g.indexes.removeEdge(nid, bid)
+		delete(g.incoming[bid], nid)
+		removed = append(removed, edge)
 	}
 	// Delete edges that terminate at this node
-	for aid, _ := range g.edges {
-		for bid, _ := range g.edges[aid] {
-			if bid == nid {
-				delete(g.edges[aid], bid)
-			}
-		}
+	for aid, edge := range g.incoming[nid] {
+		g.indexes.removeEdge(aid, nid)
+		delete(g.edges[aid], nid)
+		removed = append(removed, edge)
 	}
+	g.indexes.removeNode(nid)
 	delete(g.edges, nid)
+	delete(g.incoming, nid)
+	for _, edge := range removed {
+		g.watchers.Notify(graph.EdgeRemoved, edge.From(), edge.To())
+	}
+	if node != nil {
+		g.watchers.Notify(graph.NodeRemoved, nid, nid)
+	}
 	return node
 }
 
@@ -173,11 +206,98 @@ func (g *DirectedGraph) AddEdge(edge graph.Edge) error {
 		return fmt.Errorf("Edge from %v to %v already exists", edge.From(), edge.To())
 	}
 	g.edges[edge.From()][edge.To()] = edge
+	g.incoming[edge.To()][edge.From()] = edge
+	g.indexes.addEdge(edge)
+	g.watchers.Notify(graph.EdgeAdded, edge.From(), edge.To())
 	return nil
 }
 
 func (g *DirectedGraph) RemoveEdge(aid, bid int64) graph.Edge {
 	edge := g.Edge(aid, bid)
+	if edge != nil {
+		g.indexes.removeEdge(edge.From(), edge.To())
+	}
 	delete(g.edges[aid], bid)
+	delete(g.incoming[bid], aid)
+	if edge != nil {
+		g.watchers.Notify(graph.EdgeRemoved, aid, bid)
+	}
 	return edge
 }
+
+// Watch adds an observer of changes to the graph's nodes, edges and
+// defaults, returning a function that removes it
+func (g *DirectedGraph) Watch(observer graph.GraphObserver) func() {
+	return g.watchers.Watch(observer)
+}
+
+// IndexNodes indexes nodes by the value of an attribute.  The index is kept
+// up to date as nodes are added and removed, and as their attributes are
+// changed through SetAttribute and SetAttributes for nodes that are
+// graph.Observable
+func (g *DirectedGraph) IndexNodes(key interface{}) {
+	nodes := make([]graph.Node, 0, len(g.nodes))
+	for _, node := range g.nodes {
+		nodes = append(nodes, node)
+	}
+	g.indexes.indexNodes(key, nodes)
+}
+
+// DropNodeIndex removes the index of nodes by an attribute
+func (g *DirectedGraph) DropNodeIndex(key interface{}) {
+	g.indexes.dropNodeIndex(key)
+}
+
+// IndexEdges indexes edges by the value of an attribute, kept up to date
+// in the same way as node indexes
+func (g *DirectedGraph) IndexEdges(key interface{}) {
+	edges := make([]graph.Edge, 0)
+	for _, bids := range g.edges {
+		for _, edge := range bids {
+			edges = append(edges, edge)
+		}
+	}
+	g.indexes.indexEdges(key, edges)
+}
+
+// DropEdgeIndex removes the index of edges by an attribute
+func (g *DirectedGraph) DropEdgeIndex(key interface{}) {
+	g.indexes.dropEdgeIndex(key)
+}
+
+// NodesWhere returns the nodes with an indexed attribute equal to a value
+// of the same type, in ID order
+func (g *DirectedGraph) NodesWhere(key, value interface{}) ([]graph.Node, error) {
+	return lookupNodes(g, &g.indexes, key, func(i *index) ([]ref, error) { return i.equal(value), nil })
+}
+
+// NodesBetween returns the nodes with an indexed attribute from min to max
+// inclusive, in ID order.  Numbers are compared with numbers and strings
+// with strings; a nil bound is open
+func (g *DirectedGraph) NodesBetween(key, min, max interface{}) ([]graph.Node, error) {
+	return lookupNodes(g, &g.indexes, key, func(i *index) ([]ref, error) { return i.between(min, max) })
+}
+
+// NodesWithPrefix returns the nodes with an indexed string attribute
+// starting with a prefix, in ID order
+func (g *DirectedGraph) NodesWithPrefix(key interface{}, prefix string) ([]graph.Node, error) {
+	return lookupNodes(g, &g.indexes, key, func(i *index) ([]ref, error) { return i.prefix(prefix), nil })
+}
+
+// EdgesWhere returns the edges with an indexed attribute equal to a value
+// of the same type, ordered by their ends
+func (g *DirectedGraph) EdgesWhere(key, value interface{}) ([]graph.Edge, error) {
+	return lookupEdges(g, &g.indexes, key, func(i *index) ([]ref, error) { return i.equal(value), nil })
+}
+
+// EdgesBetween returns the edges with an indexed attribute from min to max
+// inclusive, ordered by their ends
+func (g *DirectedGraph) EdgesBetween(key, min, max interface{}) ([]graph.Edge, error) {
+	return lookupEdges(g, &g.indexes, key, func(i *index) ([]ref, error) { return i.between(min, max) })
+}
+
+// EdgesWithPrefix returns the edges with an indexed string attribute
+// starting with a prefix, ordered by their ends
+func (g *DirectedGraph) EdgesWithPrefix(key interface{}, prefix string) ([]graph.Edge, error) {
+	return lookupEdges(g, &g.indexes, key, func(i *index) ([]ref, error) { return i.prefix(prefix), nil })
+}
@@ -17,6 +17,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/wealdtech/go-graph"
 	"github.com/wealdtech/go-graph/edges"
 	"github.com/wealdtech/go-graph/nodes"
 )
@@ -138,3 +139,56 @@ func TestDirectedGraphDistance(t *testing.T) {
 	// Ensure that connected nodes to 1 is 3 with 2 length
 	assert.Len(t, g.ConnectedNodes(1, 3), 3)
 }
+
+func TestDirectedGraphIncomingEdges(t *testing.T) {
+	g := NewDirectedGraph()
+	for i := int64(1); i <= 3; i++ {
+		assert.NoError(t, g.AddNode(nodes.NewSimpleNode(i)))
+	}
+	assert.NoError(t, g.AddEdge(edges.NewDirectedEdge(1, 3)))
+	assert.NoError(t, g.AddEdge(edges.NewDirectedEdge(2, 3)))
+	assert.NoError(t, g.AddEdge(edges.NewDirectedEdge(3, 1)))
+	assert.Len(t, g.IncomingEdges(3), 2)
+	assert.Len(t, g.IncomingEdges(1), 1)
+	assert.Len(t, g.IncomingEdges(2), 0)
+
+	g.RemoveEdge(2, 3)
+	assert.Len(t, g.IncomingEdges(3), 1)
+
+	g.RemoveNode(3)
+	assert.Len(t, g.IncomingEdges(1), 0)
+	assert.Len(t, g.IncomingEdges(3), 0)
+	assert.Len(t, g.Edges(1), 0)
+}
+
+func TestDirectedGraphWatch(t *testing.T) {
+	g := NewDirectedGraph()
+	changes := make([][3]int64, 0)
+	stop := g.Watch(func(change graph.Change, from, to int64) {
+		changes = append(changes, [3]int64{int64(change), from, to})
+	})
+	assert.NoError(t, g.AddNode(nodes.NewSimpleNode(1)))
+	assert.NoError(t, g.AddNode(nodes.NewSimpleNode(2)))
+	assert.NoError(t, g.AddEdge(edges.NewDirectedEdge(1, 2)))
+	assert.NoError(t, g.AddEdge(edges.NewDirectedEdge(2, 2)))
+	g.RemoveNode(2)
+	g.SetEdgeDefaults(map[interface{}]interface{}{"weight": 1})
+	stop()
+	g.RemoveNode(1)
+
+	assert.Equal(t, [][3]int64{
+		{int64(graph.NodeAdded), 1, 1},
+		{int64(graph.NodeAdded), 2, 2},
+		{int64(graph.EdgeAdded), 1, 2},
+		{int64(graph.EdgeAdded), 2, 2},
+	}, changes[:4])
+	assert.ElementsMatch(t, [][3]int64{
+		{int64(graph.EdgeRemoved), 1, 2},
+		{int64(graph.EdgeRemoved), 2, 2},
+	}, changes[4:6])
+	assert.Equal(t, [][3]int64{
+		{int64(graph.NodeRemoved), 2, 2},
+		{int64(graph.DefaultsChanged), 0, 0},
+	}, changes[6:])
+	assert.Len(t, g.Edges(1), 0)
+}
@@ -0,0 +1,484 @@
+package graphs
+
+// Copyright © 2018 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/wealdtech/go-graph"
+)
+
+// ref identifies an indexed item: a node by its ID at both ends, or an
+// edge by its ends
+type ref struct {
+	from int64
+	to   int64
+}
+
+// index maps the values of an attribute to the items holding them.  Nil
+// values, values that cannot be map keys and values that are not equal to
+// themselves, such as NaN, are not indexed
+type index struct {
+	values  map[ref]interface{}
+	entries map[interface{}]map[ref]bool
+	// numbers and strings hold the distinct values of each kind, sorted,
+	// for range and prefix lookups
+	numbers []interface{}
+	strings []interface{}
+}
+
+func newIndex() *index {
+	return &index{
+		values:  make(map[ref]interface{}),
+		entries: make(map[interface{}]map[ref]bool),
+	}
+}
+
+func (i *index) add(item ref, value interface{}) {
+	if i.load(item, value) {
+		if sorted := i.sorted(kind(value)); sorted != nil {
+			pos := sort.Search(len(*sorted), func(j int) bool { return compare((*sorted)[j], value) > 0 })
+			*sorted = append(*sorted, nil)
+			copy((*sorted)[pos+1:], (*sorted)[pos:])
+			(*sorted)[pos] = value
+		}
+	}
+}
+
+// load adds an item without keeping the sorted values up to date,
+// returning true if its value is new to the index.  sortValues must be
+// called once loading is complete
+func (i *index) load(item ref, value interface{}) bool {
+	if !indexable(value) {
+		return false
+	}
+	i.values[item] = value
+	created := i.entries[value] == nil
+	if created {
+		i.entries[value] = make(map[ref]bool)
+	}
+	i.entries[value][item] = true
+	return created
+}
+
+// sortValues rebuilds the sorted values of each kind
+func (i *index) sortValues() {
+	i.numbers = make([]interface{}, 0)
+	i.strings = make([]interface{}, 0)
+	for value := range i.entries {
+		if sorted := i.sorted(kind(value)); sorted != nil {
+			*sorted = append(*sorted, value)
+		}
+	}
+	for _, sorted := range [][]interface{}{i.numbers, i.strings} {
+		sort.Slice(sorted, func(a, b int) bool { return compare(sorted[a], sorted[b]) < 0 })
+	}
+}
+
+func (i *index) remove(item ref) {
+	value, exists := i.values[item]
+	if !exists {
+		return
+	}
+	delete(i.values, item)
+	delete(i.entries[value], item)
+	if len(i.entries[value]) == 0 {
+		delete(i.entries, value)
+		if sorted := i.sorted(kind(value)); sorted != nil {
+			// Values of different types can compare as equal, so look
+			// for this one among those that do
+			for pos := sort.Search(len(*sorted), func(j int) bool { return compare((*sorted)[j], value) >= 0 }); pos < len(*sorted); pos++ {
+				if (*sorted)[pos] == value {
+					*sorted = append((*sorted)[:pos], (*sorted)[pos+1:]...)
+					break
+				}
+			}
+		}
+	}
+}
+
+// sorted returns the sorted values of a kind, or nil if values of the
+// kind cannot be ordered
+func (i *index) sorted(k string) *[]interface{} {
+	switch k {
+	case "number":
+		return &i.numbers
+	case "string":
+		return &i.strings
+	default:
+		return nil
+	}
+}
+
+// indexable returns true if a value can be found again as a map key
+func indexable(value interface{}) bool {
+	return value != nil && reflect.TypeOf(value).Comparable() && value == value
+}
+
+// equal returns the items with a value.  Values only match values of the
+// same type
+func (i *index) equal(value interface{}) []ref {
+	if !indexable(value) {
+		return []ref{}
+	}
+	return sortedRefs(i.entries[value])
+}
+
+// between returns the items with values from min to max inclusive.
+// Numbers are compared with numbers and strings with strings; a nil bound
+// is open
+func (i *index) between(min, max interface{}) ([]ref, error) {
+	bound := ""
+	for _, value := range []interface{}{min, max} {
+		if value == nil {
+			continue
+		}
+		k := kind(value)
+		if k == "" {
+			return nil, fmt.Errorf("Range bound %v is neither a number nor a string", value)
+		}
+		if bound != "" && k != bound {
+			return nil, fmt.Errorf("Range bounds %v and %v cannot be compared", min, max)
+		}
+		bound = k
+	}
+	items := make(map[ref]bool)
+	for _, k := range []string{"number", "string"} {
+		if bound != "" && k != bound {
+			continue
+		}
+		sorted := *i.sorted(k)
+		start := 0
+		if min != nil {
+			start = sort.Search(len(sorted), func(j int) bool { return compare(sorted[j], min) >= 0 })
+		}
+		end := len(sorted)
+		if max != nil {
+			end = sort.Search(len(sorted), func(j int) bool { return compare(sorted[j], max) > 0 })
+		}
+		if end < start {
+			continue
+		}
+		for _, value := range sorted[start:end] {
+			for item := range i.entries[value] {
+				items[item] = true
+			}
+		}
+	}
+	return sortedRefs(items), nil
+}
+
+// prefix returns the items with string values starting with a prefix
+func (i *index) prefix(prefix string) []ref {
+	items := make(map[ref]bool)
+	start := sort.Search(len(i.strings), func(j int) bool { return compare(i.strings[j], prefix) >= 0 })
+	for _, value := range i.strings[start:] {
+		if !strings.HasPrefix(reflect.ValueOf(value).String(), prefix) {
+			break
+		}
+		for item := range i.entries[value] {
+			items[item] = true
+		}
+	}
+	return sortedRefs(items)
+}
+
+// kind returns "number" or "string" for values that can be ordered, or
+// an empty string
+func kind(value interface{}) string {
+	switch reflect.ValueOf(value).Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.String:
+		return "string"
+	default:
+		return ""
+	}
+}
+
+// compare orders two numbers or two strings.  Integers are compared
+// exactly; other numbers as float64
+func compare(a, b interface{}) int {
+	va := reflect.ValueOf(a)
+	vb := reflect.ValueOf(b)
+	if va.Kind() == reflect.String {
+		return strings.Compare(va.String(), vb.String())
+	}
+	switch {
+	case isInt(va) && isInt(vb):
+		return order(va.Int() < vb.Int(), va.Int() > vb.Int())
+	case isUint(va) && isUint(vb):
+		return order(va.Uint() < vb.Uint(), va.Uint() > vb.Uint())
+	}
+	fa := float(va)
+	fb := float(vb)
+	return order(fa < fb, fa > fb)
+}
+
+func isInt(v reflect.Value) bool {
+	return v.Kind() >= reflect.Int && v.Kind() <= reflect.Int64
+}
+
+func isUint(v reflect.Value) bool {
+	return v.Kind() >= reflect.Uint && v.Kind() <= reflect.Uintptr
+}
+
+func float(v reflect.Value) float64 {
+	switch {
+	case isInt(v):
+		return float64(v.Int())
+	case isUint(v):
+		return float64(v.Uint())
+	default:
+		return v.Float()
+	}
+}
+
+func order(less, greater bool) int {
+	switch {
+	case less:
+		return -1
+	case greater:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func sortedRefs(items map[ref]bool) []ref {
+	res := make([]ref, 0, len(items))
+	for item := range items {
+		res = append(res, item)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].from != res[j].from {
+			return res[i].from < res[j].from
+		}
+		return res[i].to < res[j].to
+	})
+	return res
+}
+
+// indexes holds the attribute indexes of a graph.  While any index
+// exists the graph's nodes or edges are watched for attribute changes.
+// The zero value has no indexes
+type indexes struct {
+	nodes       map[interface{}]*index
+	edges       map[interface{}]*index
+	nodeWatches map[int64]func()
+	edgeWatches map[ref]func()
+}
+
+// addNode indexes a node added to the graph
+func (x *indexes) addNode(node graph.Node) {
+	if len(x.nodes) == 0 {
+		return
+	}
+	item := ref{node.Id(), node.Id()}
+	for key, index := range x.nodes {
+		index.add(item, node.Attribute(key))
+	}
+	x.watchNode(node)
+}
+
+// removeNode unindexes a node removed from the graph
+func (x *indexes) removeNode(nid int64) {
+	item := ref{nid, nid}
+	for _, index := range x.nodes {
+		index.remove(item)
+	}
+	if unwatch, exists := x.nodeWatches[nid]; exists {
+		unwatch()
+		delete(x.nodeWatches, nid)
+	}
+}
+
+func (x *indexes) watchNode(node graph.Node) {
+	observable, ok := node.(graph.Observable)
+	if !ok {
+		return
+	}
+	if _, exists := x.nodeWatches[node.Id()]; exists {
+		return
+	}
+	if x.nodeWatches == nil {
+		x.nodeWatches = make(map[int64]func())
+	}
+	item := ref{node.Id(), node.Id()}
+	x.nodeWatches[node.Id()] = observable.Observe(func(key, old, new interface{}) {
+		if index, exists := x.nodes[key]; exists {
+			index.remove(item)
+			index.add(item, new)
+		}
+	})
+}
+
+// addEdge indexes an edge added to the graph
+func (x *indexes) addEdge(edge graph.Edge) {
+	if len(x.edges) == 0 {
+		return
+	}
+	item := ref{edge.From(), edge.To()}
+	for key, index := range x.edges {
+		index.add(item, edge.Attribute(key))
+	}
+	x.watchEdge(edge)
+}
+
+// removeEdge unindexes an edge removed from the graph
+func (x *indexes) removeEdge(from, to int64) {
+	item := ref{from, to}
+	for _, index := range x.edges {
+		index.remove(item)
+	}
+	if unwatch, exists := x.edgeWatches[item]; exists {
+		unwatch()
+		delete(x.edgeWatches, item)
+	}
+}
+
+func (x *indexes) watchEdge(edge graph.Edge) {
+	observable, ok := edge.(graph.Observable)
+	if !ok {
+		return
+	}
+	item := ref{edge.From(), edge.To()}
+	if _, exists := x.edgeWatches[item]; exists {
+		return
+	}
+	if x.edgeWatches == nil {
+		x.edgeWatches = make(map[ref]func())
+	}
+	x.edgeWatches[item] = observable.Observe(func(key, old, new interface{}) {
+		if index, exists := x.edges[key]; exists {
+			index.remove(item)
+			index.add(item, new)
+		}
+	})
+}
+
+// indexNodes starts indexing the given nodes by an attribute
+func (x *indexes) indexNodes(key interface{}, nodes []graph.Node) {
+	if _, exists := x.nodes[key]; exists {
+		return
+	}
+	if x.nodes == nil {
+		x.nodes = make(map[interface{}]*index)
+	}
+	index := newIndex()
+	x.nodes[key] = index
+	for _, node := range nodes {
+		index.load(ref{node.Id(), node.Id()}, node.Attribute(key))
+		x.watchNode(node)
+	}
+	index.sortValues()
+}
+
+// dropNodeIndex stops indexing nodes by an attribute, and stops watching
+// them if no index is left
+func (x *indexes) dropNodeIndex(key interface{}) {
+	delete(x.nodes, key)
+	if len(x.nodes) == 0 {
+		for _, unwatch := range x.nodeWatches {
+			unwatch()
+		}
+		x.nodeWatches = nil
+	}
+}
+
+// indexEdges starts indexing the given edges by an attribute
+func (x *indexes) indexEdges(key interface{}, edges []graph.Edge) {
+	if _, exists := x.edges[key]; exists {
+		return
+	}
+	if x.edges == nil {
+		x.edges = make(map[interface{}]*index)
+	}
+	index := newIndex()
+	x.edges[key] = index
+	for _, edge := range edges {
+		index.load(ref{edge.From(), edge.To()}, edge.Attribute(key))
+		x.watchEdge(edge)
+	}
+	index.sortValues()
+}
+
+// dropEdgeIndex stops indexing edges by an attribute, and stops watching
+// them if no index is left
+func (x *indexes) dropEdgeIndex(key interface{}) {
+	delete(x.edges, key)
+	if len(x.edges) == 0 {
+		for _, unwatch := range x.edgeWatches {
+			unwatch()
+		}
+		x.edgeWatches = nil
+	}
+}
+
+func (x *indexes) nodeIndex(key interface{}) (*index, error) {
+	index, exists := x.nodes[key]
+	if !exists {
+		return nil, fmt.Errorf("No index on node attribute %v", key)
+	}
+	return index, nil
+}
+
+func (x *indexes) edgeIndex(key interface{}) (*index, error) {
+	index, exists := x.edges[key]
+	if !exists {
+		return nil, fmt.Errorf("No index on edge attribute %v", key)
+	}
+	return index, nil
+}
+
+// lookupNodes runs a lookup against a node index and returns the nodes it
+// finds
+func lookupNodes(g graph.Graph, x *indexes, key interface{}, lookup func(*index) ([]ref, error)) ([]graph.Node, error) {
+	index, err := x.nodeIndex(key)
+	if err != nil {
+		return nil, err
+	}
+	items, err := lookup(index)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]graph.Node, len(items))
+	for i, item := range items {
+		res[i] = g.Node(item.from)
+	}
+	return res, nil
+}
+
+// lookupEdges runs a lookup against an edge index and returns the edges
+// it finds
+func lookupEdges(g graph.Graph, x *indexes, key interface{}, lookup func(*index) ([]ref, error)) ([]graph.Edge, error) {
+	index, err := x.edgeIndex(key)
+	if err != nil {
+		return nil, err
+	}
+	items, err := lookup(index)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]graph.Edge, len(items))
+	for i, item := range items {
+		res[i] = g.Edge(item.from, item.to)
+	}
+	return res, nil
+}
@@ -0,0 +1,194 @@
+package graphs
+
+// Copyright © 2018 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wealdtech/go-graph"
+	"github.com/wealdtech/go-graph/edges"
+	"github.com/wealdtech/go-graph/nodes"
+)
+
+func nodeIDs(nodes []graph.Node, err error) []int64 {
+	if err != nil {
+		return nil
+	}
+	res := make([]int64, len(nodes))
+	for i, node := range nodes {
+		res[i] = node.Id()
+	}
+	return res
+}
+
+func edgeEnds(edges []graph.Edge, err error) [][2]int64 {
+	if err != nil {
+		return nil
+	}
+	res := make([][2]int64, len(edges))
+	for i, edge := range edges {
+		res[i] = [2]int64{edge.From(), edge.To()}
+	}
+	return res
+}
+
+func TestDirectedGraphNodeIndex(t *testing.T) {
+	g := NewDirectedGraph()
+	teams := []string{"payments", "search", "payments-eu", "search"}
+	for i, team := range teams {
+		node := nodes.NewSimpleNode(int64(i + 1))
+		node.SetAttribute("team", team)
+		node.SetAttribute("size", i*10)
+		assert.NoError(t, g.AddNode(node))
+	}
+
+	_, err := g.NodesWhere("team", "payments")
+	assert.EqualError(t, err, "No index on node attribute team")
+
+	g.IndexNodes("team")
+	g.IndexNodes("size")
+	assert.Equal(t, []int64{2, 4}, nodeIDs(g.NodesWhere("team", "search")))
+	assert.Equal(t, []int64{1, 3}, nodeIDs(g.NodesWithPrefix("team", "payments")))
+	assert.Equal(t, []int64{2, 3}, nodeIDs(g.NodesBetween("size", 5, 20)))
+	assert.Equal(t, []int64{3, 4}, nodeIDs(g.NodesBetween("size", 15.5, nil)))
+	assert.Equal(t, []int64{1, 3}, nodeIDs(g.NodesBetween("team", "p", "q")))
+	assert.Equal(t, []int64{}, nodeIDs(g.NodesWhere("size", int64(10))))
+
+	_, err = g.NodesBetween("size", 1, "z")
+	assert.EqualError(t, err, "Range bounds 1 and z cannot be compared")
+	_, err = g.NodesBetween("size", true, nil)
+	assert.EqualError(t, err, "Range bound true is neither a number nor a string")
+
+	// Attribute changes and mutations are tracked
+	g.Node(2).SetAttribute("team", "payments")
+	g.Node(4).SetAttributes(map[interface{}]interface{}{"size": 5})
+	node := nodes.NewSimpleNode(5)
+	node.SetAttribute("team", "search")
+	assert.NoError(t, g.AddNode(node))
+	g.RemoveNode(1)
+	assert.Equal(t, []int64{2}, nodeIDs(g.NodesWhere("team", "payments")))
+	assert.Equal(t, []int64{5}, nodeIDs(g.NodesWhere("team", "search")))
+	assert.Equal(t, []int64{4}, nodeIDs(g.NodesBetween("size", nil, 5)))
+
+	// Removed nodes are no longer watched
+	node1 := nodes.NewSimpleNode(1)
+	node1.SetAttribute("team", "search")
+	assert.Equal(t, []int64{5}, nodeIDs(g.NodesWhere("team", "search")))
+
+	g.DropNodeIndex("team")
+	_, err = g.NodesWhere("team", "search")
+	assert.EqualError(t, err, "No index on node attribute team")
+	g.Node(3).SetAttribute("size", 100)
+	assert.Equal(t, []int64{3}, nodeIDs(g.NodesBetween("size", 50, nil)))
+}
+
+func TestDirectedGraphEdgeIndex(t *testing.T) {
+	g := NewDirectedGraph()
+	for i := int64(1); i <= 3; i++ {
+		assert.NoError(t, g.AddNode(nodes.NewSimpleNode(i)))
+	}
+	for _, link := range [][2]int64{{1, 2}, {2, 1}, {2, 3}, {3, 1}} {
+		edge := edges.NewDirectedEdge(link[0], link[1])
+		edge.SetAttribute("type", "calls")
+		assert.NoError(t, g.AddEdge(edge))
+	}
+	g.IndexEdges("type")
+	assert.Equal(t, [][2]int64{{1, 2}, {2, 1}, {2, 3}, {3, 1}}, edgeEnds(g.EdgesWhere("type", "calls")))
+
+	g.Edge(2, 3).SetAttribute("type", "owns")
+	g.RemoveEdge(3, 1)
+	assert.Equal(t, [][2]int64{{1, 2}, {2, 1}}, edgeEnds(g.EdgesWhere("type", "calls")))
+	assert.Equal(t, [][2]int64{{2, 3}}, edgeEnds(g.EdgesWithPrefix("type", "o")))
+
+	// Removing a node removes its edges both ways
+	g.RemoveNode(1)
+	assert.Equal(t, [][2]int64{}, edgeEnds(g.EdgesWhere("type", "calls")))
+	_, err := g.EdgesWhere("weight", 1)
+	assert.EqualError(t, err, "No index on edge attribute weight")
+}
+
+func TestUndirectedGraphIndex(t *testing.T) {
+	g := NewUndirectedGraph()
+	for i := int64(1); i <= 4; i++ {
+		node := nodes.NewSimpleNode(i)
+		node.SetAttribute("rack", i%2)
+		assert.NoError(t, g.AddNode(node))
+	}
+	for _, link := range [][2]int64{{2, 1}, {3, 2}, {4, 3}, {4, 1}} {
+		edge := edges.NewUndirectedEdge(link[0], link[1])
+		edge.SetAttribute("latency", float64(link[0]+link[1]))
+		assert.NoError(t, g.AddEdge(edge))
+	}
+	g.IndexNodes("rack")
+	g.IndexEdges("latency")
+	assert.Equal(t, []int64{1, 3}, nodeIDs(g.NodesWhere("rack", int64(1))))
+	assert.Equal(t, [][2]int64{{1, 2}, {1, 4}, {2, 3}}, edgeEnds(g.EdgesBetween("latency", 0, 5)))
+
+	g.Edge(4, 1).SetAttribute("latency", 9.5)
+	g.RemoveNode(2)
+	assert.Equal(t, [][2]int64{{1, 4}, {3, 4}}, edgeEnds(g.EdgesBetween("latency", 5, nil)))
+	assert.Equal(t, [][2]int64{}, edgeEnds(g.EdgesBetween("latency", nil, 5)))
+	assert.Equal(t, []int64{4}, nodeIDs(g.NodesWhere("rack", int64(0))))
+}
+
+func TestIndexNaN(t *testing.T) {
+	g := NewDirectedGraph()
+	g.IndexNodes("w")
+	g.IndexEdges("w")
+
+	// NaN never equals itself, so is left out of indexes
+	node1 := nodes.NewSimpleNode(1)
+	node1.SetAttribute("w", math.NaN())
+	assert.NoError(t, g.AddNode(node1))
+	node2 := nodes.NewSimpleNode(2)
+	node2.SetAttribute("w", 1.5)
+	assert.NoError(t, g.AddNode(node2))
+	edge := edges.NewDirectedEdge(1, 2)
+	edge.SetAttribute("w", math.NaN())
+	assert.NoError(t, g.AddEdge(edge))
+	g.IndexNodes("v")
+	node2.SetAttribute("w", math.NaN())
+	node1.SetAttribute("w", 2.5)
+
+	assert.Equal(t, []int64{1}, nodeIDs(g.NodesBetween("w", nil, nil)))
+	assert.Equal(t, []int64{}, nodeIDs(g.NodesWhere("w", math.NaN())))
+	assert.Equal(t, [][2]int64{}, edgeEnds(g.EdgesBetween("w", nil, nil)))
+}
+
+func TestIndexOrdering(t *testing.T) {
+	g := NewDirectedGraph()
+	for i, size := range []interface{}{1, 1.0, int64(2), uint(3), "2"} {
+		node := nodes.NewSimpleNode(int64(i + 1))
+		node.SetAttribute("size", size)
+		assert.NoError(t, g.AddNode(node))
+	}
+	g.IndexNodes("size")
+	assert.Equal(t, []int64{1, 2, 3}, nodeIDs(g.NodesBetween("size", 0.5, 2)))
+	assert.Equal(t, []int64{3, 4}, nodeIDs(g.NodesBetween("size", 1.5, nil)))
+	assert.Equal(t, []int64{}, nodeIDs(g.NodesBetween("size", 3, 1)))
+	assert.Equal(t, []int64{5}, nodeIDs(g.NodesBetween("size", "1", "3")))
+	assert.Equal(t, []int64{1, 2, 3, 4, 5}, nodeIDs(g.NodesBetween("size", nil, nil)))
+
+	// Values that compare as equal are kept apart as they change
+	g.Node(2).SetAttribute("size", 4.5)
+	g.Node(4).SetAttribute("size", "20")
+	assert.Equal(t, []int64{1}, nodeIDs(g.NodesBetween("size", nil, 1)))
+	assert.Equal(t, []int64{2}, nodeIDs(g.NodesBetween("size", 3, nil)))
+	assert.Equal(t, []int64{4, 5}, nodeIDs(g.NodesWithPrefix("size", "2")))
+	g.RemoveNode(1)
+	assert.Equal(t, []int64{}, nodeIDs(g.NodesBetween("size", nil, 1)))
+	assert.Equal(t, []int64{2, 3}, nodeIDs(g.NodesBetween("size", 2, nil)))
+}
@@ -0,0 +1,309 @@
+package graphs
+
+// Copyright © 2018 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/wealdtech/go-graph"
+)
+
+// RootedTree is a directed graph in which every node has at most one
+// parent and there are no cycles.  Edges run from parent to child.  While
+// being built it may be a forest; Root() requires a single root
+type RootedTree struct {
+	*DirectedGraph
+	parents map[int64]int64
+	// Binary lifting tables, built on demand and discarded on change
+	lifting *lifting
+}
+
+type lifting struct {
+	index map[int64]int
+	ids   []int64
+	depth []int
+	up    [][]int
+}
+
+func NewRootedTree() *RootedTree {
+	return &RootedTree{
+		DirectedGraph: NewDirectedGraph(),
+		parents:       make(map[int64]int64),
+	}
+}
+
+// FromDirectedGraph creates a rooted tree from a directed graph, checking
+// that the graph has a single root, that every other node has exactly one
+// parent and that there are no cycles.  The tree shares the graph's nodes
+// and edges
+func FromDirectedGraph(g *DirectedGraph) (*RootedTree, error) {
+	t := NewRootedTree()
+	for _, node := range g.nodes {
+		t.AddNode(node)
+	}
+	t.SetGraphDefaults(copyDefaults(g.graphDefaults))
+	t.SetNodeDefaults(copyDefaults(g.nodeDefaults))
+	t.SetEdgeDefaults(copyDefaults(g.edgeDefaults))
+	for aid := range g.edges {
+		for _, edge := range g.edges[aid] {
+			if err := t.AddEdge(edge); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if _, err := t.Root(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// NodeManager
+func (t *RootedTree) AddNode(node graph.Node) error {
+	t.lifting = nil
+	return t.DirectedGraph.AddNode(node)
+}
+
+func (t *RootedTree) RemoveNode(nid int64) graph.Node {
+	t.lifting = nil
+	delete(t.parents, nid)
+	for bid := range t.edges[nid] {
+		delete(t.parents, bid)
+	}
+	return t.DirectedGraph.RemoveNode(nid)
+}
+
+// EdgeManager
+// AddEdge adds an edge from a parent to a child.  It fails if the child
+// already has a parent or if the edge would create a cycle
+func (t *RootedTree) AddEdge(edge graph.Edge) error {
+	if parent, exists := t.parents[edge.To()]; exists {
+		return fmt.Errorf("Node %v already has parent %v", edge.To(), parent)
+	}
+	for nid, ok := edge.From(), true; ok; nid, ok = t.parents[nid] {
+		if nid == edge.To() {
+			return fmt.Errorf("Edge from %v to %v would create a cycle", edge.From(), edge.To())
+		}
+	}
+	if err := t.DirectedGraph.AddEdge(edge); err != nil {
+		return err
+	}
+	t.lifting = nil
+	t.parents[edge.To()] = edge.From()
+	return nil
+}
+
+func (t *RootedTree) RemoveEdge(aid, bid int64) graph.Edge {
+	if parent, exists := t.parents[bid]; exists && parent == aid {
+		delete(t.parents, bid)
+		t.lifting = nil
+	}
+	return t.DirectedGraph.RemoveEdge(aid, bid)
+}
+
+// Roots returns the nodes without parents, in ascending order
+func (t *RootedTree) Roots() []int64 {
+	roots := make([]int64, 0)
+	for nid := range t.nodes {
+		if _, exists := t.parents[nid]; !exists {
+			roots = append(roots, nid)
+		}
+	}
+	sort.Slice(roots, func(i, j int) bool { return roots[i] < roots[j] })
+	return roots
+}
+
+// Root returns the root of the tree, failing if there is not exactly one
+func (t *RootedTree) Root() (int64, error) {
+	roots := t.Roots()
+	if len(roots) != 1 {
+		return 0, fmt.Errorf("Tree has %d roots", len(roots))
+	}
+	return roots[0], nil
+}
+
+// Parent returns the parent of a node.  Roots have no parent
+func (t *RootedTree) Parent(nid int64) (int64, bool) {
+	parent, exists := t.parents[nid]
+	return parent, exists
+}
+
+// Children returns the children of a node, in ascending order
+func (t *RootedTree) Children(nid int64) []int64 {
+	children := make([]int64, 0, len(t.edges[nid]))
+	for bid := range t.edges[nid] {
+		children = append(children, bid)
+	}
+	sort.Slice(children, func(i, j int) bool { return children[i] < children[j] })
+	return children
+}
+
+// Depth returns the number of edges between a node and its root, or -1 if
+// the node is not in the tree
+func (t *RootedTree) Depth(nid int64) int {
+	l := t.lifts()
+	v, exists := l.index[nid]
+	if !exists {
+		return -1
+	}
+	return l.depth[v]
+}
+
+// SubtreeSize returns the number of nodes in the subtree rooted at a node,
+// including the node itself
+func (t *RootedTree) SubtreeSize(nid int64) int {
+	if !t.HasNode(nid) {
+		return 0
+	}
+	return len(t.PreOrder(nid))
+}
+
+// LCA returns the lowest common ancestor of two nodes: the deepest node
+// that is an ancestor of both, where each node is an ancestor of itself.
+// It uses binary lifting, so after the first query each takes O(log n)
+// time until the tree changes
+func (t *RootedTree) LCA(a, b int64) (int64, error) {
+	l := t.lifts()
+	x, aok := l.index[a]
+	if !aok {
+		return 0, fmt.Errorf("Unknown node %v", a)
+	}
+	y, bok := l.index[b]
+	if !bok {
+		return 0, fmt.Errorf("Unknown node %v", b)
+	}
+	if l.depth[x] < l.depth[y] {
+		x, y = y, x
+	}
+	for k := len(l.up) - 1; k >= 0; k-- {
+		if l.depth[x]-(1<<uint(k)) >= l.depth[y] {
+			x = l.up[k][x]
+		}
+	}
+	if x == y {
+		return l.ids[x], nil
+	}
+	for k := len(l.up) - 1; k >= 0; k-- {
+		if l.up[k][x] != l.up[k][y] {
+			x = l.up[k][x]
+			y = l.up[k][y]
+		}
+	}
+	if l.up[0][x] == x {
+		return 0, fmt.Errorf("Nodes %v and %v are in different trees", a, b)
+	}
+	return l.ids[l.up[0][x]], nil
+}
+
+// lifts returns the binary lifting tables, building them if required
+func (t *RootedTree) lifts() *lifting {
+	if t.lifting != nil {
+		return t.lifting
+	}
+	l := &lifting{
+		index: make(map[int64]int, len(t.nodes)),
+	}
+	for _, root := range t.Roots() {
+		for _, nid := range t.LevelOrder(root) {
+			l.index[nid] = len(l.ids)
+			l.ids = append(l.ids, nid)
+		}
+	}
+	n := len(l.ids)
+	l.depth = make([]int, n)
+	parent := make([]int, n)
+	maxDepth := 0
+	for v, nid := range l.ids {
+		parent[v] = v
+		if p, exists := t.parents[nid]; exists {
+			parent[v] = l.index[p]
+			// Level order means the parent's depth is already known
+			l.depth[v] = l.depth[parent[v]] + 1
+			if l.depth[v] > maxDepth {
+				maxDepth = l.depth[v]
+			}
+		}
+	}
+	l.up = [][]int{parent}
+	for span := 2; span <= maxDepth; span *= 2 {
+		prev := l.up[len(l.up)-1]
+		next := make([]int, n)
+		for v := range next {
+			next[v] = prev[prev[v]]
+		}
+		l.up = append(l.up, next)
+	}
+	t.lifting = l
+	return l
+}
+
+// PreOrder returns the nodes of the subtree rooted at a node, each before
+// its children
+func (t *RootedTree) PreOrder(nid int64) []int64 {
+	res := make([]int64, 0)
+	if !t.HasNode(nid) {
+		return res
+	}
+	stack := []int64{nid}
+	for len(stack) > 0 {
+		v := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		res = append(res, v)
+		children := t.Children(v)
+		for i := len(children) - 1; i >= 0; i-- {
+			stack = append(stack, children[i])
+		}
+	}
+	return res
+}
+
+// PostOrder returns the nodes of the subtree rooted at a node, each after
+// its children
+func (t *RootedTree) PostOrder(nid int64) []int64 {
+	res := make([]int64, 0)
+	if !t.HasNode(nid) {
+		return res
+	}
+	type frame struct {
+		nid      int64
+		children []int64
+	}
+	stack := []frame{{nid: nid, children: t.Children(nid)}}
+	for len(stack) > 0 {
+		f := &stack[len(stack)-1]
+		if len(f.children) == 0 {
+			res = append(res, f.nid)
+			stack = stack[:len(stack)-1]
+			continue
+		}
+		child := f.children[0]
+		f.children = f.children[1:]
+		stack = append(stack, frame{nid: child, children: t.Children(child)})
+	}
+	return res
+}
+
+// LevelOrder returns the nodes of the subtree rooted at a node in order of
+// depth
+func (t *RootedTree) LevelOrder(nid int64) []int64 {
+	res := make([]int64, 0)
+	if !t.HasNode(nid) {
+		return res
+	}
+	res = append(res, nid)
+	for i := 0; i < len(res); i++ {
+		res = append(res, t.Children(res[i])...)
+	}
+	return res
+}
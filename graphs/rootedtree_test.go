@@ -0,0 +1,183 @@
+package graphs
+
+// Copyright © 2018 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wealdtech/go-graph/edges"
+	"github.com/wealdtech/go-graph/nodes"
+)
+
+// sampleTree creates a tree rooted at 1 with children 2, 3 and 4, where 2
+// has children 5 and 6 and 4 has the chain 7 -> 8 beneath it
+func sampleTree(t *testing.T) *RootedTree {
+	tree := NewRootedTree()
+	for i := int64(1); i <= 8; i++ {
+		err := tree.AddNode(nodes.NewSimpleNode(i))
+		assert.NoError(t, err)
+	}
+	for _, pair := range [][2]int64{{1, 2}, {1, 3}, {1, 4}, {2, 5}, {2, 6}, {4, 7}, {7, 8}} {
+		err := tree.AddEdge(edges.NewDirectedEdge(pair[0], pair[1]))
+		assert.NoError(t, err)
+	}
+	return tree
+}
+
+func TestRootedTreeAddEdge(t *testing.T) {
+	tree := sampleTree(t)
+
+	// Second parent
+	err := tree.AddEdge(edges.NewDirectedEdge(3, 5))
+	assert.Error(t, err)
+	// Cycle
+	err = tree.AddEdge(edges.NewDirectedEdge(8, 1))
+	assert.Error(t, err)
+	err = tree.AddEdge(edges.NewDirectedEdge(3, 3))
+	assert.Error(t, err)
+	assert.False(t, tree.HasEdge(3, 5))
+
+	// Reparenting after removing the old edge
+	assert.NotNil(t, tree.RemoveEdge(2, 5))
+	err = tree.AddEdge(edges.NewDirectedEdge(3, 5))
+	assert.NoError(t, err)
+	parent, exists := tree.Parent(5)
+	assert.True(t, exists)
+	assert.Equal(t, int64(3), parent)
+
+	// Removing a node orphans its children
+	tree.RemoveNode(4)
+	_, exists = tree.Parent(7)
+	assert.False(t, exists)
+	assert.Equal(t, []int64{1, 7}, tree.Roots())
+	_, err = tree.Root()
+	assert.Error(t, err)
+}
+
+func TestRootedTreeHelpers(t *testing.T) {
+	tree := sampleTree(t)
+
+	root, err := tree.Root()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), root)
+	_, exists := tree.Parent(1)
+	assert.False(t, exists)
+	assert.Equal(t, []int64{2, 3, 4}, tree.Children(1))
+	assert.Equal(t, []int64{}, tree.Children(5))
+
+	assert.Equal(t, 0, tree.Depth(1))
+	assert.Equal(t, 2, tree.Depth(6))
+	assert.Equal(t, 3, tree.Depth(8))
+	assert.Equal(t, -1, tree.Depth(9))
+
+	assert.Equal(t, 8, tree.SubtreeSize(1))
+	assert.Equal(t, 3, tree.SubtreeSize(2))
+	assert.Equal(t, 1, tree.SubtreeSize(8))
+	assert.Equal(t, 0, tree.SubtreeSize(9))
+}
+
+func TestRootedTreeLCA(t *testing.T) {
+	tree := sampleTree(t)
+
+	tests := []struct {
+		a, b, lca int64
+	}{
+		{5, 6, 2},
+		{5, 8, 1},
+		{8, 7, 7},
+		{4, 8, 4},
+		{3, 3, 3},
+		{1, 6, 1},
+	}
+	for _, test := range tests {
+		lca, err := tree.LCA(test.a, test.b)
+		assert.NoError(t, err)
+		assert.Equal(t, test.lca, lca, "LCA of %d and %d", test.a, test.b)
+	}
+	_, err := tree.LCA(1, 9)
+	assert.Error(t, err)
+
+	// Changes are picked up
+	err = tree.AddNode(nodes.NewSimpleNode(9))
+	assert.NoError(t, err)
+	err = tree.AddEdge(edges.NewDirectedEdge(6, 9))
+	assert.NoError(t, err)
+	lca, err := tree.LCA(9, 5)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), lca)
+
+	// Separate trees in a forest
+	tree.RemoveEdge(1, 4)
+	_, err = tree.LCA(8, 5)
+	assert.Error(t, err)
+}
+
+func TestRootedTreeTraversals(t *testing.T) {
+	tree := sampleTree(t)
+
+	assert.Equal(t, []int64{1, 2, 5, 6, 3, 4, 7, 8}, tree.PreOrder(1))
+	assert.Equal(t, []int64{5, 6, 2, 3, 8, 7, 4, 1}, tree.PostOrder(1))
+	assert.Equal(t, []int64{1, 2, 3, 4, 5, 6, 7, 8}, tree.LevelOrder(1))
+	assert.Equal(t, []int64{4, 7, 8}, tree.PreOrder(4))
+	assert.Equal(t, []int64{}, tree.PostOrder(9))
+}
+
+func TestFromDirectedGraph(t *testing.T) {
+	g := NewDirectedGraph()
+	for i := int64(1); i <= 4; i++ {
+		err := g.AddNode(nodes.NewSimpleNode(i))
+		assert.NoError(t, err)
+	}
+	for _, pair := range [][2]int64{{1, 2}, {1, 3}, {3, 4}} {
+		err := g.AddEdge(edges.NewDirectedEdge(pair[0], pair[1]))
+		assert.NoError(t, err)
+	}
+	g.SetNodeDefaults(map[interface{}]interface{}{"shape": "box"})
+
+	tree, err := FromDirectedGraph(g)
+	assert.NoError(t, err)
+	assert.Len(t, tree.Nodes(), 4)
+	assert.Equal(t, g.Edge(3, 4), tree.Edge(3, 4))
+	assert.Equal(t, "box", (*tree.NodeDefaults())["shape"])
+	lca, err := tree.LCA(2, 4)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), lca)
+
+	// Two parents
+	err = g.AddEdge(edges.NewDirectedEdge(2, 4))
+	assert.NoError(t, err)
+	_, err = FromDirectedGraph(g)
+	assert.Error(t, err)
+	g.RemoveEdge(2, 4)
+
+	// Two roots
+	err = g.AddNode(nodes.NewSimpleNode(5))
+	assert.NoError(t, err)
+	_, err = FromDirectedGraph(g)
+	assert.Error(t, err)
+
+	// A cycle with no root
+	cycle := NewDirectedGraph()
+	for i := int64(1); i <= 2; i++ {
+		err := cycle.AddNode(nodes.NewSimpleNode(i))
+		assert.NoError(t, err)
+	}
+	err = cycle.AddEdge(edges.NewDirectedEdge(1, 2))
+	assert.NoError(t, err)
+	err = cycle.AddEdge(edges.NewDirectedEdge(2, 1))
+	assert.NoError(t, err)
+	_, err = FromDirectedGraph(cycle)
+	assert.Error(t, err)
+}
@@ -0,0 +1,295 @@
+package graphs
+
+// Copyright © 2018 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"fmt"
+	"math/bits"
+	"sort"
+
+	"github.com/wealdtech/go-graph"
+	"github.com/wealdtech/go-graph/edges"
+	"github.com/wealdtech/go-graph/nodes"
+)
+
+// Reachability records which nodes of a directed graph can be reached from
+// which others.  Nodes in the same strongly connected component share a
+// bitset, so this is compact for graphs with large cycles
+type Reachability struct {
+	ids       []int64
+	index     map[int64]int
+	component []int
+	rows      [][]uint64
+}
+
+// Reachable returns true if there is a path of at least one edge from one
+// node to another.  A node can only reach itself if it is part of a cycle
+func (r *Reachability) Reachable(from, to int64) bool {
+	a, aok := r.index[from]
+	b, bok := r.index[to]
+	if !aok || !bok {
+		return false
+	}
+	return r.rows[r.component[a]][b/64]&(1<<uint(b%64)) != 0
+}
+
+// ReachableFrom returns the IDs of the nodes reachable from a node, in
+// ascending order
+func (r *Reachability) ReachableFrom(nid int64) []int64 {
+	res := make([]int64, 0)
+	a, exists := r.index[nid]
+	if !exists {
+		return res
+	}
+	for w, word := range r.rows[r.component[a]] {
+		for word != 0 {
+			bit := bits.TrailingZeros64(word)
+			res = append(res, r.ids[w*64+bit])
+			word &^= 1 << uint(bit)
+		}
+	}
+	return res
+}
+
+// Reachability calculates which nodes can be reached from which others
+func (g *DirectedGraph) Reachability() *Reachability {
+	ids := make([]int64, 0, len(g.nodes))
+	for nid := range g.nodes {
+		ids = append(ids, nid)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	index := make(map[int64]int, len(ids))
+	for i, nid := range ids {
+		index[nid] = i
+	}
+	successors := make([][]int, len(ids))
+	for i, nid := range ids {
+		for bid := range g.edges[nid] {
+			successors[i] = append(successors[i], index[bid])
+		}
+	}
+
+	component, members := stronglyConnectedComponents(successors)
+	words := (len(ids) + 63) / 64
+	rows := make([][]uint64, len(members))
+	// Components are numbered in reverse topological order, so successors
+	// are always complete before they are needed
+	for c := range members {
+		rows[c] = make([]uint64, words)
+		cyclic := len(members[c]) > 1
+		for _, v := range members[c] {
+			for _, w := range successors[v] {
+				d := component[w]
+				if d == c {
+					cyclic = true
+					continue
+				}
+				for k := range rows[c] {
+					rows[c][k] |= rows[d][k]
+				}
+				rows[c][w/64] |= 1 << uint(w%64)
+			}
+		}
+		if cyclic {
+			for _, v := range members[c] {
+				rows[c][v/64] |= 1 << uint(v%64)
+			}
+		}
+	}
+
+	return &Reachability{
+		ids:       ids,
+		index:     index,
+		component: component,
+		rows:      rows,
+	}
+}
+
+// TransitiveClosure returns a new graph with copies of the nodes and an
+// edge from each node to every node reachable from it.  Existing edges are
+// copied with their attributes; added edges are plain directed edges
+func (g *DirectedGraph) TransitiveClosure() *DirectedGraph {
+	reachability := g.Reachability()
+	closure := g.emptyCopy()
+	for _, aid := range reachability.ids {
+		for _, bid := range reachability.ReachableFrom(aid) {
+			edge := g.Edge(aid, bid)
+			if edge == nil {
+				edge = edges.NewDirectedEdge(aid, bid)
+			} else {
+				edge = copyEdge(edge)
+			}
+			// Both nodes are present so this cannot fail
+			closure.AddEdge(edge)
+		}
+	}
+	return closure
+}
+
+// TransitiveReduction returns a new graph with copies of the nodes and
+// edges and the same reachability but the fewest edges, along with the
+// edges of this graph that were dropped.  The graph must be acyclic
+func (g *DirectedGraph) TransitiveReduction() (*DirectedGraph, []graph.Edge, error) {
+	reachability := g.Reachability()
+	for _, nid := range reachability.ids {
+		if reachability.Reachable(nid, nid) {
+			return nil, nil, fmt.Errorf("Node %v is part of a cycle", nid)
+		}
+	}
+
+	reduction := g.emptyCopy()
+	dropped := make([]graph.Edge, 0)
+	words := (len(reachability.ids) + 63) / 64
+	for _, aid := range reachability.ids {
+		// Nodes reachable through at least two edges
+		indirect := make([]uint64, words)
+		for bid := range g.edges[aid] {
+			row := reachability.rows[reachability.component[reachability.index[bid]]]
+			for k := range row {
+				indirect[k] |= row[k]
+			}
+		}
+		for _, edge := range g.Edges(aid) {
+			b := reachability.index[edge.To()]
+			if indirect[b/64]&(1<<uint(b%64)) != 0 {
+				dropped = append(dropped, edge)
+			} else if err := reduction.AddEdge(copyEdge(edge)); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+	sort.Slice(dropped, func(i, j int) bool {
+		if dropped[i].From() != dropped[j].From() {
+			return dropped[i].From() < dropped[j].From()
+		}
+		return dropped[i].To() < dropped[j].To()
+	})
+	return reduction, dropped, nil
+}
+
+// emptyCopy returns a new graph with copies of the nodes and defaults of
+// this graph but no edges
+func (g *DirectedGraph) emptyCopy() *DirectedGraph {
+	res := NewDirectedGraph()
+	for nid, node := range g.nodes {
+		copied := nodes.NewSimpleNode(nid)
+		copied.SetAttributes(copyAttributes(node.Attributes()))
+		// IDs are unique so this cannot fail
+		res.AddNode(copied)
+	}
+	res.SetGraphDefaults(copyDefaults(g.graphDefaults))
+	res.SetNodeDefaults(copyDefaults(g.nodeDefaults))
+	res.SetEdgeDefaults(copyDefaults(g.edgeDefaults))
+	return res
+}
+
+// copyEdge returns a plain directed edge with a copy of the attributes of
+// an edge
+func copyEdge(edge graph.Edge) graph.Edge {
+	res := edges.NewDirectedEdge(edge.From(), edge.To())
+	res.SetAttributes(copyAttributes(edge.Attributes()))
+	return res
+}
+
+// copyAttributes returns a copy of a set of attributes, which may be nil
+func copyAttributes(attrs *map[interface{}]interface{}) map[interface{}]interface{} {
+	if attrs == nil {
+		return make(map[interface{}]interface{})
+	}
+	return copyDefaults(*attrs)
+}
+
+func copyDefaults(defaults map[interface{}]interface{}) map[interface{}]interface{} {
+	res := make(map[interface{}]interface{}, len(defaults))
+	for k, v := range defaults {
+		res[k] = v
+	}
+	return res
+}
+
+// stronglyConnectedComponents finds the strongly connected components of
+// a graph given as successor lists, using Tarjan's algorithm.  It returns
+// the component of each node and the members of each component, with
+// components in reverse topological order
+func stronglyConnectedComponents(successors [][]int) ([]int, [][]int) {
+	n := len(successors)
+	index := make([]int, n)
+	lowlink := make([]int, n)
+	onStack := make([]bool, n)
+	component := make([]int, n)
+	for i := range index {
+		index[i] = -1
+	}
+	members := make([][]int, 0)
+	stack := make([]int, 0)
+	next := 0
+
+	type frame struct {
+		v     int
+		child int
+	}
+	for root := 0; root < n; root++ {
+		if index[root] >= 0 {
+			continue
+		}
+		calls := []frame{{v: root}}
+		index[root] = next
+		lowlink[root] = next
+		next++
+		stack = append(stack, root)
+		onStack[root] = true
+		for len(calls) > 0 {
+			f := &calls[len(calls)-1]
+			v := f.v
+			if f.child < len(successors[v]) {
+				w := successors[v][f.child]
+				f.child++
+				if index[w] < 0 {
+					index[w] = next
+					lowlink[w] = next
+					next++
+					stack = append(stack, w)
+					onStack[w] = true
+					calls = append(calls, frame{v: w})
+				} else if onStack[w] && index[w] < lowlink[v] {
+					lowlink[v] = index[w]
+				}
+				continue
+			}
+			if lowlink[v] == index[v] {
+				c := len(members)
+				group := make([]int, 0)
+				for {
+					w := stack[len(stack)-1]
+					stack = stack[:len(stack)-1]
+					onStack[w] = false
+					component[w] = c
+					group = append(group, w)
+					if w == v {
+						break
+					}
+				}
+				members = append(members, group)
+			}
+			calls = calls[:len(calls)-1]
+			if len(calls) > 0 {
+				parent := calls[len(calls)-1].v
+				if lowlink[v] < lowlink[parent] {
+					lowlink[parent] = lowlink[v]
+				}
+			}
+		}
+	}
+	return component, members
+}
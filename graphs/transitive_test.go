@@ -0,0 +1,115 @@
+package graphs
+
+// Copyright © 2018 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wealdtech/go-graph/edges"
+	"github.com/wealdtech/go-graph/nodes"
+)
+
+func TestReachability(t *testing.T) {
+	g := NewDirectedGraph()
+	for i := int64(1); i <= 5; i++ {
+		err := g.AddNode(nodes.NewSimpleNode(i))
+		assert.NoError(t, err)
+	}
+	// 1 -> 2 -> 3 -> 2 (cycle), 3 -> 4, 5 isolated
+	for _, pair := range [][2]int64{{1, 2}, {2, 3}, {3, 2}, {3, 4}} {
+		err := g.AddEdge(edges.NewDirectedEdge(pair[0], pair[1]))
+		assert.NoError(t, err)
+	}
+
+	r := g.Reachability()
+	assert.Equal(t, []int64{2, 3, 4}, r.ReachableFrom(1))
+	assert.Equal(t, []int64{2, 3, 4}, r.ReachableFrom(2))
+	assert.Equal(t, []int64{}, r.ReachableFrom(4))
+	assert.True(t, r.Reachable(1, 4))
+	assert.True(t, r.Reachable(2, 2))
+	assert.False(t, r.Reachable(1, 1))
+	assert.False(t, r.Reachable(4, 1))
+	assert.False(t, r.Reachable(1, 5))
+	assert.False(t, r.Reachable(1, 6))
+}
+
+func TestTransitiveClosure(t *testing.T) {
+	g := NewDirectedGraph()
+	for i := int64(1); i <= 4; i++ {
+		err := g.AddNode(nodes.NewSimpleNode(i))
+		assert.NoError(t, err)
+	}
+	g.Node(1).SetAttribute("name", "one")
+	edge12 := edges.NewDirectedEdge(1, 2)
+	edge12.SetAttribute("weight", 2)
+	err := g.AddEdge(edge12)
+	assert.NoError(t, err)
+	err = g.AddEdge(edges.NewDirectedEdge(2, 3))
+	assert.NoError(t, err)
+	err = g.AddEdge(edges.NewDirectedEdge(3, 4))
+	assert.NoError(t, err)
+	g.SetEdgeDefaults(map[interface{}]interface{}{"color": "blue"})
+
+	closure := g.TransitiveClosure()
+	assert.Len(t, closure.Nodes(), 4)
+	assert.Len(t, closure.Edges(1), 3)
+	assert.Len(t, closure.Edges(2), 2)
+	assert.Len(t, closure.Edges(3), 1)
+	assert.Len(t, closure.Edges(4), 0)
+	assert.Equal(t, edge12, closure.Edge(1, 2))
+	assert.True(t, closure.HasEdge(1, 4))
+	assert.Equal(t, "blue", closure.EdgeDefault("color"))
+
+	// Nodes and edges are copies
+	assert.Equal(t, "one", closure.Node(1).Attribute("name"))
+	closure.Node(1).SetAttribute("name", "uno")
+	closure.Edge(1, 2).SetAttribute("weight", 3)
+	assert.Equal(t, "one", g.Node(1).Attribute("name"))
+	assert.Equal(t, 2, edge12.Attribute("weight"))
+
+	// Original is unchanged
+	assert.Len(t, g.Edges(1), 1)
+}
+
+func TestTransitiveReduction(t *testing.T) {
+	g := NewDirectedGraph()
+	for i := int64(1); i <= 4; i++ {
+		err := g.AddNode(nodes.NewSimpleNode(i))
+		assert.NoError(t, err)
+	}
+	for _, pair := range [][2]int64{{1, 2}, {2, 3}, {3, 4}, {1, 3}, {1, 4}, {2, 4}} {
+		err := g.AddEdge(edges.NewDirectedEdge(pair[0], pair[1]))
+		assert.NoError(t, err)
+	}
+
+	reduction, dropped, err := g.TransitiveReduction()
+	assert.NoError(t, err)
+	assert.True(t, reduction.HasEdge(1, 2))
+	assert.True(t, reduction.HasEdge(2, 3))
+	assert.True(t, reduction.HasEdge(3, 4))
+	assert.Len(t, reduction.Edges(1), 1)
+	assert.Len(t, reduction.Edges(2), 1)
+	assert.Len(t, dropped, 3)
+	assert.False(t, g.Edge(1, 2) == reduction.Edge(1, 2))
+	assert.Equal(t, g.Edge(1, 3), dropped[0])
+	assert.Equal(t, g.Edge(1, 4), dropped[1])
+	assert.Equal(t, g.Edge(2, 4), dropped[2])
+
+	// Cycles are rejected
+	err = g.AddEdge(edges.NewDirectedEdge(4, 1))
+	assert.NoError(t, err)
+	_, _, err = g.TransitiveReduction()
+	assert.Error(t, err)
+}
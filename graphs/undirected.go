@@ -25,6 +25,8 @@ type UndirectedGraph struct {
 	graphDefaults map[interface{}]interface{}
 	nodeDefaults  map[interface{}]interface{}
 	edgeDefaults  map[interface{}]interface{}
+	indexes       indexes
+	watchers      graph.Watchers
 }
 
 func NewUndirectedGraph() *UndirectedGraph {
@@ -37,6 +39,11 @@ func NewUndirectedGraph() *UndirectedGraph {
 	}
 }
 
+// IsDirected returns false, as edges join nodes both ways
+func (g *UndirectedGraph) IsDirected() bool {
+	return false
+}
+
 func (g *UndirectedGraph) HasNode(nid int64) bool {
 	_, ok := g.nodes[nid]
 	return ok
@@ -104,6 +111,7 @@ func (g *UndirectedGraph) GraphDefault(key interface{}) interface{} {
 
 func (g *UndirectedGraph) SetGraphDefaults(defaults map[interface{}]interface{}) {
 	g.graphDefaults = defaults
+	g.watchers.Notify(graph.DefaultsChanged, 0, 0)
 }
 
 func (g *UndirectedGraph) NodeDefaults() *map[interface{}]interface{} {
@@ -116,6 +124,7 @@ func (g *UndirectedGraph) NodeDefault(key interface{}) interface{} {
 
 func (g *UndirectedGraph) SetNodeDefaults(defaults map[interface{}]interface{}) {
 	g.nodeDefaults = defaults
+	g.watchers.Notify(graph.DefaultsChanged, 0, 0)
 }
 
 func (g *UndirectedGraph) EdgeDefaults() *map[interface{}]interface{} {
@@ -128,6 +137,7 @@ func (g *UndirectedGraph) EdgeDefault(key interface{}) interface{} {
 
 func (g *UndirectedGraph) SetEdgeDefaults(defaults map[interface{}]interface{}) {
 	g.edgeDefaults = defaults
+	g.watchers.Notify(graph.DefaultsChanged, 0, 0)
 }
 
 // NodeManager
@@ -137,17 +147,28 @@ func (g *UndirectedGraph) AddNode(node graph.Node) error {
 	}
 	g.nodes[node.Id()] = node
 	g.edges[node.Id()] = make(map[int64]graph.Edge)
+	g.indexes.addNode(node)
+	g.watchers.Notify(graph.NodeAdded, node.Id(), node.Id())
 	return nil
 }
 
 func (g *UndirectedGraph) RemoveNode(nid int64) graph.Node {
 	node := g.Node(nid)
 	delete(g.nodes, nid)
+	removed := g.edges[nid]
 	// Delete associated edges
-	for bid, _ := range g.edges[nid] {
+	for bid, edge := range removed {
+		g.indexes.removeEdge(edge.From(), edge.To())
 		delete(g.edges[bid], nid)
 	}
+	g.indexes.removeNode(nid)
 	delete(g.edges, nid)
+	for _, edge := range removed {
+		g.watchers.Notify(graph.EdgeRemoved, edge.From(), edge.To())
+	}
+	if node != nil {
+		g.watchers.Notify(graph.NodeRemoved, nid, nid)
+	}
 	return node
 }
 
@@ -167,14 +188,101 @@ func (g *UndirectedGraph) AddEdge(edge graph.Edge) error {
 	if edge.From() != edge.To() {
 		g.edges[edge.To()][edge.From()] = edge
 	}
+	g.indexes.addEdge(edge)
+	g.watchers.Notify(graph.EdgeAdded, edge.From(), edge.To())
 	return nil
 }
 
 func (g *UndirectedGraph) RemoveEdge(aid, bid int64) graph.Edge {
 	edge := g.Edge(aid, bid)
+	if edge != nil {
+		g.indexes.removeEdge(edge.From(), edge.To())
+	}
 	delete(g.edges[aid], bid)
 	if aid != bid {
 		delete(g.edges[bid], aid)
 	}
+	if edge != nil {
+		g.watchers.Notify(graph.EdgeRemoved, edge.From(), edge.To())
+	}
 	return edge
 }
+
+// Watch adds an observer of changes to the graph's nodes, edges and
+// defaults, returning a function that removes it
+func (g *UndirectedGraph) Watch(observer graph.GraphObserver) func() {
+	return g.watchers.Watch(observer)
+}
+
+// IndexNodes indexes nodes by the value of an attribute.  The index is kept
+// up to date as nodes are added and removed, and as their attributes are
+// changed through SetAttribute and SetAttributes for nodes that are
+// graph.Observable
+func (g *UndirectedGraph) IndexNodes(key interface{}) {
+	nodes := make([]graph.Node, 0, len(g.nodes))
+	for _, node := range g.nodes {
+		nodes = append(nodes, node)
+	}
+	g.indexes.indexNodes(key, nodes)
+}
+
+// DropNodeIndex removes the index of nodes by an attribute
+func (g *UndirectedGraph) DropNodeIndex(key interface{}) {
+	g.indexes.dropNodeIndex(key)
+}
+
+// IndexEdges indexes edges by the value of an attribute, kept up to date
+// in the same way as node indexes
+func (g *UndirectedGraph) IndexEdges(key interface{}) {
+	edges := make([]graph.Edge, 0)
+	for aid, bids := range g.edges {
+		for _, edge := range bids {
+			if edge.From() == aid {
+				edges = append(edges, edge)
+			}
+		}
+	}
+	g.indexes.indexEdges(key, edges)
+}
+
+// DropEdgeIndex removes the index of edges by an attribute
+func (g *UndirectedGraph) DropEdgeIndex(key interface{}) {
+	g.indexes.dropEdgeIndex(key)
+}
+
+// NodesWhere returns the nodes with an indexed attribute equal to a value
+// of the same type, in ID order
+func (g *UndirectedGraph) NodesWhere(key, value interface{}) ([]graph.Node, error) {
+	return lookupNodes(g, &g.indexes, key, func(i *index) ([]ref, error) { return i.equal(value), nil })
+}
+
+// NodesBetween returns the nodes with an indexed attribute from min to max
+// inclusive, in ID order.  Numbers are compared with numbers and strings
+// with strings; a nil bound is open
+func (g *UndirectedGraph) NodesBetween(key, min, max interface{}) ([]graph.Node, error) {
+	return lookupNodes(g, &g.indexes, key, func(i *index) ([]ref, error) { return i.between(min, max) })
+}
+
+// NodesWithPrefix returns the nodes with an indexed string attribute
+// starting with a prefix, in ID order
+func (g *UndirectedGraph) NodesWithPrefix(key interface{}, prefix string) ([]graph.Node, error) {
+	return lookupNodes(g, &g.indexes, key, func(i *index) ([]ref, error) { return i.prefix(prefix), nil })
+}
+
+// EdgesWhere returns the edges with an indexed attribute equal to a value
+// of the same type, ordered by their ends
+func (g *UndirectedGraph) EdgesWhere(key, value interface{}) ([]graph.Edge, error) {
+	return lookupEdges(g, &g.indexes, key, func(i *index) ([]ref, error) { return i.equal(value), nil })
+}
+
+// EdgesBetween returns the edges with an indexed attribute from min to max
+// inclusive, ordered by their ends
+func (g *UndirectedGraph) EdgesBetween(key, min, max interface{}) ([]graph.Edge, error) {
+	return lookupEdges(g, &g.indexes, key, func(i *index) ([]ref, error) { return i.between(min, max) })
+}
+
+// EdgesWithPrefix returns the edges with an indexed string attribute
+// starting with a prefix, ordered by their ends
+func (g *UndirectedGraph) EdgesWithPrefix(key interface{}, prefix string) ([]graph.Edge, error) {
+	return lookupEdges(g, &g.indexes, key, func(i *index) ([]ref, error) { return i.prefix(prefix), nil })
+}
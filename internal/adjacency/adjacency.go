@@ -0,0 +1,130 @@
+package adjacency
+
+// Copyright © 2018 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"sort"
+
+	"github.com/wealdtech/go-graph"
+)
+
+// Lists is an undirected view of a graph with sorted neighbour lists.
+// Nodes are indexed in order of ID.  Self-loops and parallel edges are
+// dropped
+type Lists struct {
+	IDs        []int64
+	Neighbours [][]int32
+}
+
+// Number returns the IDs of the nodes of a graph in ascending order, and
+// the index of each ID among them
+func Number(g graph.Graph) ([]int64, map[int64]int) {
+	nodes := g.Nodes()
+	ids := make([]int64, 0, len(nodes))
+	for _, node := range nodes {
+		ids = append(ids, node.Id())
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	index := make(map[int64]int, len(ids))
+	for i, nid := range ids {
+		index[nid] = i
+	}
+	return ids, index
+}
+
+// New returns the adjacency lists of a graph
+func New(g graph.Graph) *Lists {
+	ids, index := Number(g)
+	neighbours := make([][]int32, len(ids))
+	for _, nid := range ids {
+		for _, edge := range g.Edges(nid) {
+			a, aok := index[edge.From()]
+			b, bok := index[edge.To()]
+			if !aok || !bok || a == b {
+				continue
+			}
+			neighbours[a] = append(neighbours[a], int32(b))
+			neighbours[b] = append(neighbours[b], int32(a))
+		}
+	}
+	for i := range neighbours {
+		list := neighbours[i]
+		sort.Slice(list, func(x, y int) bool { return list[x] < list[y] })
+		deduped := list[:0]
+		for j, v := range list {
+			if j == 0 || list[j-1] != v {
+				deduped = append(deduped, v)
+			}
+		}
+		neighbours[i] = deduped
+	}
+	return &Lists{
+		IDs:        ids,
+		Neighbours: neighbours,
+	}
+}
+
+// NodeIDs converts a set of indices to sorted node IDs
+func (a *Lists) NodeIDs(set []int32) []int64 {
+	res := make([]int64, len(set))
+	for i, v := range set {
+		res[i] = a.IDs[v]
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i] < res[j] })
+	return res
+}
+
+// DegeneracyOrder returns the nodes in the order they are removed when
+// repeatedly removing a node of minimum remaining degree
+func (a *Lists) DegeneracyOrder() []int32 {
+	n := len(a.IDs)
+	degrees := make([]int, n)
+	max := 0
+	for i := range degrees {
+		degrees[i] = len(a.Neighbours[i])
+		if degrees[i] > max {
+			max = degrees[i]
+		}
+	}
+	buckets := make([][]int32, max+1)
+	for i := range degrees {
+		buckets[degrees[i]] = append(buckets[degrees[i]], int32(i))
+	}
+	removed := make([]bool, n)
+	order := make([]int32, 0, n)
+	for d := 0; len(order) < n; {
+		if d > max || len(buckets[d]) == 0 {
+			d++
+			continue
+		}
+		v := buckets[d][len(buckets[d])-1]
+		buckets[d] = buckets[d][:len(buckets[d])-1]
+		if removed[v] || degrees[v] != d {
+			// Stale entry
+			continue
+		}
+		removed[v] = true
+		order = append(order, v)
+		for _, w := range a.Neighbours[v] {
+			if !removed[w] {
+				degrees[w]--
+				buckets[degrees[w]] = append(buckets[degrees[w]], w)
+				if degrees[w] < d {
+					d = degrees[w]
+				}
+			}
+		}
+	}
+	return order
+}
@@ -0,0 +1,105 @@
+package build
+
+// Copyright © 2018 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"sort"
+
+	"github.com/wealdtech/go-graph"
+	"github.com/wealdtech/go-graph/edges"
+	"github.com/wealdtech/go-graph/graphs"
+	"github.com/wealdtech/go-graph/nodes"
+)
+
+// Graph is a graph that can be built up and changed.  Edges are removed
+// one at a time, as by DirectedGraph and UndirectedGraph
+type Graph interface {
+	graph.Graph
+	graph.NodeManager
+	AddEdge(edge graph.Edge) error
+	RemoveEdge(aid, bid int64) graph.Edge
+}
+
+// New creates an empty directed or undirected graph
+func New(directed bool) Graph {
+	if directed {
+		return graphs.NewDirectedGraph()
+	}
+	return graphs.NewUndirectedGraph()
+}
+
+// NodeIDs returns the IDs of the nodes of a graph, sorted
+func NodeIDs(g graph.Graph) []int64 {
+	res := make([]int64, 0)
+	for _, node := range g.Nodes() {
+		res = append(res, node.Id())
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i] < res[j] })
+	return res
+}
+
+// OwnedEdges returns the edges starting from a node, sorted by their ends.
+// Each undirected edge is owned by its lower node, so visiting the owned
+// edges of every node visits each edge once
+func OwnedEdges(g graph.Graph, nid int64) []graph.Edge {
+	res := make([]graph.Edge, 0)
+	for _, edge := range g.Edges(nid) {
+		if edge.From() == nid {
+			res = append(res, edge)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].To() < res[j].To() })
+	return res
+}
+
+// CopyAttributes returns a copy of a set of attributes, which may be nil
+func CopyAttributes(attrs *map[interface{}]interface{}) map[interface{}]interface{} {
+	res := make(map[interface{}]interface{})
+	if attrs != nil {
+		for key, value := range *attrs {
+			res[key] = value
+		}
+	}
+	return res
+}
+
+// CopyDefaults copies the defaults of one graph to another
+func CopyDefaults(g Graph, from graph.Graph) {
+	g.SetGraphDefaults(CopyAttributes(from.GraphDefaults()))
+	g.SetNodeDefaults(CopyAttributes(from.NodeDefaults()))
+	g.SetEdgeDefaults(CopyAttributes(from.EdgeDefaults()))
+}
+
+// AddNode adds a simple node with the given attributes to a graph
+func AddNode(g Graph, nid int64, attrs map[interface{}]interface{}) error {
+	node := nodes.NewSimpleNode(nid)
+	node.SetAttributes(attrs)
+	return g.AddNode(node)
+}
+
+// AddEdge adds an edge of the graph's kind between two nodes with the
+// given attributes, unless the nodes are already joined
+func AddEdge(g Graph, from, to int64, attrs map[interface{}]interface{}) error {
+	if g.HasEdge(from, to) {
+		return nil
+	}
+	var edge graph.Edge
+	if graph.IsDirected(g) {
+		edge = edges.NewDirectedEdge(from, to)
+	} else {
+		edge = edges.NewUndirectedEdge(from, to)
+	}
+	edge.SetAttributes(attrs)
+	return g.AddEdge(edge)
+}
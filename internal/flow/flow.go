@@ -0,0 +1,137 @@
+package flow
+
+// Copyright © 2018 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"math"
+)
+
+// Network is a network of arcs with integer capacities and optional
+// costs.  Each arc is stored next to its residual partner, so arc i^1 is
+// the reverse of arc i
+type Network struct {
+	Arcs []Arc
+	Adj  [][]int
+}
+
+// Arc is an arc of a network
+type Arc struct {
+	From     int
+	To       int
+	Capacity int
+	Flow     int
+	Cost     float64
+}
+
+// New creates a network with n nodes and no arcs
+func New(n int) *Network {
+	return &Network{
+		Adj: make([][]int, n),
+	}
+}
+
+// AddArc adds an arc and its residual, returning the index of the arc
+func (f *Network) AddArc(from, to, capacity int, cost float64) int {
+	arc := len(f.Arcs)
+	f.Adj[from] = append(f.Adj[from], arc)
+	f.Arcs = append(f.Arcs, Arc{From: from, To: to, Capacity: capacity, Cost: cost})
+	f.Adj[to] = append(f.Adj[to], arc+1)
+	f.Arcs = append(f.Arcs, Arc{From: to, To: from, Cost: -cost})
+	return arc
+}
+
+// MaxFlow pushes as much flow as possible from source to sink along
+// shortest augmenting paths (Edmonds-Karp), ignoring costs.  It returns the
+// total flow
+func (f *Network) MaxFlow(source, sink int) int {
+	total := 0
+	for {
+		via := make([]int, len(f.Adj))
+		for i := range via {
+			via[i] = -1
+		}
+		queue := []int{source}
+		for len(queue) > 0 && via[sink] < 0 {
+			v := queue[0]
+			queue = queue[1:]
+			for _, i := range f.Adj[v] {
+				arc := f.Arcs[i]
+				if arc.To == source || via[arc.To] >= 0 || arc.Capacity-arc.Flow <= 0 {
+					continue
+				}
+				via[arc.To] = i
+				queue = append(queue, arc.To)
+			}
+		}
+		if via[sink] < 0 {
+			return total
+		}
+		total += f.augment(source, sink, via)
+	}
+}
+
+// MinCostFlow pushes as much flow as possible from source to sink along
+// successive cheapest augmenting paths, returning the total flow
+func (f *Network) MinCostFlow(source, sink int) int {
+	total := 0
+	for {
+		// Bellman-Ford, as residual arcs have negative costs
+		distance := make([]float64, len(f.Adj))
+		via := make([]int, len(f.Adj))
+		for i := range distance {
+			distance[i] = math.Inf(1)
+			via[i] = -1
+		}
+		distance[source] = 0
+		for round := 0; round < len(f.Adj); round++ {
+			updated := false
+			for v := range f.Adj {
+				if math.IsInf(distance[v], 1) {
+					continue
+				}
+				for _, a := range f.Adj[v] {
+					arc := f.Arcs[a]
+					if arc.Capacity-arc.Flow > 0 && distance[v]+arc.Cost < distance[arc.To]-1e-12 {
+						distance[arc.To] = distance[v] + arc.Cost
+						via[arc.To] = a
+						updated = true
+					}
+				}
+			}
+			if !updated {
+				break
+			}
+		}
+		if via[sink] < 0 {
+			return total
+		}
+		total += f.augment(source, sink, via)
+	}
+}
+
+// augment pushes as much flow as possible along the path to the sink given
+// by the arc used to reach each node, returning the amount pushed
+func (f *Network) augment(source, sink int, via []int) int {
+	amount := math.MaxInt32
+	for v := sink; v != source; v = f.Arcs[via[v]].From {
+		if residual := f.Arcs[via[v]].Capacity - f.Arcs[via[v]].Flow; residual < amount {
+			amount = residual
+		}
+	}
+	for v := sink; v != source; v = f.Arcs[via[v]].From {
+		f.Arcs[via[v]].Flow += amount
+		f.Arcs[via[v]^1].Flow -= amount
+	}
+	return amount
+}
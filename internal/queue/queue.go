@@ -0,0 +1,40 @@
+package queue
+
+// Copyright © 2018 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Item is a node waiting in a priority queue
+type Item struct {
+	ID       int64
+	Distance float64
+}
+
+// Priority is a min-heap of items for use with container/heap, ordered by
+// distance and then by ID
+type Priority []Item
+
+func (pq Priority) Len() int { return len(pq) }
+func (pq Priority) Less(i, j int) bool {
+	if pq[i].Distance != pq[j].Distance {
+		return pq[i].Distance < pq[j].Distance
+	}
+	return pq[i].ID < pq[j].ID
+}
+func (pq Priority) Swap(i, j int)       { pq[i], pq[j] = pq[j], pq[i] }
+func (pq *Priority) Push(x interface{}) { *pq = append(*pq, x.(Item)) }
+func (pq *Priority) Pop() interface{} {
+	old := *pq
+	item := old[len(old)-1]
+	*pq = old[:len(old)-1]
+	return item
+}
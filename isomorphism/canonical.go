@@ -0,0 +1,309 @@
+package isomorphism
+
+// Copyright © 2018 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/wealdtech/go-graph"
+)
+
+// Canonical is a canonical labelling of a graph.  Two graphs are
+// isomorphic exactly when their forms are equal
+type Canonical struct {
+	// Order lists the node IDs in canonical order
+	Order []int64
+	// Form describes the graph in terms of canonical positions
+	Form string
+}
+
+// Hash returns a fixed-length hash of the canonical form
+func (c *Canonical) Hash() string {
+	return digest(c.Form)
+}
+
+// CanonicalLabelling finds a canonical labelling of a graph by colour
+// refinement, individualising nodes where refinement alone cannot tell
+// them apart and keeping the smallest resulting form.  Interchangeable
+// nodes are only tried once, but the search can still grow exponentially
+// for large, highly symmetric graphs, so this is intended for small graphs
+func CanonicalLabelling(g graph.Graph, labels *Labels) *Canonical {
+	c := newCanonicaliser(newStructure(g), labels)
+	c.search(c.initial)
+	return &Canonical{
+		Order: c.bestOrder,
+		Form:  c.best,
+	}
+}
+
+// Isomorphic returns true if two graphs have the same structure
+func Isomorphic(g1, g2 graph.Graph) bool {
+	return IsomorphicLabelled(g1, g2, nil)
+}
+
+// IsomorphicLabelled returns true if two graphs have the same structure
+// and the same selected node and edge attributes
+func IsomorphicLabelled(g1, g2 graph.Graph, labels *Labels) bool {
+	s1, s2 := newStructure(g1), newStructure(g2)
+	if s1.directed != s2.directed || len(s1.ids) != len(s2.ids) || s1.size != s2.size {
+		return false
+	}
+	if WLHash(g1, 0, labels) != WLHash(g2, 0, labels) {
+		return false
+	}
+	return CanonicalLabelling(g1, labels).Form == CanonicalLabelling(g2, labels).Form
+}
+
+// canonicaliser holds the state of a canonical labelling search
+type canonicaliser struct {
+	s          *structure
+	nodeLabels []string
+	edgeLabels map[[2]int]string
+	edgeIDs    map[[2]int]int
+	initial    []int
+	best       string
+	bestOrder  []int64
+}
+
+func newCanonicaliser(s *structure, labels *Labels) *canonicaliser {
+	c := &canonicaliser{
+		s:          s,
+		nodeLabels: initialLabels(s, labels),
+		edgeLabels: make(map[[2]int]string, len(s.edges)),
+	}
+	for key, edge := range s.edges {
+		c.edgeLabels[key] = labels.edge(edge)
+	}
+	c.edgeIDs = intern(c.edgeLabels)
+	nodeKeys := make(map[[2]int]string, len(s.ids))
+	for v, label := range c.nodeLabels {
+		nodeKeys[[2]int{v, v}] = label
+	}
+	nodeIDs := intern(nodeKeys)
+	c.initial = make([]int, len(s.ids))
+	for v := range c.initial {
+		c.initial[v] = nodeIDs[[2]int{v, v}]
+	}
+	return c
+}
+
+// intern numbers labels by their sorted order, so the numbers depend only
+// on the labels themselves
+func intern(labels map[[2]int]string) map[[2]int]int {
+	distinct := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, label := range labels {
+		if !seen[label] {
+			seen[label] = true
+			distinct = append(distinct, label)
+		}
+	}
+	sort.Strings(distinct)
+	rank := make(map[string]int, len(distinct))
+	for i, label := range distinct {
+		rank[label] = i
+	}
+	res := make(map[[2]int]int, len(labels))
+	for key, label := range labels {
+		res[key] = rank[label]
+	}
+	return res
+}
+
+// refine splits colour classes until nodes of the same colour have the
+// same number of neighbours of each colour, along each kind of edge.
+// Colours are renumbered by the sorted order of their signatures
+func (c *canonicaliser) refine(colours []int) []int {
+	n := len(colours)
+	count := distinctCount(colours)
+	for {
+		signatures := make([][]int, n)
+		for v := 0; v < n; v++ {
+			triples := make([][3]int, 0, len(c.s.out[v])+len(c.s.in[v]))
+			for _, w := range c.s.out[v] {
+				triples = append(triples, [3]int{0, c.edgeIDs[[2]int{v, w}], colours[w]})
+			}
+			if c.s.directed {
+				for _, w := range c.s.in[v] {
+					triples = append(triples, [3]int{1, c.edgeIDs[[2]int{w, v}], colours[w]})
+				}
+			}
+			sort.Slice(triples, func(i, j int) bool {
+				for k := 0; k < 3; k++ {
+					if triples[i][k] != triples[j][k] {
+						return triples[i][k] < triples[j][k]
+					}
+				}
+				return false
+			})
+			signature := make([]int, 0, 1+3*len(triples))
+			signature = append(signature, colours[v])
+			for _, triple := range triples {
+				signature = append(signature, triple[:]...)
+			}
+			signatures[v] = signature
+		}
+		order := make([]int, n)
+		for i := range order {
+			order[i] = i
+		}
+		sort.Slice(order, func(i, j int) bool { return compareInts(signatures[order[i]], signatures[order[j]]) < 0 })
+		next := make([]int, n)
+		colour := 0
+		for i, v := range order {
+			if i > 0 && compareInts(signatures[order[i-1]], signatures[v]) != 0 {
+				colour++
+			}
+			next[v] = colour
+		}
+		colours = next
+		if n == 0 || colour+1 == count {
+			return colours
+		}
+		count = colour + 1
+	}
+}
+
+func distinctCount(colours []int) int {
+	seen := make(map[int]bool)
+	for _, colour := range colours {
+		seen[colour] = true
+	}
+	return len(seen)
+}
+
+func compareInts(a, b []int) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			if a[i] < b[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return len(a) - len(b)
+}
+
+// search refines the colouring and either records the resulting order or
+// individualises each distinct member of the first ambiguous colour class
+func (c *canonicaliser) search(colours []int) {
+	colours = c.refine(colours)
+	sizes := make(map[int]int)
+	for _, colour := range colours {
+		sizes[colour]++
+	}
+	target := -1
+	for colour, size := range sizes {
+		if size > 1 && (target < 0 || colour < target) {
+			target = colour
+		}
+	}
+	if target < 0 {
+		c.leaf(colours)
+		return
+	}
+
+	representatives := make([]int, 0)
+	for v, colour := range colours {
+		if colour != target {
+			continue
+		}
+		interchangeable := false
+		for _, r := range representatives {
+			if c.twins(r, v) {
+				interchangeable = true
+				break
+			}
+		}
+		if interchangeable {
+			continue
+		}
+		representatives = append(representatives, v)
+		next := make([]int, len(colours))
+		for w, colour := range colours {
+			next[w] = 2*colour + 1
+		}
+		next[v] = 2 * colours[v]
+		c.search(next)
+	}
+}
+
+// twins returns true if swapping two nodes leaves the graph unchanged
+func (c *canonicaliser) twins(u, v int) bool {
+	if c.nodeLabels[u] != c.nodeLabels[v] {
+		return false
+	}
+	edge := func(a, b int) string {
+		if label, exists := c.edgeLabels[[2]int{a, b}]; exists {
+			return "+" + label
+		}
+		return "-"
+	}
+	if edge(u, v) != edge(v, u) {
+		return false
+	}
+	for _, lists := range [][][]int{c.s.out, c.s.in} {
+		for _, w := range append(append([]int{}, lists[u]...), lists[v]...) {
+			if w == u || w == v {
+				continue
+			}
+			if edge(u, w) != edge(v, w) || edge(w, u) != edge(w, v) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// leaf builds the form for a discrete colouring and keeps it if it is the
+// smallest so far
+func (c *canonicaliser) leaf(colours []int) {
+	n := len(colours)
+	order := make([]int, n)
+	for v, colour := range colours {
+		order[colour] = v
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "directed=%v;nodes=%d;", c.s.directed, n)
+	for _, v := range order {
+		fmt.Fprintf(&b, "%q;", c.nodeLabels[v])
+	}
+	entries := make([][2]int, 0, len(c.edgeLabels))
+	for key := range c.edgeLabels {
+		a, z := colours[key[0]], colours[key[1]]
+		if !c.s.directed && a > z {
+			continue
+		}
+		entries = append(entries, [2]int{a, z})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i][0] != entries[j][0] {
+			return entries[i][0] < entries[j][0]
+		}
+		return entries[i][1] < entries[j][1]
+	})
+	for _, entry := range entries {
+		fmt.Fprintf(&b, "%d>%d:%q;", entry[0], entry[1], c.edgeLabels[[2]int{order[entry[0]], order[entry[1]]}])
+	}
+	form := b.String()
+	if c.bestOrder == nil || form < c.best {
+		c.best = form
+		c.bestOrder = make([]int64, n)
+		for i, v := range order {
+			c.bestOrder[i] = c.s.ids[v]
+		}
+	}
+}
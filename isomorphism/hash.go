@@ -0,0 +1,141 @@
+package isomorphism
+
+// Copyright © 2018 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/wealdtech/go-graph"
+)
+
+// Labels selects the node and edge attributes that hashing and canonical
+// labelling take into account.  A nil *Labels uses structure alone
+type Labels struct {
+	Node []interface{}
+	Edge []interface{}
+}
+
+func (l *Labels) node(node graph.Node) string {
+	if l == nil {
+		return ""
+	}
+	return attributeLabel(node, l.Node)
+}
+
+func (l *Labels) edge(edge graph.Edge) string {
+	if l == nil {
+		return ""
+	}
+	return attributeLabel(edge, l.Edge)
+}
+
+func attributeLabel(a graph.Attributed, keys []interface{}) string {
+	parts := make([]string, len(keys))
+	for i, key := range keys {
+		value := a.Attribute(key)
+		parts[i] = fmt.Sprintf("%T:%v", value, value)
+	}
+	return strings.Join(parts, "\x1f")
+}
+
+// initialLabels gives each node a label from its attributes and any
+// self-loop
+func initialLabels(s *structure, labels *Labels) []string {
+	res := make([]string, len(s.ids))
+	for v := range s.ids {
+		res[v] = labels.node(s.nodes[v])
+		if s.loops[v] {
+			res[v] += "\x1eloop\x1e" + labels.edge(s.edges[[2]int{v, v}])
+		}
+	}
+	return res
+}
+
+func digest(parts ...string) string {
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\x1d")))
+	return hex.EncodeToString(sum[:16])
+}
+
+// WLNodeHashes returns the Weisfeiler-Lehman label of each node after the
+// given number of iterations, which defaults to 3 if 0 or less.  Each
+// iteration hashes a node's label together with the labels of its
+// neighbours and the edges to them, so nodes with the same label have the
+// same neighbourhood to that depth
+func WLNodeHashes(g graph.Graph, iterations int, labels *Labels) map[int64]string {
+	s := newStructure(g)
+	history := wlLabels(s, iterations, labels)
+	res := make(map[int64]string, len(s.ids))
+	for v, nid := range s.ids {
+		res[nid] = history[len(history)-1][v]
+	}
+	return res
+}
+
+// WLHash returns a Weisfeiler-Lehman subtree hash of a graph, which does
+// not depend on node IDs or the order in which the graph was built.
+// Isomorphic graphs always have the same hash; graphs with the same hash
+// are very likely but not certain to be isomorphic.  Iterations default
+// to 3 if 0 or less
+func WLHash(g graph.Graph, iterations int, labels *Labels) string {
+	s := newStructure(g)
+	history := wlLabels(s, iterations, labels)
+	all := make([]string, 0, len(history)*len(s.ids))
+	for _, round := range history {
+		all = append(all, round...)
+	}
+	sort.Strings(all)
+	return digest(append([]string{fmt.Sprintf("directed=%v", s.directed), fmt.Sprintf("edges=%d", s.size)}, all...)...)
+}
+
+// wlLabels returns the labels of each node after each iteration, starting
+// with the hashed initial labels
+func wlLabels(s *structure, iterations int, labels *Labels) [][]string {
+	if iterations <= 0 {
+		iterations = 3
+	}
+	current := initialLabels(s, labels)
+	for v := range current {
+		current[v] = digest(current[v])
+	}
+	history := [][]string{current}
+	for i := 0; i < iterations; i++ {
+		next := make([]string, len(s.ids))
+		for v := range s.ids {
+			next[v] = digest(append([]string{current[v]}, neighbourhood(s, v, labels, current)...)...)
+		}
+		current = next
+		history = append(history, current)
+	}
+	return history
+}
+
+// neighbourhood returns the sorted labels of a node's neighbours, along
+// with the edges to them
+func neighbourhood(s *structure, v int, labels *Labels, current []string) []string {
+	res := make([]string, 0, len(s.out[v])+len(s.in[v]))
+	for _, w := range s.out[v] {
+		res = append(res, "out\x1e"+labels.edge(s.edges[[2]int{v, w}])+"\x1e"+current[w])
+	}
+	if s.directed {
+		for _, w := range s.in[v] {
+			res = append(res, "in\x1e"+labels.edge(s.edges[[2]int{w, v}])+"\x1e"+current[w])
+		}
+	}
+	sort.Strings(res)
+	return res
+}
@@ -0,0 +1,252 @@
+package isomorphism
+
+// Copyright © 2018 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wealdtech/go-graph/edges"
+	"github.com/wealdtech/go-graph/graphs"
+	"github.com/wealdtech/go-graph/nodes"
+)
+
+func typedNode(id int64, kind string) *nodes.SimpleNode {
+	node := nodes.NewSimpleNode(id)
+	node.SetAttribute("type", kind)
+	return node
+}
+
+func directed(t *testing.T, kinds map[int64]string, pairs [][2]int64) *graphs.DirectedGraph {
+	g := graphs.NewDirectedGraph()
+	for id, kind := range kinds {
+		assert.NoError(t, g.AddNode(typedNode(id, kind)))
+	}
+	for _, pair := range pairs {
+		assert.NoError(t, g.AddEdge(edges.NewDirectedEdge(pair[0], pair[1])))
+	}
+	return g
+}
+
+func undirected(t *testing.T, n int64, pairs [][2]int64) *graphs.UndirectedGraph {
+	g := graphs.NewUndirectedGraph()
+	for i := int64(1); i <= n; i++ {
+		assert.NoError(t, g.AddNode(nodes.NewSimpleNode(i)))
+	}
+	for _, pair := range pairs {
+		assert.NoError(t, g.AddEdge(edges.NewUndirectedEdge(pair[0], pair[1])))
+	}
+	return g
+}
+
+func collect(t *testing.T, m *Matcher) []map[int64]int64 {
+	res := make([]map[int64]int64, 0)
+	err := m.Each(context.Background(), func(mapping map[int64]int64) bool {
+		res = append(res, mapping)
+		return true
+	})
+	assert.NoError(t, err)
+	return res
+}
+
+func TestPatternSearch(t *testing.T) {
+	// A load balancer with two backends that both talk to the same DB
+	pattern := directed(t,
+		map[int64]string{1: "lb", 2: "backend", 3: "backend", 4: "db"},
+		[][2]int64{{1, 2}, {1, 3}, {2, 4}, {3, 4}})
+	// Load balancer 10 has backends 11, 12 and 13; 11 and 12 share DB 20
+	// while 13 uses DB 21.  Load balancer 30 has a single backend
+	target := directed(t,
+		map[int64]string{10: "lb", 11: "backend", 12: "backend", 13: "backend", 20: "db", 21: "db", 30: "lb", 31: "backend"},
+		[][2]int64{{10, 11}, {10, 12}, {10, 13}, {11, 20}, {12, 20}, {13, 21}, {30, 31}, {31, 21}, {11, 12}})
+
+	m := NewMatcher(pattern, target, Monomorphism)
+	m.NodeMatch = NodeAttributesMatch("type")
+	matches := collect(t, m)
+	// Two matches, with the backends either way round
+	assert.Len(t, matches, 2)
+	for _, match := range matches {
+		assert.Equal(t, int64(10), match[1])
+		assert.Equal(t, int64(20), match[4])
+		assert.ElementsMatch(t, []int64{11, 12}, []int64{match[2], match[3]})
+	}
+
+	// The extra edge between 11 and 12 rules out an induced match
+	m = NewMatcher(pattern, target, SubgraphIsomorphism)
+	m.NodeMatch = NodeAttributesMatch("type")
+	assert.Len(t, collect(t, m), 0)
+
+	// Without types a second load balancer feeding 13 and 31 matches too
+	assert.NoError(t, target.AddEdge(edges.NewDirectedEdge(30, 13)))
+	m = NewMatcher(pattern, target, Monomorphism)
+	assert.Len(t, collect(t, m), 4)
+}
+
+func TestEdgeMatch(t *testing.T) {
+	pattern := directed(t, map[int64]string{1: "a", 2: "a"}, nil)
+	edge := edges.NewDirectedEdge(1, 2)
+	edge.SetAttribute("protocol", "grpc")
+	assert.NoError(t, pattern.AddEdge(edge))
+
+	target := directed(t, map[int64]string{1: "a", 2: "a", 3: "a"}, nil)
+	for _, pair := range [][2]int64{{1, 2}, {2, 3}} {
+		edge := edges.NewDirectedEdge(pair[0], pair[1])
+		edge.SetAttribute("protocol", "http")
+		assert.NoError(t, target.AddEdge(edge))
+	}
+	target.Edge(2, 3).SetAttribute("protocol", "grpc")
+
+	m := NewMatcher(pattern, target, Monomorphism)
+	m.EdgeMatch = EdgeAttributesMatch("protocol")
+	match, err := m.First(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, map[int64]int64{1: 2, 2: 3}, match)
+}
+
+func TestIsomorphism(t *testing.T) {
+	// A hexagon and a relabelled hexagon
+	a := undirected(t, 6, [][2]int64{{1, 2}, {2, 3}, {3, 4}, {4, 5}, {5, 6}, {6, 1}})
+	b := undirected(t, 6, [][2]int64{{1, 3}, {3, 5}, {5, 2}, {2, 4}, {4, 6}, {6, 1}})
+	matches := collect(t, NewMatcher(a, b, Isomorphism))
+	// The hexagon has 12 automorphisms
+	assert.Len(t, matches, 12)
+	for _, match := range matches {
+		for _, pair := range [][2]int64{{1, 2}, {2, 3}, {3, 4}, {4, 5}, {5, 6}, {6, 1}} {
+			assert.True(t, b.HasEdge(match[pair[0]], match[pair[1]]))
+		}
+	}
+
+	// Two triangles are not a hexagon
+	c := undirected(t, 6, [][2]int64{{1, 2}, {2, 3}, {3, 1}, {4, 5}, {5, 6}, {6, 4}})
+	match, err := NewMatcher(a, c, Isomorphism).First(context.Background())
+	assert.NoError(t, err)
+	assert.Nil(t, match)
+
+	// A triangle is an induced subgraph of the two triangles but not of
+	// the hexagon
+	triangle := undirected(t, 3, [][2]int64{{1, 2}, {2, 3}, {3, 1}})
+	assert.Len(t, collect(t, NewMatcher(triangle, c, SubgraphIsomorphism)), 12)
+	assert.Len(t, collect(t, NewMatcher(triangle, a, SubgraphIsomorphism)), 0)
+
+	// A path of three nodes is a monomorphic but not an induced subgraph
+	// of a triangle
+	path := undirected(t, 3, [][2]int64{{1, 2}, {2, 3}})
+	assert.Len(t, collect(t, NewMatcher(path, triangle, Monomorphism)), 6)
+	assert.Len(t, collect(t, NewMatcher(path, triangle, SubgraphIsomorphism)), 0)
+}
+
+func TestMatcherStreaming(t *testing.T) {
+	complete := undirected(t, 6, nil)
+	for i := int64(1); i <= 6; i++ {
+		for j := i + 1; j <= 6; j++ {
+			assert.NoError(t, complete.AddEdge(edges.NewUndirectedEdge(i, j)))
+		}
+	}
+	path := undirected(t, 3, [][2]int64{{1, 2}, {2, 3}})
+
+	count := 0
+	for range NewMatcher(path, complete, Monomorphism).Channel(context.Background()) {
+		count++
+	}
+	assert.Equal(t, 120, count)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := NewMatcher(path, complete, Monomorphism).Each(ctx, func(map[int64]int64) bool { return true })
+	assert.Equal(t, context.Canceled, err)
+
+	err = NewMatcher(path, directed(t, nil, nil), Monomorphism).Each(context.Background(), func(map[int64]int64) bool { return true })
+	assert.Error(t, err)
+}
+
+func TestWLHash(t *testing.T) {
+	a := undirected(t, 6, [][2]int64{{1, 2}, {2, 3}, {3, 4}, {4, 5}, {5, 6}, {6, 1}})
+	b := undirected(t, 6, [][2]int64{{1, 3}, {3, 5}, {5, 2}, {2, 4}, {4, 6}, {6, 1}})
+	c := undirected(t, 6, [][2]int64{{1, 2}, {2, 3}, {3, 1}, {4, 5}, {5, 6}, {6, 4}})
+	assert.Equal(t, WLHash(a, 0, nil), WLHash(b, 0, nil))
+	// Two triangles and a hexagon are the classic case that WL cannot
+	// separate
+	assert.Equal(t, WLHash(a, 0, nil), WLHash(c, 0, nil))
+
+	path := undirected(t, 3, [][2]int64{{1, 2}, {2, 3}})
+	hashes := WLNodeHashes(path, 2, nil)
+	assert.Equal(t, hashes[1], hashes[3])
+	assert.NotEqual(t, hashes[1], hashes[2])
+
+	// Attributes are only included when selected
+	labelled := undirected(t, 6, [][2]int64{{1, 2}, {2, 3}, {3, 4}, {4, 5}, {5, 6}, {6, 1}})
+	labelled.Node(1).SetAttribute("colour", "red")
+	labelled.Edge(3, 4).SetAttribute("weight", 2)
+	assert.Equal(t, WLHash(a, 0, nil), WLHash(labelled, 0, nil))
+	assert.NotEqual(t, WLHash(a, 0, nil), WLHash(labelled, 0, &Labels{Node: []interface{}{"colour"}}))
+	assert.NotEqual(t, WLHash(a, 0, nil), WLHash(labelled, 0, &Labels{Edge: []interface{}{"weight"}}))
+
+	// Direction matters
+	forward := directed(t, map[int64]string{1: "a", 2: "a", 3: "a"}, [][2]int64{{1, 2}, {2, 3}})
+	star := directed(t, map[int64]string{1: "a", 2: "a", 3: "a"}, [][2]int64{{1, 2}, {3, 2}})
+	assert.NotEqual(t, WLHash(forward, 0, nil), WLHash(star, 0, nil))
+	assert.NotEqual(t, WLHash(forward, 0, nil), WLHash(path, 0, nil))
+}
+
+func TestCanonicalLabelling(t *testing.T) {
+	a := undirected(t, 6, [][2]int64{{1, 2}, {2, 3}, {3, 4}, {4, 5}, {5, 6}, {6, 1}})
+	b := undirected(t, 6, [][2]int64{{1, 3}, {3, 5}, {5, 2}, {2, 4}, {4, 6}, {6, 1}})
+	c := undirected(t, 6, [][2]int64{{1, 2}, {2, 3}, {3, 1}, {4, 5}, {5, 6}, {6, 4}})
+
+	ca, cb := CanonicalLabelling(a, nil), CanonicalLabelling(b, nil)
+	assert.Equal(t, ca.Form, cb.Form)
+	assert.Equal(t, ca.Hash(), cb.Hash())
+	assert.Len(t, ca.Order, 6)
+	// Consecutive nodes in canonical order of b are joined as in a
+	for i := 0; i < 6; i++ {
+		for j := 0; j < 6; j++ {
+			assert.Equal(t, a.HasEdge(ca.Order[i], ca.Order[j]), b.HasEdge(cb.Order[i], cb.Order[j]))
+		}
+	}
+	assert.NotEqual(t, ca.Form, CanonicalLabelling(c, nil).Form)
+
+	assert.True(t, Isomorphic(a, b))
+	assert.False(t, Isomorphic(a, c))
+	assert.False(t, Isomorphic(a, undirected(t, 6, nil)))
+	assert.True(t, Isomorphic(undirected(t, 8, nil), undirected(t, 8, nil)))
+
+	// Labelled isomorphism
+	b.Node(3).SetAttribute("colour", "red")
+	labels := &Labels{Node: []interface{}{"colour"}}
+	assert.True(t, Isomorphic(a, b))
+	assert.False(t, IsomorphicLabelled(a, b, labels))
+	a.Node(6).SetAttribute("colour", "red")
+	assert.True(t, IsomorphicLabelled(a, b, labels))
+
+	forward := directed(t, map[int64]string{1: "a", 2: "a", 3: "a"}, [][2]int64{{1, 2}, {2, 3}})
+	backward := directed(t, map[int64]string{1: "a", 2: "a", 3: "a"}, [][2]int64{{3, 2}, {2, 1}})
+	star := directed(t, map[int64]string{1: "a", 2: "a", 3: "a"}, [][2]int64{{1, 2}, {3, 2}})
+	assert.True(t, Isomorphic(forward, backward))
+	assert.False(t, Isomorphic(forward, star))
+}
+
+func TestLookahead(t *testing.T) {
+	// A square with a triangular roof
+	house := undirected(t, 5, [][2]int64{{1, 2}, {2, 3}, {3, 4}, {4, 1}, {1, 5}, {2, 5}})
+	path := undirected(t, 3, [][2]int64{{1, 2}, {2, 3}})
+	triangle := undirected(t, 3, [][2]int64{{1, 2}, {2, 3}, {3, 1}})
+
+	assert.Len(t, collect(t, NewMatcher(path, house, Monomorphism)), 18)
+	assert.Len(t, collect(t, NewMatcher(path, house, SubgraphIsomorphism)), 12)
+	assert.Len(t, collect(t, NewMatcher(triangle, house, Monomorphism)), 6)
+	assert.Len(t, collect(t, NewMatcher(triangle, house, SubgraphIsomorphism)), 6)
+	assert.Len(t, collect(t, NewMatcher(house, house, Isomorphism)), 2)
+	assert.Len(t, collect(t, NewMatcher(house, house, SubgraphIsomorphism)), 2)
+}
@@ -0,0 +1,109 @@
+package isomorphism
+
+// Copyright © 2018 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"sort"
+
+	"github.com/wealdtech/go-graph"
+)
+
+// structure is an indexed copy of a graph's adjacency.  For undirected
+// graphs the successors and predecessors are the same.  Self-loops are
+// kept out of the neighbour lists
+type structure struct {
+	directed bool
+	ids      []int64
+	index    map[int64]int
+	nodes    []graph.Node
+	out      [][]int
+	in       [][]int
+	edges    map[[2]int]graph.Edge
+	loops    []bool
+	size     int
+}
+
+func newStructure(g graph.Graph) *structure {
+	nodes := g.Nodes()
+	s := &structure{
+		directed: graph.IsDirected(g),
+		ids:      make([]int64, 0, len(nodes)),
+		index:    make(map[int64]int, len(nodes)),
+		nodes:    make([]graph.Node, len(nodes)),
+		out:      make([][]int, len(nodes)),
+		in:       make([][]int, len(nodes)),
+		edges:    make(map[[2]int]graph.Edge),
+		loops:    make([]bool, len(nodes)),
+	}
+	for _, node := range nodes {
+		s.ids = append(s.ids, node.Id())
+	}
+	sort.Slice(s.ids, func(i, j int) bool { return s.ids[i] < s.ids[j] })
+	for i, nid := range s.ids {
+		s.index[nid] = i
+		s.nodes[i] = g.Node(nid)
+	}
+	for v, nid := range s.ids {
+		for _, edge := range g.Edges(nid) {
+			a, aok := s.index[edge.From()]
+			b, bok := s.index[edge.To()]
+			if !aok || !bok || a != v {
+				continue
+			}
+			s.size++
+			s.edges[[2]int{a, b}] = edge
+			if a == b {
+				s.loops[a] = true
+				continue
+			}
+			s.out[a] = append(s.out[a], b)
+			s.in[b] = append(s.in[b], a)
+			if !s.directed {
+				s.edges[[2]int{b, a}] = edge
+				s.out[b] = append(s.out[b], a)
+				s.in[a] = append(s.in[a], b)
+			}
+		}
+	}
+	for v := range s.ids {
+		sort.Ints(s.out[v])
+		sort.Ints(s.in[v])
+	}
+	return s
+}
+
+// neighbours returns the nodes joined to v in either direction, without
+// duplicates
+func (s *structure) neighbours(v int) []int {
+	if !s.directed {
+		return s.out[v]
+	}
+	res := make([]int, 0, len(s.out[v])+len(s.in[v]))
+	i, j := 0, 0
+	for i < len(s.out[v]) || j < len(s.in[v]) {
+		switch {
+		case j == len(s.in[v]) || (i < len(s.out[v]) && s.out[v][i] < s.in[v][j]):
+			res = append(res, s.out[v][i])
+			i++
+		case i == len(s.out[v]) || s.in[v][j] < s.out[v][i]:
+			res = append(res, s.in[v][j])
+			j++
+		default:
+			res = append(res, s.out[v][i])
+			i++
+			j++
+		}
+	}
+	return res
+}
@@ -0,0 +1,473 @@
+package isomorphism
+
+// Copyright © 2018 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/wealdtech/go-graph"
+)
+
+// Mode is the kind of match to search for
+type Mode int
+
+const (
+	// Isomorphism maps every node of the pattern to a node of the target,
+	// which must be the same size, with edges matching exactly
+	Isomorphism Mode = iota
+	// SubgraphIsomorphism maps the pattern to a subgraph of the target
+	// induced by the matched nodes: two matched nodes are joined in the
+	// target exactly when they are joined in the pattern
+	SubgraphIsomorphism
+	// Monomorphism maps the pattern to any subgraph of the target: the
+	// target may have extra edges between matched nodes
+	Monomorphism
+)
+
+// NodeMatch decides whether a node of the pattern may be matched to a node
+// of the target
+type NodeMatch func(pattern, target graph.Node) bool
+
+// EdgeMatch decides whether an edge of the pattern may be matched to an
+// edge of the target
+type EdgeMatch func(pattern, target graph.Edge) bool
+
+// NodeAttributesMatch returns a NodeMatch that requires the given
+// attributes to be equal.  An attribute missing from both nodes is equal
+func NodeAttributesMatch(keys ...interface{}) NodeMatch {
+	return func(pattern, target graph.Node) bool {
+		return attributesEqual(pattern, target, keys)
+	}
+}
+
+// EdgeAttributesMatch returns an EdgeMatch that requires the given
+// attributes to be equal.  An attribute missing from both edges is equal
+func EdgeAttributesMatch(keys ...interface{}) EdgeMatch {
+	return func(pattern, target graph.Edge) bool {
+		return attributesEqual(pattern, target, keys)
+	}
+}
+
+func attributesEqual(a, b graph.Attributed, keys []interface{}) bool {
+	for _, key := range keys {
+		if !reflect.DeepEqual(a.Attribute(key), b.Attribute(key)) {
+			return false
+		}
+	}
+	return true
+}
+
+// Matcher searches for matches of a pattern graph in a target graph using
+// VF2++: pattern nodes are matched in an order that reaches the most
+// constrained nodes first, and each candidate is checked against the
+// nodes already matched and pruned by looking ahead at its unmatched
+// neighbours.  Both graphs must be directed or both undirected
+type Matcher struct {
+	// NodeMatch, if set, decides which nodes may be matched
+	NodeMatch NodeMatch
+	// EdgeMatch, if set, decides which edges may be matched
+	EdgeMatch EdgeMatch
+
+	pattern graph.Graph
+	target  graph.Graph
+	mode    Mode
+}
+
+// NewMatcher creates a matcher for the given pattern, target and mode
+func NewMatcher(pattern, target graph.Graph, mode Mode) *Matcher {
+	return &Matcher{
+		pattern: pattern,
+		target:  target,
+		mode:    mode,
+	}
+}
+
+// Each calls fn with each match, as a map from pattern node IDs to target
+// node IDs.  Symmetric patterns match the same target nodes several times,
+// once for each automorphism.  Enumeration stops if fn returns false, or
+// with the context's error if it is cancelled
+func (m *Matcher) Each(ctx context.Context, fn func(mapping map[int64]int64) bool) error {
+	if graph.IsDirected(m.pattern) != graph.IsDirected(m.target) {
+		return fmt.Errorf("Pattern and target must both be directed or both be undirected")
+	}
+	s := newState(ctx, m, fn)
+	if s.possible() {
+		s.match(0)
+	}
+	return s.err
+}
+
+// Channel streams matches over a channel, which is closed when enumeration
+// finishes.  Cancel the context to stop enumeration early.  Errors give no
+// matches
+func (m *Matcher) Channel(ctx context.Context) <-chan map[int64]int64 {
+	ch := make(chan map[int64]int64)
+	go func() {
+		defer close(ch)
+		m.Each(ctx, func(mapping map[int64]int64) bool {
+			select {
+			case ch <- mapping:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		})
+	}()
+	return ch
+}
+
+// First returns the first match found, or nil if there is none
+func (m *Matcher) First(ctx context.Context) (map[int64]int64, error) {
+	var res map[int64]int64
+	err := m.Each(ctx, func(mapping map[int64]int64) bool {
+		res = mapping
+		return false
+	})
+	return res, err
+}
+
+// state holds the progress of a search
+type state struct {
+	ctx     context.Context
+	m       *Matcher
+	p       *structure
+	t       *structure
+	fn      func(mapping map[int64]int64) bool
+	err     error
+	done    bool
+	steps   int
+	order   []int
+	compat  [][]bool
+	core    []int
+	reverse []int
+	// pNeighbours and tNeighbours hold the neighbours of each node in
+	// either direction, and pTerminal and tTerminal how many of them have
+	// been matched.  Unmatched nodes with matched neighbours form the
+	// terminal sets
+	pNeighbours [][]int
+	tNeighbours [][]int
+	pTerminal   []int
+	tTerminal   []int
+}
+
+func newState(ctx context.Context, m *Matcher, fn func(mapping map[int64]int64) bool) *state {
+	s := &state{
+		ctx: ctx,
+		m:   m,
+		p:   newStructure(m.pattern),
+		t:   newStructure(m.target),
+		fn:  fn,
+	}
+	s.core = make([]int, len(s.p.ids))
+	for i := range s.core {
+		s.core[i] = -1
+	}
+	s.reverse = make([]int, len(s.t.ids))
+	for i := range s.reverse {
+		s.reverse[i] = -1
+	}
+	s.pNeighbours = make([][]int, len(s.p.ids))
+	for u := range s.pNeighbours {
+		s.pNeighbours[u] = s.p.neighbours(u)
+	}
+	s.tNeighbours = make([][]int, len(s.t.ids))
+	for v := range s.tNeighbours {
+		s.tNeighbours[v] = s.t.neighbours(v)
+	}
+	s.pTerminal = make([]int, len(s.p.ids))
+	s.tTerminal = make([]int, len(s.t.ids))
+	return s
+}
+
+// possible carries out cheap checks on the whole graphs and works out the
+// candidate targets for each pattern node.  It returns false if there can
+// be no match
+func (s *state) possible() bool {
+	if len(s.p.ids) > len(s.t.ids) {
+		return false
+	}
+	if s.m.mode == Isomorphism && (len(s.p.ids) != len(s.t.ids) || s.p.size != s.t.size) {
+		return false
+	}
+	s.compat = make([][]bool, len(s.p.ids))
+	counts := make([]int, len(s.p.ids))
+	for u := range s.p.ids {
+		s.compat[u] = make([]bool, len(s.t.ids))
+		for v := range s.t.ids {
+			if s.compatible(u, v) {
+				s.compat[u][v] = true
+				counts[u]++
+			}
+		}
+		if counts[u] == 0 {
+			return false
+		}
+	}
+	s.order = s.matchingOrder(counts)
+	return true
+}
+
+// compatible checks whether a pattern node could ever match a target node
+func (s *state) compatible(u, v int) bool {
+	pOut, pIn := len(s.p.out[u]), len(s.p.in[u])
+	tOut, tIn := len(s.t.out[v]), len(s.t.in[v])
+	if s.m.mode == Isomorphism {
+		if pOut != tOut || pIn != tIn || s.p.loops[u] != s.t.loops[v] {
+			return false
+		}
+	} else if pOut > tOut || pIn > tIn || (s.p.loops[u] && !s.t.loops[v]) {
+		return false
+	}
+	if s.m.mode == SubgraphIsomorphism && s.t.loops[v] && !s.p.loops[u] {
+		return false
+	}
+	if s.m.NodeMatch != nil && !s.m.NodeMatch(s.p.nodes[u], s.t.nodes[v]) {
+		return false
+	}
+	if s.p.loops[u] && s.m.EdgeMatch != nil && !s.m.EdgeMatch(s.p.edges[[2]int{u, u}], s.t.edges[[2]int{v, v}]) {
+		return false
+	}
+	return true
+}
+
+// matchingOrder orders the pattern nodes breadth-first from the most
+// constrained node.  Within each level, nodes with the most neighbours
+// already ordered come first, then those with the fewest candidates, then
+// those with the highest degree
+func (s *state) matchingOrder(counts []int) []int {
+	n := len(s.p.ids)
+	order := make([]int, 0, n)
+	ordered := make([]bool, n)
+	connections := make([]int, n)
+	degree := make([]int, n)
+	for u := range degree {
+		degree[u] = len(s.p.neighbours(u))
+	}
+	better := func(a, b int) bool {
+		if connections[a] != connections[b] {
+			return connections[a] > connections[b]
+		}
+		if counts[a] != counts[b] {
+			return counts[a] < counts[b]
+		}
+		if degree[a] != degree[b] {
+			return degree[a] > degree[b]
+		}
+		return a < b
+	}
+	for len(order) < n {
+		root := -1
+		for u := 0; u < n; u++ {
+			if !ordered[u] && (root < 0 || better(u, root)) {
+				root = u
+			}
+		}
+		level := []int{root}
+		seen := map[int]bool{root: true}
+		for len(level) > 0 {
+			next := make([]int, 0)
+			for len(level) > 0 {
+				best := 0
+				for i := range level {
+					if better(level[i], level[best]) {
+						best = i
+					}
+				}
+				u := level[best]
+				level = append(level[:best], level[best+1:]...)
+				order = append(order, u)
+				ordered[u] = true
+				for _, w := range s.p.neighbours(u) {
+					connections[w]++
+					if !ordered[w] && !seen[w] {
+						seen[w] = true
+						next = append(next, w)
+					}
+				}
+			}
+			level = next
+		}
+	}
+	return order
+}
+
+// match extends the current partial match with the pattern node at the
+// given position of the order
+func (s *state) match(position int) {
+	if position == len(s.order) {
+		mapping := make(map[int64]int64, len(s.core))
+		for u, v := range s.core {
+			mapping[s.p.ids[u]] = s.t.ids[v]
+		}
+		if err := s.ctx.Err(); err != nil {
+			s.err = err
+			s.done = true
+		} else if !s.fn(mapping) {
+			s.done = true
+		}
+		return
+	}
+	s.steps++
+	if s.steps%1024 == 0 {
+		if err := s.ctx.Err(); err != nil {
+			s.err = err
+			s.done = true
+			return
+		}
+	}
+
+	u := s.order[position]
+	for _, v := range s.candidates(u) {
+		if s.reverse[v] >= 0 || !s.compat[u][v] || !s.feasible(u, v) || !s.lookahead(u, v) {
+			continue
+		}
+		s.pair(u, v, 1)
+		s.match(position + 1)
+		s.pair(u, v, -1)
+		if s.done {
+			return
+		}
+	}
+}
+
+// pair matches a pattern node to a target node, or with a delta of -1
+// undoes the match
+func (s *state) pair(u, v int, delta int) {
+	if delta > 0 {
+		s.core[u] = v
+		s.reverse[v] = u
+	} else {
+		s.core[u] = -1
+		s.reverse[v] = -1
+	}
+	for _, w := range s.pNeighbours[u] {
+		s.pTerminal[w] += delta
+	}
+	for _, x := range s.tNeighbours[v] {
+		s.tTerminal[x] += delta
+	}
+}
+
+// candidates returns the target nodes that a pattern node might match.
+// If a neighbour of the node has been matched, only the corresponding
+// neighbours of its match are possible
+func (s *state) candidates(u int) []int {
+	for _, w := range s.p.out[u] {
+		if s.core[w] >= 0 {
+			return s.t.in[s.core[w]]
+		}
+	}
+	for _, w := range s.p.in[u] {
+		if s.core[w] >= 0 {
+			return s.t.out[s.core[w]]
+		}
+	}
+	res := make([]int, 0)
+	for v, ok := range s.compat[u] {
+		if ok {
+			res = append(res, v)
+		}
+	}
+	return res
+}
+
+// feasible checks a candidate pair against the nodes already matched
+func (s *state) feasible(u, v int) bool {
+	pOut, tOut := 0, 0
+	for _, w := range s.p.out[u] {
+		x := s.core[w]
+		if x < 0 {
+			continue
+		}
+		pOut++
+		edge, exists := s.t.edges[[2]int{v, x}]
+		if !exists {
+			return false
+		}
+		if s.m.EdgeMatch != nil && !s.m.EdgeMatch(s.p.edges[[2]int{u, w}], edge) {
+			return false
+		}
+	}
+	pIn, tIn := 0, 0
+	if s.p.directed {
+		for _, w := range s.p.in[u] {
+			x := s.core[w]
+			if x < 0 {
+				continue
+			}
+			pIn++
+			edge, exists := s.t.edges[[2]int{x, v}]
+			if !exists {
+				return false
+			}
+			if s.m.EdgeMatch != nil && !s.m.EdgeMatch(s.p.edges[[2]int{w, u}], edge) {
+				return false
+			}
+		}
+	}
+	if s.m.mode == Monomorphism {
+		return true
+	}
+	// Every pattern edge to a matched node has a target edge, so equal
+	// counts mean the target has no extra edges among matched nodes
+	for _, x := range s.t.out[v] {
+		if s.reverse[x] >= 0 {
+			tOut++
+		}
+	}
+	if s.t.directed {
+		for _, x := range s.t.in[v] {
+			if s.reverse[x] >= 0 {
+				tIn++
+			}
+		}
+	}
+	return pOut == tOut && pIn == tIn
+}
+
+// lookahead compares the unmatched neighbours of a candidate pair.  Every
+// match maps pattern neighbours in the terminal set to target neighbours
+// in the terminal set.  Induced matches also map the other neighbours to
+// target neighbours outside it, and isomorphisms do both one to one
+func (s *state) lookahead(u, v int) bool {
+	pTerminal, pNew := unmatched(s.pNeighbours[u], u, s.core, s.pTerminal)
+	tTerminal, tNew := unmatched(s.tNeighbours[v], v, s.reverse, s.tTerminal)
+	switch s.m.mode {
+	case Isomorphism:
+		return pTerminal == tTerminal && pNew == tNew
+	case SubgraphIsomorphism:
+		return pTerminal <= tTerminal && pNew <= tNew
+	default:
+		return pTerminal <= tTerminal && pTerminal+pNew <= tTerminal+tNew
+	}
+}
+
+// unmatched counts the unmatched neighbours of a node that are in the
+// terminal set and those that are not
+func unmatched(neighbours []int, self int, matched []int, terminal []int) (int, int) {
+	inside, outside := 0, 0
+	for _, w := range neighbours {
+		if w == self || matched[w] >= 0 {
+			continue
+		}
+		if terminal[w] > 0 {
+			inside++
+		} else {
+			outside++
+		}
+	}
+	return inside, outside
+}
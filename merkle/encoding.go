@@ -0,0 +1,90 @@
+package merkle
+
+// Copyright © 2018 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"sort"
+)
+
+// hasher writes length-prefixed fields to SHA-256, so that no two
+// different sequences of fields give the same input
+type hasher struct {
+	h hash.Hash
+}
+
+func newHasher(kind string) *hasher {
+	h := &hasher{h: sha256.New()}
+	h.string(kind)
+	return h
+}
+
+func (h *hasher) string(s string) {
+	var length [8]byte
+	binary.BigEndian.PutUint64(length[:], uint64(len(s)))
+	h.h.Write(length[:])
+	h.h.Write([]byte(s))
+}
+
+func (h *hasher) int(i int64) {
+	var value [8]byte
+	binary.BigEndian.PutUint64(value[:], uint64(i))
+	h.h.Write(value[:])
+}
+
+func (h *hasher) bool(b bool) {
+	if b {
+		h.h.Write([]byte{1})
+	} else {
+		h.h.Write([]byte{0})
+	}
+}
+
+// attributes writes a set of attributes sorted by their encoded keys
+func (h *hasher) attributes(attrs *map[interface{}]interface{}) {
+	if attrs == nil {
+		h.int(0)
+		return
+	}
+	entries := make([][2]string, 0, len(*attrs))
+	for key, value := range *attrs {
+		entries = append(entries, [2]string{encode(key), encode(value)})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i][0] != entries[j][0] {
+			return entries[i][0] < entries[j][0]
+		}
+		return entries[i][1] < entries[j][1]
+	})
+	h.int(int64(len(entries)))
+	for _, entry := range entries {
+		h.string(entry[0])
+		h.string(entry[1])
+	}
+}
+
+func (h *hasher) sum() string {
+	return hex.EncodeToString(h.h.Sum(nil))
+}
+
+// encode describes a key or value by its type and default formatting.
+// Values that format differently on different replicas, such as pointers,
+// will not hash consistently
+func encode(value interface{}) string {
+	return fmt.Sprintf("%T:%v", value, value)
+}
@@ -0,0 +1,419 @@
+package merkle
+
+// Copyright © 2018 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/wealdtech/go-graph"
+)
+
+// pathLength is the number of hex digits in a node's path
+const pathLength = 16
+
+// Tree is a Merkle tree over the nodes of a graph.  Each edge is hashed
+// from its ends and attributes, and each node from its ID, its attributes
+// and the hashes of the edges it owns.  As in the dot exporter, a node
+// owns the edges that start from it, so an undirected edge belongs to its
+// lower node.
+//
+// Nodes are arranged in a trie by their path, a hex form of their ID that
+// sorts in the same order as the IDs, and each branch of the trie hashes
+// its children.  The root hash covers the trie along with the graph's
+// directedness and defaults, so two graphs with the same root hash have
+// the same content.  Replicas with different roots can walk down from the
+// root with Children, following only branches that differ, to find the
+// nodes that differ.
+//
+// When the graph is graph.Watchable the tree rehashes the parts affected
+// as nodes, edges and defaults change, and as attributes change on nodes
+// and edges that are graph.Observable; call Close to stop.  Otherwise, or
+// after changing attributes through the maps returned by Attributes, call
+// UpdateNode, UpdateEdge or UpdateDefaults to do the same
+type Tree struct {
+	g        graph.Graph
+	directed bool
+	defaults string
+	nodes    map[int64]string
+	// edges holds the hashes of the edges owned by each node
+	edges map[int64]map[int64]string
+	// owners holds the nodes owning edges to each node
+	owners map[int64]map[int64]bool
+	// paths holds the paths of all nodes, sorted
+	paths    []string
+	subtrees map[string]string
+	// unwatch stops watching the graph, if it is being watched
+	unwatch func()
+	// nodeObservers and edgeObservers stop observing attributes
+	nodeObservers map[int64]func()
+	edgeObservers map[[2]int64]func()
+}
+
+// New creates a Merkle tree for a graph
+func New(g graph.Graph) *Tree {
+	t := &Tree{g: g}
+	if w, ok := g.(graph.Watchable); ok {
+		t.unwatch = w.Watch(t.changed)
+	}
+	t.Rebuild()
+	return t
+}
+
+// Close stops the tree following changes to the graph
+func (t *Tree) Close() {
+	if t.unwatch != nil {
+		t.unwatch()
+		t.unwatch = nil
+	}
+	t.forgetAll()
+}
+
+// Path returns the path of a node ID in the trie
+func Path(nid int64) string {
+	return fmt.Sprintf("%016x", uint64(nid)^(1<<63))
+}
+
+func pathID(path string) int64 {
+	value, _ := strconv.ParseUint(path, 16, 64)
+	return int64(value ^ (1 << 63))
+}
+
+// Rebuild rehashes the whole graph
+func (t *Tree) Rebuild() {
+	t.directed = graph.IsDirected(t.g)
+	t.nodes = make(map[int64]string)
+	t.edges = make(map[int64]map[int64]string)
+	t.owners = make(map[int64]map[int64]bool)
+	t.subtrees = make(map[string]string)
+	t.UpdateDefaults()
+	t.forgetAll()
+	nodes := t.g.Nodes()
+	t.paths = make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		t.hash(node.Id())
+		t.paths = append(t.paths, Path(node.Id()))
+		if t.unwatch != nil {
+			t.observeNode(node.Id())
+			for _, edge := range t.g.Edges(node.Id()) {
+				if edge.From() == node.Id() {
+					t.observeEdge(edge.From(), edge.To())
+				}
+			}
+		}
+	}
+	sort.Strings(t.paths)
+}
+
+// changed follows a change to a watched graph
+func (t *Tree) changed(change graph.Change, from, to int64) {
+	switch change {
+	case graph.NodeAdded:
+		t.observeNode(from)
+		t.UpdateNode(from)
+	case graph.NodeRemoved:
+		t.forgetNode(from)
+		t.UpdateNode(from)
+	case graph.EdgeAdded:
+		t.observeEdge(from, to)
+		t.UpdateEdge(from, to)
+	case graph.EdgeRemoved:
+		t.forgetEdge(from, to)
+		t.UpdateEdge(from, to)
+	case graph.DefaultsChanged:
+		t.UpdateDefaults()
+	}
+}
+
+// observeNode follows changes to the attributes of a node
+func (t *Tree) observeNode(nid int64) {
+	if o, ok := t.g.Node(nid).(graph.Observable); ok {
+		t.forgetNode(nid)
+		t.nodeObservers[nid] = o.Observe(func(key, old, new interface{}) {
+			t.rehash(nid)
+		})
+	}
+}
+
+// observeEdge follows changes to the attributes of an edge
+func (t *Tree) observeEdge(from, to int64) {
+	if o, ok := t.g.Edge(from, to).(graph.Observable); ok {
+		t.forgetEdge(from, to)
+		t.edgeObservers[[2]int64{from, to}] = o.Observe(func(key, old, new interface{}) {
+			t.UpdateEdge(from, to)
+		})
+	}
+}
+
+func (t *Tree) forgetNode(nid int64) {
+	if stop, exists := t.nodeObservers[nid]; exists {
+		stop()
+		delete(t.nodeObservers, nid)
+	}
+}
+
+func (t *Tree) forgetEdge(from, to int64) {
+	if stop, exists := t.edgeObservers[[2]int64{from, to}]; exists {
+		stop()
+		delete(t.edgeObservers, [2]int64{from, to})
+	}
+}
+
+// forgetAll stops observing the attributes of all nodes and edges
+func (t *Tree) forgetAll() {
+	for _, stop := range t.nodeObservers {
+		stop()
+	}
+	for _, stop := range t.edgeObservers {
+		stop()
+	}
+	t.nodeObservers = make(map[int64]func())
+	t.edgeObservers = make(map[[2]int64]func())
+}
+
+// UpdateDefaults rehashes the graph, node and edge defaults
+func (t *Tree) UpdateDefaults() {
+	h := newHasher("defaults")
+	h.bool(t.directed)
+	h.attributes(t.g.GraphDefaults())
+	h.attributes(t.g.NodeDefaults())
+	h.attributes(t.g.EdgeDefaults())
+	t.defaults = h.sum()
+}
+
+// UpdateNode rehashes a node after it has been added, removed or had its
+// attributes changed.  Nodes owning edges to it are rehashed as well, in
+// case removing the node removed those edges
+func (t *Tree) UpdateNode(nid int64) {
+	owners := make([]int64, 0, len(t.owners[nid]))
+	for owner := range t.owners[nid] {
+		owners = append(owners, owner)
+	}
+	t.rehash(nid)
+	for _, owner := range owners {
+		t.rehash(owner)
+	}
+}
+
+// UpdateEdge rehashes an edge after it has been added, removed or had its
+// attributes changed
+func (t *Tree) UpdateEdge(from, to int64) {
+	t.rehash(from)
+	if !t.directed && to != from {
+		t.rehash(to)
+	}
+}
+
+// rehash recalculates the hashes of a node and the edges it owns, keeping
+// the sorted paths up to date
+func (t *Tree) rehash(nid int64) {
+	path := Path(nid)
+	_, existed := t.nodes[nid]
+	t.hash(nid)
+	_, exists := t.nodes[nid]
+	switch {
+	case existed && !exists:
+		i := sort.SearchStrings(t.paths, path)
+		t.paths = append(t.paths[:i], t.paths[i+1:]...)
+	case !existed && exists:
+		i := sort.SearchStrings(t.paths, path)
+		t.paths = append(t.paths, "")
+		copy(t.paths[i+1:], t.paths[i:])
+		t.paths[i] = path
+	}
+}
+
+// hash recalculates the hashes of a node and the edges it owns
+func (t *Tree) hash(nid int64) {
+	for to := range t.edges[nid] {
+		delete(t.owners[to], nid)
+		if len(t.owners[to]) == 0 {
+			delete(t.owners, to)
+		}
+	}
+	delete(t.edges, nid)
+	t.invalidate(Path(nid))
+
+	if !t.g.HasNode(nid) {
+		delete(t.nodes, nid)
+		return
+	}
+
+	owned := make(map[int64]string)
+	for _, edge := range t.g.Edges(nid) {
+		if edge.From() != nid {
+			continue
+		}
+		h := newHasher("edge")
+		h.int(edge.From())
+		h.int(edge.To())
+		h.attributes(edge.Attributes())
+		owned[edge.To()] = h.sum()
+		if t.owners[edge.To()] == nil {
+			t.owners[edge.To()] = make(map[int64]bool)
+		}
+		t.owners[edge.To()][nid] = true
+	}
+	targets := make([]int64, 0, len(owned))
+	for to := range owned {
+		targets = append(targets, to)
+	}
+	sort.Slice(targets, func(i, j int) bool { return targets[i] < targets[j] })
+
+	h := newHasher("node")
+	h.int(nid)
+	h.attributes(t.g.Node(nid).Attributes())
+	h.int(int64(len(targets)))
+	for _, to := range targets {
+		h.int(to)
+		h.string(owned[to])
+	}
+	if len(owned) > 0 {
+		t.edges[nid] = owned
+	}
+	t.nodes[nid] = h.sum()
+}
+
+// invalidate forgets the cached hashes of the branches above a path
+func (t *Tree) invalidate(path string) {
+	for i := 0; i <= len(path); i++ {
+		delete(t.subtrees, path[:i])
+	}
+}
+
+// Root returns the root hash of the graph
+func (t *Tree) Root() string {
+	h := newHasher("root")
+	h.string(t.defaults)
+	h.string(t.Subtree(""))
+	return h.sum()
+}
+
+// DefaultsHash returns the hash of the graph's directedness and defaults
+func (t *Tree) DefaultsHash() string {
+	return t.defaults
+}
+
+// NodeHash returns the hash of a node, which covers the edges it owns
+func (t *Tree) NodeHash(nid int64) (string, bool) {
+	res, exists := t.nodes[nid]
+	return res, exists
+}
+
+// EdgeHash returns the hash of an edge
+func (t *Tree) EdgeHash(from, to int64) (string, bool) {
+	if res, exists := t.edges[from][to]; exists {
+		return res, true
+	}
+	if !t.directed {
+		res, exists := t.edges[to][from]
+		return res, exists
+	}
+	return "", false
+}
+
+// Subtree returns the hash of the nodes whose paths start with the given
+// prefix, or an empty string if there are none.  A subtree holding a
+// single node has the same hash wherever it is in the trie
+func (t *Tree) Subtree(prefix string) string {
+	if res, exists := t.subtrees[prefix]; exists {
+		return res
+	}
+	paths := t.under(prefix)
+	var res string
+	switch {
+	case len(paths) == 0:
+		res = ""
+	case len(paths) == 1:
+		h := newHasher("leaf")
+		h.string(paths[0])
+		h.string(t.nodes[pathID(paths[0])])
+		res = h.sum()
+	default:
+		h := newHasher("branch")
+		for _, child := range t.childPrefixes(prefix, paths) {
+			h.string(child)
+			h.string(t.Subtree(child))
+		}
+		res = h.sum()
+	}
+	t.subtrees[prefix] = res
+	return res
+}
+
+// Children returns the hashes of the non-empty branches one hex digit
+// below a prefix, keyed by their prefixes
+func (t *Tree) Children(prefix string) map[string]string {
+	res := make(map[string]string)
+	if len(prefix) >= pathLength {
+		return res
+	}
+	for _, child := range t.childPrefixes(prefix, t.under(prefix)) {
+		res[child] = t.Subtree(child)
+	}
+	return res
+}
+
+// under returns the sorted paths starting with a prefix
+func (t *Tree) under(prefix string) []string {
+	start := sort.SearchStrings(t.paths, prefix)
+	end := start
+	for end < len(t.paths) && strings.HasPrefix(t.paths[end], prefix) {
+		end++
+	}
+	return t.paths[start:end]
+}
+
+// childPrefixes returns the distinct prefixes one digit longer than the
+// given prefix among sorted paths that start with it
+func (t *Tree) childPrefixes(prefix string, paths []string) []string {
+	res := make([]string, 0)
+	for _, path := range paths {
+		child := path[:len(prefix)+1]
+		if len(res) == 0 || res[len(res)-1] != child {
+			res = append(res, child)
+		}
+	}
+	return res
+}
+
+// Diff returns the IDs of the nodes that differ between two trees, either
+// because they are only in one tree or because their hashes differ.  Only
+// branches with different hashes are visited.  Differences in defaults
+// are not included; compare DefaultsHash for those
+func Diff(a, b *Tree) []int64 {
+	res := make([]int64, 0)
+	diff(a, b, "", &res)
+	sort.Slice(res, func(i, j int) bool { return res[i] < res[j] })
+	return res
+}
+
+func diff(a, b *Tree, prefix string, res *[]int64) {
+	if a.Subtree(prefix) == b.Subtree(prefix) {
+		return
+	}
+	if len(prefix) == pathLength {
+		*res = append(*res, pathID(prefix))
+		return
+	}
+	children := a.Children(prefix)
+	for child := range b.Children(prefix) {
+		children[child] = ""
+	}
+	for child := range children {
+		diff(a, b, child, res)
+	}
+}
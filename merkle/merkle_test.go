@@ -0,0 +1,167 @@
+package merkle
+
+// Copyright © 2018 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wealdtech/go-graph/edges"
+	"github.com/wealdtech/go-graph/graphs"
+	"github.com/wealdtech/go-graph/nodes"
+)
+
+func undirected(t *testing.T, ids []int64, links [][2]int64) *graphs.UndirectedGraph {
+	g := graphs.NewUndirectedGraph()
+	for _, id := range ids {
+		assert.NoError(t, g.AddNode(nodes.NewSimpleNode(id)))
+	}
+	for _, link := range links {
+		assert.NoError(t, g.AddEdge(edges.NewUndirectedEdge(link[0], link[1])))
+	}
+	return g
+}
+
+func TestRoot(t *testing.T) {
+	g1 := undirected(t, []int64{1, 2, 3, -4}, [][2]int64{{1, 2}, {2, 3}, {3, -4}})
+	g2 := undirected(t, []int64{-4, 3, 2, 1}, [][2]int64{{-4, 3}, {3, 2}, {2, 1}})
+	t1, t2 := New(g1), New(g2)
+	assert.Len(t, t1.Root(), 64)
+	assert.Equal(t, t1.Root(), t2.Root())
+	assert.Equal(t, []int64{}, Diff(t1, t2))
+
+	// Same structure, but directed
+	d := graphs.NewDirectedGraph()
+	for _, id := range []int64{1, 2, 3, -4} {
+		assert.NoError(t, d.AddNode(nodes.NewSimpleNode(id)))
+	}
+	assert.NoError(t, d.AddEdge(edges.NewDirectedEdge(1, 2)))
+	assert.NoError(t, d.AddEdge(edges.NewDirectedEdge(2, 3)))
+	assert.NoError(t, d.AddEdge(edges.NewDirectedEdge(-4, 3)))
+	assert.NotEqual(t, t1.Root(), New(d).Root())
+
+	// Different IDs
+	g3 := undirected(t, []int64{1, 2, 3, 4}, [][2]int64{{1, 2}, {2, 3}, {3, 4}})
+	assert.NotEqual(t, t1.Root(), New(g3).Root())
+	assert.Equal(t, []int64{-4, 3, 4}, Diff(t1, New(g3)))
+
+	// Attribute types matter
+	g1.Node(1).SetAttribute("weight", 1)
+	g2.Node(1).SetAttribute("weight", "1")
+	t1.UpdateNode(1)
+	t2.UpdateNode(1)
+	assert.NotEqual(t, t1.Root(), t2.Root())
+	assert.Equal(t, []int64{1}, Diff(t1, t2))
+}
+
+func TestUpdates(t *testing.T) {
+	g1 := undirected(t, []int64{1, 2, 3, 4, 100}, [][2]int64{{1, 2}, {2, 3}, {3, 4}, {4, 100}})
+	g2 := undirected(t, []int64{1, 2, 3, 4, 100}, [][2]int64{{1, 2}, {2, 3}, {3, 4}, {4, 100}})
+	t1, t2 := New(g1), New(g2)
+	root := t1.Root()
+	node, exists := t1.NodeHash(2)
+	assert.True(t, exists)
+	edge, exists := t1.EdgeHash(3, 2)
+	assert.True(t, exists)
+
+	// Edge attributes change the edge and its owner
+	g1.Edge(2, 3).SetAttribute("colour", "red")
+	t1.UpdateEdge(2, 3)
+	assert.NotEqual(t, root, t1.Root())
+	changed, _ := t1.EdgeHash(2, 3)
+	assert.NotEqual(t, edge, changed)
+	changed, _ = t1.NodeHash(2)
+	assert.NotEqual(t, node, changed)
+	assert.Equal(t, []int64{2}, Diff(t1, t2))
+	assert.Equal(t, New(g1).Root(), t1.Root())
+
+	g2.Edge(2, 3).SetAttribute("colour", "red")
+	t2.UpdateEdge(3, 2)
+	assert.Equal(t, t1.Root(), t2.Root())
+
+	// Removing a node removes the edges that neighbours own
+	g1.RemoveNode(4)
+	t1.UpdateNode(4)
+	assert.Equal(t, New(g1).Root(), t1.Root())
+	assert.Equal(t, []int64{3, 4}, Diff(t1, t2))
+	_, exists = t1.NodeHash(4)
+	assert.False(t, exists)
+
+	assert.NoError(t, g1.AddNode(nodes.NewSimpleNode(4)))
+	t1.UpdateNode(4)
+	assert.NoError(t, g1.AddEdge(edges.NewUndirectedEdge(3, 4)))
+	t1.UpdateEdge(3, 4)
+	assert.NoError(t, g1.AddEdge(edges.NewUndirectedEdge(4, 100)))
+	t1.UpdateEdge(4, 100)
+	assert.Equal(t, t1.Root(), t2.Root())
+
+	// Defaults change the root but no nodes
+	g1.SetNodeDefaults(map[interface{}]interface{}{"shape": "box"})
+	t1.UpdateDefaults()
+	assert.NotEqual(t, t1.Root(), t2.Root())
+	assert.NotEqual(t, t1.DefaultsHash(), t2.DefaultsHash())
+	assert.Equal(t, []int64{}, Diff(t1, t2))
+}
+
+func TestWatch(t *testing.T) {
+	g1 := undirected(t, []int64{1, 2, 3, 4}, [][2]int64{{1, 2}, {2, 3}, {3, 4}})
+	g2 := undirected(t, []int64{1, 2, 3, 4}, [][2]int64{{1, 2}, {2, 3}, {3, 4}})
+	t1, t2 := New(g1), New(g2)
+
+	// Changes to the graph and its attributes are followed without updates
+	g1.Node(2).SetAttribute("colour", "red")
+	assert.Equal(t, []int64{2}, Diff(t1, t2))
+	g1.Edge(3, 2).SetAttribute("weight", 2)
+	assert.Equal(t, New(g1).Root(), t1.Root())
+	assert.NoError(t, g1.AddNode(nodes.NewSimpleNode(5)))
+	assert.NoError(t, g1.AddEdge(edges.NewUndirectedEdge(4, 5)))
+	assert.Equal(t, New(g1).Root(), t1.Root())
+	assert.Equal(t, []int64{2, 4, 5}, Diff(t1, t2))
+
+	// Removed nodes and edges are no longer observed
+	edge := g1.Edge(4, 5)
+	g1.RemoveNode(5)
+	edge.SetAttribute("weight", 5)
+	assert.Equal(t, New(g1).Root(), t1.Root())
+	assert.Equal(t, []int64{2}, Diff(t1, t2))
+
+	g1.SetGraphDefaults(map[interface{}]interface{}{"rankdir": "LR"})
+	assert.Equal(t, New(g1).Root(), t1.Root())
+
+	// Closed trees no longer follow the graph
+	root := t1.Root()
+	t1.Close()
+	g1.Node(2).SetAttribute("colour", "blue")
+	g1.RemoveNode(4)
+	assert.Equal(t, root, t1.Root())
+	t1.Rebuild()
+	assert.Equal(t, New(g1).Root(), t1.Root())
+}
+
+func TestChildren(t *testing.T) {
+	g := undirected(t, []int64{1, 2, 0x100}, nil)
+	tree := New(g)
+	assert.Equal(t, "8000000000000001", Path(1))
+	assert.Equal(t, "7fffffffffffffff", Path(-1))
+	assert.Equal(t, "", tree.Subtree("0"))
+
+	children := tree.Children("")
+	assert.Len(t, children, 1)
+	assert.Equal(t, tree.Subtree("8"), children["8"])
+
+	children = tree.Children("8000000000000")
+	assert.Len(t, children, 2)
+	assert.Equal(t, tree.Subtree(Path(0x100)), children["80000000000001"])
+	assert.Len(t, tree.Children(Path(1)), 0)
+}
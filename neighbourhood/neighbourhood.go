@@ -0,0 +1,198 @@
+package neighbourhood
+
+// Copyright © 2018 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/wealdtech/go-graph"
+	"github.com/wealdtech/go-graph/internal/build"
+)
+
+// DistanceAttribute is the node attribute holding a node's hop distance
+// from the centre of an ego graph
+const DistanceAttribute = "distance"
+
+// Direction is the direction in which edges of a directed graph are
+// followed outwards from the centre.  It is ignored for undirected graphs
+type Direction int
+
+const (
+	// Out follows edges from their start to their end
+	Out Direction = iota
+	// In follows edges from their end to their start
+	In
+	// Both follows edges either way
+	Both
+)
+
+// Neighbourhood returns the nodes within a given number of hops of a centre
+// node, grouped into rings by their distance.  The first ring holds only
+// the centre and each ring is sorted by node ID.  Rings stop at the radius
+// or at the last ring that is not empty
+func Neighbourhood(g graph.Graph, centre int64, radius int, direction Direction) ([][]graph.Node, error) {
+	distances, err := Distances(g, centre, radius, direction)
+	if err != nil {
+		return nil, err
+	}
+	rings := make([][]graph.Node, 0)
+	for _, nid := range sortedIDs(distances) {
+		distance := distances[nid]
+		for len(rings) <= distance {
+			rings = append(rings, make([]graph.Node, 0))
+		}
+		rings[distance] = append(rings[distance], g.Node(nid))
+	}
+	return rings, nil
+}
+
+// Distances returns the hop distance from a centre node of each node within
+// a given radius of it
+func Distances(g graph.Graph, centre int64, radius int, direction Direction) (map[int64]int, error) {
+	if !g.HasNode(centre) {
+		return nil, fmt.Errorf("Unknown node %v", centre)
+	}
+	if radius < 0 {
+		return nil, fmt.Errorf("Radius must not be negative")
+	}
+	steps := newStepper(g, direction)
+	distances := map[int64]int{centre: 0}
+	queue := []int64{centre}
+	for len(queue) > 0 {
+		nid := queue[0]
+		queue = queue[1:]
+		if distances[nid] == radius {
+			continue
+		}
+		for _, next := range steps.from(nid) {
+			if _, visited := distances[next]; !visited {
+				distances[next] = distances[nid] + 1
+				queue = append(queue, next)
+			}
+		}
+	}
+	return distances, nil
+}
+
+// EgoGraph returns the subgraph induced by the nodes within a given number
+// of hops of a centre node.  It is a copy, with the graph's defaults and
+// the nodes' and edges' attributes; each node also has its distance from
+// the centre in DistanceAttribute, which overwrites any attribute of the
+// same name.  All edges between the chosen nodes are kept, whichever
+// direction was followed to reach them
+func EgoGraph(g graph.Graph, centre int64, radius int, direction Direction) (graph.Graph, error) {
+	distances, err := Distances(g, centre, radius, direction)
+	if err != nil {
+		return nil, err
+	}
+	res := build.New(graph.IsDirected(g))
+	build.CopyDefaults(res, g)
+	ids := sortedIDs(distances)
+	for _, nid := range ids {
+		attrs := build.CopyAttributes(g.Node(nid).Attributes())
+		attrs[DistanceAttribute] = distances[nid]
+		if err := build.AddNode(res, nid, attrs); err != nil {
+			return nil, err
+		}
+	}
+	for _, nid := range ids {
+		for _, edge := range build.OwnedEdges(g, nid) {
+			if _, included := distances[edge.To()]; included {
+				if err := build.AddEdge(res, edge.From(), edge.To(), build.CopyAttributes(edge.Attributes())); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	return res, nil
+}
+
+// incoming is implemented by graphs that track the edges arriving at a node
+type incoming interface {
+	IncomingEdges(nid int64) []graph.Edge
+}
+
+// stepper finds the nodes one hop from a node, following edges in a
+// direction
+type stepper struct {
+	g         graph.Graph
+	directed  bool
+	direction Direction
+	// sources holds the starts of the edges arriving at each node, built
+	// when first needed for graphs that do not track incoming edges
+	sources map[int64][]int64
+}
+
+func newStepper(g graph.Graph, direction Direction) *stepper {
+	return &stepper{g: g, directed: graph.IsDirected(g), direction: direction}
+}
+
+// from returns the sorted nodes one hop from a node
+func (s *stepper) from(nid int64) []int64 {
+	res := make([]int64, 0)
+	if !s.directed {
+		for _, edge := range s.g.Edges(nid) {
+			if edge.From() == nid {
+				res = append(res, edge.To())
+			} else {
+				res = append(res, edge.From())
+			}
+		}
+	} else {
+		if s.direction != In {
+			for _, edge := range s.g.Edges(nid) {
+				if edge.From() == nid {
+					res = append(res, edge.To())
+				}
+			}
+		}
+		if s.direction != Out {
+			res = append(res, s.sourcesOf(nid)...)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i] < res[j] })
+	return res
+}
+
+// sourcesOf returns the starts of the edges arriving at a node
+func (s *stepper) sourcesOf(nid int64) []int64 {
+	if g, ok := s.g.(incoming); ok {
+		res := make([]int64, 0)
+		for _, edge := range g.IncomingEdges(nid) {
+			res = append(res, edge.From())
+		}
+		return res
+	}
+	if s.sources == nil {
+		s.sources = make(map[int64][]int64)
+		for _, node := range s.g.Nodes() {
+			for _, edge := range s.g.Edges(node.Id()) {
+				if edge.From() == node.Id() {
+					s.sources[edge.To()] = append(s.sources[edge.To()], edge.From())
+				}
+			}
+		}
+	}
+	return s.sources[nid]
+}
+
+func sortedIDs(distances map[int64]int) []int64 {
+	res := make([]int64, 0, len(distances))
+	for nid := range distances {
+		res = append(res, nid)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i] < res[j] })
+	return res
+}
@@ -0,0 +1,133 @@
+package neighbourhood
+
+// Copyright © 2018 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wealdtech/go-graph"
+	"github.com/wealdtech/go-graph/edges"
+	"github.com/wealdtech/go-graph/exporters/dot"
+	"github.com/wealdtech/go-graph/graphs"
+	"github.com/wealdtech/go-graph/nodes"
+)
+
+func ringIDs(rings [][]graph.Node) [][]int64 {
+	res := make([][]int64, len(rings))
+	for i, ring := range rings {
+		res[i] = make([]int64, len(ring))
+		for j, node := range ring {
+			res[i][j] = node.Id()
+		}
+	}
+	return res
+}
+
+func directed(t *testing.T) *graphs.DirectedGraph {
+	// 1 -> 2 -> 3 -> 4, 5 -> 1, 6 -> 5, 2 -> 6
+	g := graphs.NewDirectedGraph()
+	for i := int64(1); i <= 6; i++ {
+		assert.NoError(t, g.AddNode(nodes.NewSimpleNode(i)))
+	}
+	for _, link := range [][2]int64{{1, 2}, {2, 3}, {3, 4}, {5, 1}, {6, 5}, {2, 6}} {
+		assert.NoError(t, g.AddEdge(edges.NewDirectedEdge(link[0], link[1])))
+	}
+	return g
+}
+
+// untracked hides a directed graph's incoming edges
+type untracked struct {
+	graph.Graph
+}
+
+func (g untracked) IsDirected() bool {
+	return true
+}
+
+func TestNeighbourhood(t *testing.T) {
+	g := directed(t)
+
+	rings, err := Neighbourhood(g, 1, 2, Out)
+	assert.Nil(t, err)
+	assert.Equal(t, [][]int64{{1}, {2}, {3, 6}}, ringIDs(rings))
+
+	rings, err = Neighbourhood(g, 1, 10, In)
+	assert.Nil(t, err)
+	assert.Equal(t, [][]int64{{1}, {5}, {6}, {2}}, ringIDs(rings))
+	rings, err = Neighbourhood(untracked{g}, 1, 10, In)
+	assert.Nil(t, err)
+	assert.Equal(t, [][]int64{{1}, {5}, {6}, {2}}, ringIDs(rings))
+
+	rings, err = Neighbourhood(g, 1, 1, Both)
+	assert.Nil(t, err)
+	assert.Equal(t, [][]int64{{1}, {2, 5}}, ringIDs(rings))
+
+	rings, err = Neighbourhood(g, 4, 3, Out)
+	assert.Nil(t, err)
+	assert.Equal(t, [][]int64{{4}}, ringIDs(rings))
+
+	_, err = Neighbourhood(g, 7, 1, Out)
+	assert.EqualError(t, err, "Unknown node 7")
+	_, err = Neighbourhood(g, 1, -1, Out)
+	assert.EqualError(t, err, "Radius must not be negative")
+}
+
+func TestNeighbourhoodUndirected(t *testing.T) {
+	g := graphs.NewUndirectedGraph()
+	for i := int64(1); i <= 5; i++ {
+		assert.NoError(t, g.AddNode(nodes.NewSimpleNode(i)))
+	}
+	for _, link := range [][2]int64{{1, 2}, {2, 3}, {1, 3}, {3, 4}} {
+		assert.NoError(t, g.AddEdge(edges.NewUndirectedEdge(link[0], link[1])))
+	}
+	// Direction is ignored for undirected graphs
+	rings, err := Neighbourhood(g, 4, 5, In)
+	assert.Nil(t, err)
+	assert.Equal(t, [][]int64{{4}, {3}, {1, 2}}, ringIDs(rings))
+
+	distances, err := Distances(g, 1, 1, Out)
+	assert.Nil(t, err)
+	assert.Equal(t, map[int64]int{1: 0, 2: 1, 3: 1}, distances)
+}
+
+func TestEgoGraph(t *testing.T) {
+	g := directed(t)
+	g.Node(2).SetAttribute("colour", "red")
+	g.Edge(2, 3).SetAttribute("weight", 2)
+
+	ego, err := EgoGraph(g, 2, 1, Out)
+	assert.Nil(t, err)
+	assert.Equal(t, `digraph g {
+  2 [ colour="red" distance="0" ];
+  2 -> 3 [ weight="2" ];
+  2 -> 6;
+  3 [ distance="1" ];
+  6 [ distance="1" ];
+}`, string(dot.Marshal(ego)))
+
+	// Edges between chosen nodes are kept whichever way they point
+	ego, err = EgoGraph(g, 5, 1, Both)
+	assert.Nil(t, err)
+	assert.Equal(t, `digraph g {
+  1 [ distance="1" ];
+  5 [ distance="0" ];
+  5 -> 1;
+  6 [ distance="1" ];
+  6 -> 5;
+}`, string(dot.Marshal(ego)))
+
+	// The original graph is untouched
+	assert.Nil(t, g.Node(2).Attribute(DistanceAttribute))
+}
@@ -13,9 +13,14 @@ package nodes
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
+import (
+	"github.com/wealdtech/go-graph"
+)
+
 type SimpleNode struct {
-	id    int64
-	attrs map[interface{}]interface{}
+	id        int64
+	attrs     map[interface{}]interface{}
+	observers graph.Observers
 }
 
 func NewSimpleNode(nid int64) *SimpleNode {
@@ -38,9 +43,18 @@ func (n *SimpleNode) Attribute(key interface{}) interface{} {
 }
 
 func (n *SimpleNode) SetAttributes(attrs map[interface{}]interface{}) {
+	old := n.attrs
 	n.attrs = attrs
+	n.observers.NotifyAll(old, attrs)
 }
 
 func (n *SimpleNode) SetAttribute(key, value interface{}) {
+	old := n.attrs[key]
 	n.attrs[key] = value
+	n.observers.Notify(key, old, value)
+}
+
+// Observe adds an observer of changes to the node's attributes
+func (n *SimpleNode) Observe(observer graph.AttributeObserver) func() {
+	return n.observers.Observe(observer)
 }
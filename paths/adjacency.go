@@ -0,0 +1,141 @@
+package paths
+
+// Copyright © 2018 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"container/heap"
+	"fmt"
+	"sort"
+
+	"github.com/wealdtech/go-graph"
+	"github.com/wealdtech/go-graph/internal/adjacency"
+	"github.com/wealdtech/go-graph/internal/queue"
+)
+
+// arcLists hold the arcs leaving each node, sorted by destination.
+// Undirected edges give an arc in each direction.  Self-loops are dropped
+type arcLists struct {
+	ids   []int64
+	index map[int64]int
+	arcs  [][]arc
+}
+
+type arc struct {
+	to     int
+	edge   graph.Edge
+	weight float64
+}
+
+func newArcLists(g graph.Graph, weight graph.WeightFunc) (*arcLists, error) {
+	if weight == nil {
+		weight = graph.UnitWeight
+	}
+	directed := graph.IsDirected(g)
+	a := &arcLists{}
+	a.ids, a.index = adjacency.Number(g)
+	a.arcs = make([][]arc, len(a.ids))
+	for v, nid := range a.ids {
+		for _, edge := range g.Edges(nid) {
+			other := edge.To()
+			if edge.From() != nid {
+				if directed {
+					continue
+				}
+				other = edge.From()
+			}
+			w, exists := a.index[other]
+			if !exists || w == v {
+				continue
+			}
+			cost := weight(edge)
+			if cost < 0 {
+				return nil, fmt.Errorf("Edge from %v to %v has negative weight", edge.From(), edge.To())
+			}
+			a.arcs[v] = append(a.arcs[v], arc{to: w, edge: edge, weight: cost})
+		}
+		list := a.arcs[v]
+		sort.Slice(list, func(i, j int) bool { return list[i].to < list[j].to })
+	}
+	return a, nil
+}
+
+// lookup returns the indices of two nodes
+func (a *arcLists) lookup(from, to int64) (int, int, error) {
+	s, exists := a.index[from]
+	if !exists {
+		return 0, 0, fmt.Errorf("Unknown node %v", from)
+	}
+	t, exists := a.index[to]
+	if !exists {
+		return 0, 0, fmt.Errorf("Unknown node %v", to)
+	}
+	return s, t, nil
+}
+
+// nodeIDs converts a sequence of indices to node IDs
+func (a *arcLists) nodeIDs(seq []int) []int64 {
+	res := make([]int64, len(seq))
+	for i, v := range seq {
+		res[i] = a.ids[v]
+	}
+	return res
+}
+
+// shortestPath finds a shortest path between two nodes with Dijkstra's
+// algorithm, avoiding blocked nodes and arcs.  It returns the arcs taken
+// and the total cost, or nil if the target cannot be reached
+func (a *arcLists) shortestPath(source, target int, blockedNodes []bool, blockedArcs map[[2]int]bool) ([]arc, float64) {
+	distance := make([]float64, len(a.ids))
+	via := make([]int, len(a.ids))
+	prev := make([]int, len(a.ids))
+	reached := make([]bool, len(a.ids))
+	done := make([]bool, len(a.ids))
+	reached[source] = true
+	pq := &queue.Priority{{ID: int64(source)}}
+	for pq.Len() > 0 {
+		item := heap.Pop(pq).(queue.Item)
+		v := int(item.ID)
+		if done[v] {
+			continue
+		}
+		done[v] = true
+		if v == target {
+			break
+		}
+		for i, arc := range a.arcs[v] {
+			if blockedNodes[arc.to] || blockedArcs[[2]int{v, arc.to}] {
+				continue
+			}
+			d := item.Distance + arc.weight
+			if !reached[arc.to] || d < distance[arc.to] {
+				reached[arc.to] = true
+				distance[arc.to] = d
+				via[arc.to] = i
+				prev[arc.to] = v
+				heap.Push(pq, queue.Item{ID: int64(arc.to), Distance: d})
+			}
+		}
+	}
+	if !done[target] {
+		return nil, 0
+	}
+	res := make([]arc, 0)
+	for v := target; v != source; v = prev[v] {
+		res = append(res, a.arcs[prev[v]][via[v]])
+	}
+	for i, j := 0, len(res)-1; i < j; i, j = i+1, j-1 {
+		res[i], res[j] = res[j], res[i]
+	}
+	return res, distance[target]
+}
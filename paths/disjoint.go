@@ -0,0 +1,182 @@
+package paths
+
+// Copyright © 2018 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/wealdtech/go-graph"
+	"github.com/wealdtech/go-graph/internal/flow"
+)
+
+// EdgeDisjointPaths returns a largest set of paths between two nodes that
+// share no edges, found as a maximum flow with unit edge capacities.  Each
+// path is given as node IDs; paths are ordered by length and then by their
+// node IDs
+func EdgeDisjointPaths(g graph.Graph, from, to int64) ([][]int64, error) {
+	a, err := newArcLists(g, graph.UnitWeight)
+	if err != nil {
+		return nil, err
+	}
+	source, target, err := a.lookup(from, to)
+	if err != nil {
+		return nil, err
+	}
+	if source == target {
+		return nil, fmt.Errorf("Start and finish nodes are the same")
+	}
+
+	f := flow.New(len(a.ids))
+	directed := graph.IsDirected(g)
+	for v := range a.arcs {
+		for _, arc := range a.arcs[v] {
+			// Undirected edges appear once from each end; add one pair of
+			// opposing arcs for each
+			if !directed && arc.to < v {
+				continue
+			}
+			f.AddArc(v, arc.to, 1, 0)
+			if !directed {
+				f.AddArc(arc.to, v, 1, 0)
+			}
+		}
+	}
+	f.MaxFlow(source, target)
+	if !directed {
+		cancelOpposingFlows(f)
+	}
+	res := make([][]int64, 0)
+	for _, seq := range decompose(f, source, target) {
+		res = append(res, a.nodeIDs(seq))
+	}
+	sortPaths(res)
+	return res, nil
+}
+
+// NodeDisjointPaths returns a largest set of paths between two nodes that
+// share no nodes other than their ends, found as a maximum flow with unit
+// node capacities.  Each path is given as node IDs; paths are ordered by
+// length and then by their node IDs
+func NodeDisjointPaths(g graph.Graph, from, to int64) ([][]int64, error) {
+	a, err := newArcLists(g, graph.UnitWeight)
+	if err != nil {
+		return nil, err
+	}
+	source, target, err := a.lookup(from, to)
+	if err != nil {
+		return nil, err
+	}
+	if source == target {
+		return nil, fmt.Errorf("Start and finish nodes are the same")
+	}
+
+	// Each node v becomes an entry 2v and an exit 2v+1 joined by an arc of
+	// unit capacity, so that only one path can pass through it
+	n := len(a.ids)
+	f := flow.New(2 * n)
+	for v := 0; v < n; v++ {
+		capacity := 1
+		if v == source || v == target {
+			capacity = n
+		}
+		f.AddArc(2*v, 2*v+1, capacity, 0)
+	}
+	for v := range a.arcs {
+		for _, arc := range a.arcs[v] {
+			f.AddArc(2*v+1, 2*arc.to, 1, 0)
+		}
+	}
+	f.MaxFlow(2*source+1, 2*target)
+	res := make([][]int64, 0)
+	for _, seq := range decompose(f, 2*source+1, 2*target) {
+		// Keep one index for each split node
+		ids := make([]int64, 0, len(seq)/2+1)
+		for i, v := range seq {
+			if i == 0 || v%2 == 0 {
+				ids = append(ids, a.ids[v/2])
+			}
+		}
+		res = append(res, ids)
+	}
+	sortPaths(res)
+	return res, nil
+}
+
+func sortPaths(paths [][]int64) {
+	sort.Slice(paths, func(i, j int) bool {
+		a, b := paths[i], paths[j]
+		if len(a) != len(b) {
+			return len(a) < len(b)
+		}
+		for k := range a {
+			if a[k] != b[k] {
+				return a[k] < b[k]
+			}
+		}
+		return false
+	})
+}
+
+// cancelOpposingFlows removes flow that runs both ways along an undirected
+// edge, which is represented by arcs 4k and 4k+2
+func cancelOpposingFlows(f *flow.Network) {
+	for i := 0; i+2 < len(f.Arcs); i += 4 {
+		forward, backward := f.Arcs[i].Flow, f.Arcs[i+2].Flow
+		if forward > 0 && backward > 0 {
+			cancelled := forward
+			if backward < cancelled {
+				cancelled = backward
+			}
+			f.Arcs[i].Flow -= cancelled
+			f.Arcs[i+1].Flow += cancelled
+			f.Arcs[i+2].Flow -= cancelled
+			f.Arcs[i+3].Flow += cancelled
+		}
+	}
+}
+
+// decompose splits the flow into paths from source to sink, consuming it.
+// Any cycles met along the way are dropped
+func decompose(f *flow.Network, source, sink int) [][]int {
+	res := make([][]int, 0)
+	for {
+		path := []int{source}
+		position := map[int]int{source: 0}
+		for v := source; v != sink; {
+			next := -1
+			for _, i := range f.Adj[v] {
+				if i%2 == 0 && f.Arcs[i].Flow > 0 {
+					f.Arcs[i].Flow--
+					next = f.Arcs[i].To
+					break
+				}
+			}
+			if next < 0 {
+				return res
+			}
+			if p, seen := position[next]; seen {
+				for _, w := range path[p+1:] {
+					delete(position, w)
+				}
+				path = path[:p+1]
+			} else {
+				position[next] = len(path)
+				path = append(path, next)
+			}
+			v = next
+		}
+		res = append(res, path)
+	}
+}
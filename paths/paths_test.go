@@ -0,0 +1,193 @@
+package paths
+
+// Copyright © 2018 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wealdtech/go-graph"
+	"github.com/wealdtech/go-graph/edges"
+	"github.com/wealdtech/go-graph/graphs"
+	"github.com/wealdtech/go-graph/nodes"
+)
+
+var weight = graph.AttributeWeight("weight")
+
+// network creates the directed graph used by Yen's original example, with
+// nodes C=1, D=2, E=3, F=4, G=5 and H=6
+func network(t *testing.T) *graphs.DirectedGraph {
+	g := graphs.NewDirectedGraph()
+	for i := int64(1); i <= 6; i++ {
+		assert.NoError(t, g.AddNode(nodes.NewSimpleNode(i)))
+	}
+	for _, arc := range [][3]int64{{1, 2, 3}, {1, 3, 2}, {2, 4, 4}, {3, 2, 1}, {3, 4, 2}, {3, 5, 3}, {4, 5, 2}, {4, 6, 1}, {5, 6, 2}} {
+		edge := edges.NewDirectedEdge(arc[0], arc[1])
+		edge.SetAttribute("weight", arc[2])
+		assert.NoError(t, g.AddEdge(edge))
+	}
+	return g
+}
+
+func TestKShortestPaths(t *testing.T) {
+	g := network(t)
+	paths, err := KShortestPaths(context.Background(), g, weight, 1, 6, 3)
+	assert.NoError(t, err)
+	assert.Len(t, paths, 3)
+	assert.Equal(t, []int64{1, 3, 4, 6}, paths[0].Nodes)
+	assert.Equal(t, 5.0, paths[0].Cost)
+	assert.Equal(t, []int64{1, 3, 5, 6}, paths[1].Nodes)
+	assert.Equal(t, 7.0, paths[1].Cost)
+	assert.Equal(t, []int64{1, 2, 4, 6}, paths[2].Nodes)
+	assert.Equal(t, 8.0, paths[2].Cost)
+	assert.Equal(t, g.Edge(3, 4), paths[0].Edges[1])
+
+	// Exhausting the paths
+	all, err := KShortestPaths(context.Background(), g, weight, 1, 6, 100)
+	assert.NoError(t, err)
+	assert.Len(t, all, 7)
+	for i := 1; i < len(all); i++ {
+		assert.True(t, all[i-1].Cost <= all[i].Cost)
+	}
+
+	_, err = KShortestPaths(context.Background(), g, weight, 1, 7, 3)
+	assert.Error(t, err)
+	none, err := KShortestPaths(context.Background(), g, weight, 6, 1, 3)
+	assert.NoError(t, err)
+	assert.Len(t, none, 0)
+
+	negative := edges.NewDirectedEdge(6, 1)
+	negative.SetAttribute("weight", -1)
+	assert.NoError(t, g.AddEdge(negative))
+	_, err = KShortestPaths(context.Background(), g, weight, 1, 6, 3)
+	assert.Error(t, err)
+}
+
+func TestShortestPathsChannel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch := ShortestPathsChannel(ctx, network(t), nil, 1, 6)
+	first := <-ch
+	assert.Equal(t, []int64{1, 2, 4, 6}, first.Nodes)
+	assert.Equal(t, 3.0, first.Cost)
+	cancel()
+	for range ch {
+	}
+}
+
+func TestUndirectedShortestPaths(t *testing.T) {
+	// A square 1-2-3-4 with a diagonal 1-3
+	g := graphs.NewUndirectedGraph()
+	for i := int64(1); i <= 4; i++ {
+		assert.NoError(t, g.AddNode(nodes.NewSimpleNode(i)))
+	}
+	for _, pair := range [][2]int64{{1, 2}, {2, 3}, {3, 4}, {4, 1}, {1, 3}} {
+		assert.NoError(t, g.AddEdge(edges.NewUndirectedEdge(pair[0], pair[1])))
+	}
+	paths, err := KShortestPaths(context.Background(), g, nil, 2, 4, 10)
+	assert.NoError(t, err)
+	actual := make([][]int64, len(paths))
+	for i := range paths {
+		actual[i] = paths[i].Nodes
+	}
+	assert.Equal(t, [][]int64{{2, 1, 4}, {2, 3, 4}, {2, 1, 3, 4}, {2, 3, 1, 4}}, actual)
+}
+
+func TestSimplePaths(t *testing.T) {
+	g := network(t)
+	found := make([][]int64, 0)
+	err := SimplePaths(context.Background(), g, 1, 6, 0, func(path []int64) bool {
+		found = append(found, path)
+		return true
+	})
+	assert.NoError(t, err)
+	assert.Len(t, found, 7)
+	assert.Equal(t, []int64{1, 2, 4, 5, 6}, found[0])
+
+	found = found[:0]
+	err = SimplePaths(context.Background(), g, 1, 6, 3, func(path []int64) bool {
+		found = append(found, path)
+		return true
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, [][]int64{{1, 2, 4, 6}, {1, 3, 4, 6}, {1, 3, 5, 6}}, found)
+
+	// Stopping early
+	count := 0
+	err = SimplePaths(context.Background(), g, 1, 6, 0, func(path []int64) bool {
+		count++
+		return count < 2
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	// Cancelled
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = SimplePaths(ctx, g, 1, 6, 0, func(path []int64) bool { return true })
+	assert.Equal(t, context.Canceled, err)
+
+	count = 0
+	for range SimplePathsChannel(context.Background(), g, 1, 6, 0) {
+		count++
+	}
+	assert.Equal(t, 7, count)
+}
+
+func TestDisjointPaths(t *testing.T) {
+	// Two routes from 1 to 6 that share node 4, plus a third through 5
+	g := graphs.NewDirectedGraph()
+	for i := int64(1); i <= 6; i++ {
+		assert.NoError(t, g.AddNode(nodes.NewSimpleNode(i)))
+	}
+	for _, pair := range [][2]int64{{1, 2}, {1, 3}, {2, 4}, {3, 4}, {4, 6}, {4, 5}, {5, 6}, {1, 5}} {
+		assert.NoError(t, g.AddEdge(edges.NewDirectedEdge(pair[0], pair[1])))
+	}
+	edgeDisjoint, err := EdgeDisjointPaths(g, 1, 6)
+	assert.NoError(t, err)
+	assert.Len(t, edgeDisjoint, 2)
+	used := make(map[[2]int64]bool)
+	for _, path := range edgeDisjoint {
+		assert.Equal(t, int64(1), path[0])
+		assert.Equal(t, int64(6), path[len(path)-1])
+		for i := 0; i < len(path)-1; i++ {
+			assert.True(t, g.HasEdge(path[i], path[i+1]))
+			assert.False(t, used[[2]int64{path[i], path[i+1]}])
+			used[[2]int64{path[i], path[i+1]}] = true
+		}
+	}
+
+	nodeDisjoint, err := NodeDisjointPaths(g, 1, 6)
+	assert.NoError(t, err)
+	assert.Equal(t, [][]int64{{1, 5, 6}, {1, 2, 4, 6}}, nodeDisjoint)
+
+	_, err = NodeDisjointPaths(g, 1, 1)
+	assert.Error(t, err)
+
+	// Undirected: a cycle gives two paths each way round
+	u := graphs.NewUndirectedGraph()
+	for i := int64(1); i <= 4; i++ {
+		assert.NoError(t, u.AddNode(nodes.NewSimpleNode(i)))
+	}
+	for _, pair := range [][2]int64{{1, 2}, {2, 3}, {3, 4}, {4, 1}} {
+		assert.NoError(t, u.AddEdge(edges.NewUndirectedEdge(pair[0], pair[1])))
+	}
+	cyclePaths, err := EdgeDisjointPaths(u, 1, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, [][]int64{{1, 2, 3}, {1, 4, 3}}, cyclePaths)
+	cyclePaths, err = NodeDisjointPaths(u, 2, 4)
+	assert.NoError(t, err)
+	assert.Equal(t, [][]int64{{2, 1, 4}, {2, 3, 4}}, cyclePaths)
+}
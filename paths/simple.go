@@ -0,0 +1,99 @@
+package paths
+
+// Copyright © 2018 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"context"
+
+	"github.com/wealdtech/go-graph"
+)
+
+// SimplePaths calls fn with the node IDs of each simple path between two
+// nodes with at most maxLength edges; a maxLength of 0 or less means no
+// limit.  Paths are found depth-first with neighbours in ascending order.
+// Enumeration stops if fn returns false, or with the context's error if it
+// is cancelled
+func SimplePaths(ctx context.Context, g graph.Graph, from, to int64, maxLength int, fn func(path []int64) bool) error {
+	a, err := newArcLists(g, graph.UnitWeight)
+	if err != nil {
+		return err
+	}
+	source, target, err := a.lookup(from, to)
+	if err != nil {
+		return err
+	}
+	if source == target {
+		return nil
+	}
+	if maxLength <= 0 {
+		maxLength = len(a.ids)
+	}
+
+	onPath := make([]bool, len(a.ids))
+	onPath[source] = true
+	path := []int{source}
+	next := []int{0}
+	for steps := 0; len(path) > 0; steps++ {
+		if steps%1024 == 0 {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+		}
+		top := len(path) - 1
+		v := path[top]
+		if next[top] >= len(a.arcs[v]) || top >= maxLength {
+			onPath[v] = false
+			path = path[:top]
+			next = next[:top]
+			continue
+		}
+		w := a.arcs[v][next[top]].to
+		next[top]++
+		if onPath[w] {
+			continue
+		}
+		if w == target {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if !fn(a.nodeIDs(append(path, w))) {
+				return nil
+			}
+			continue
+		}
+		onPath[w] = true
+		path = append(path, w)
+		next = append(next, 0)
+	}
+	return nil
+}
+
+// SimplePathsChannel streams the simple paths between two nodes over a
+// channel, which is closed when enumeration finishes.  Cancel the context
+// to stop enumeration early.  Errors, such as unknown nodes, give no paths
+func SimplePathsChannel(ctx context.Context, g graph.Graph, from, to int64, maxLength int) <-chan []int64 {
+	ch := make(chan []int64)
+	go func() {
+		defer close(ch)
+		SimplePaths(ctx, g, from, to, maxLength, func(path []int64) bool {
+			select {
+			case ch <- path:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		})
+	}()
+	return ch
+}
@@ -0,0 +1,211 @@
+package paths
+
+// Copyright © 2018 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"container/heap"
+	"context"
+	"fmt"
+
+	"github.com/wealdtech/go-graph"
+)
+
+// Path is a path through a graph
+type Path struct {
+	// Nodes are the IDs of the nodes on the path, from start to finish
+	Nodes []int64
+	// Edges are the edges between consecutive nodes
+	Edges []graph.Edge
+	// Cost is the total weight of the edges
+	Cost float64
+}
+
+// candidate is a path found by Yen's algorithm that has not yet been
+// reported
+type candidate struct {
+	nodes []int
+	arcs  []arc
+	cost  float64
+}
+
+// ShortestPaths calls fn with the loopless paths between two nodes in
+// order of increasing cost, using Yen's algorithm.  Ties between paths
+// of equal cost are broken deterministically.  A nil weight gives every
+// edge a weight of 1; negative weights are not allowed.  Enumeration stops
+// when there are no more paths, if fn returns false, or with the
+// context's error if it is cancelled
+func ShortestPaths(ctx context.Context, g graph.Graph, weight graph.WeightFunc, from, to int64, fn func(path *Path) bool) error {
+	a, err := newArcLists(g, weight)
+	if err != nil {
+		return err
+	}
+	source, target, err := a.lookup(from, to)
+	if err != nil {
+		return err
+	}
+	if source == target {
+		return fmt.Errorf("Start and finish nodes are the same")
+	}
+
+	blockedNodes := make([]bool, len(a.ids))
+	arcs, cost := a.shortestPath(source, target, blockedNodes, nil)
+	if arcs == nil {
+		return nil
+	}
+	found := []*candidate{newCandidate(source, arcs, cost)}
+	seen := map[string]bool{fmt.Sprint(found[0].nodes): true}
+	candidates := &candidateQueue{}
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		latest := found[len(found)-1]
+		if !fn(a.path(latest)) {
+			return nil
+		}
+
+		// Deviate from the latest path at each of its nodes in turn
+		for i := 0; i < len(latest.nodes)-1; i++ {
+			spur := latest.nodes[i]
+			root := latest.nodes[:i+1]
+			blockedArcs := make(map[[2]int]bool)
+			for _, p := range found {
+				if len(p.nodes) > i+1 && equal(p.nodes[:i+1], root) {
+					blockedArcs[[2]int{spur, p.nodes[i+1]}] = true
+				}
+			}
+			for _, v := range root[:i] {
+				blockedNodes[v] = true
+			}
+			arcs, cost := a.shortestPath(spur, target, blockedNodes, blockedArcs)
+			for _, v := range root[:i] {
+				blockedNodes[v] = false
+			}
+			if arcs == nil {
+				continue
+			}
+			rootCost := 0.0
+			for _, arc := range latest.arcs[:i] {
+				rootCost += arc.weight
+			}
+			c := newCandidate(source, append(append([]arc{}, latest.arcs[:i]...), arcs...), rootCost+cost)
+			key := fmt.Sprint(c.nodes)
+			if !seen[key] {
+				seen[key] = true
+				heap.Push(candidates, c)
+			}
+		}
+
+		if candidates.Len() == 0 {
+			return nil
+		}
+		found = append(found, heap.Pop(candidates).(*candidate))
+	}
+}
+
+// ShortestPathsChannel streams the loopless paths between two nodes in
+// order of increasing cost over a channel, which is closed when
+// enumeration finishes.  Cancel the context to stop enumeration early.
+// Errors, such as unknown nodes, give no paths
+func ShortestPathsChannel(ctx context.Context, g graph.Graph, weight graph.WeightFunc, from, to int64) <-chan *Path {
+	ch := make(chan *Path)
+	go func() {
+		defer close(ch)
+		ShortestPaths(ctx, g, weight, from, to, func(path *Path) bool {
+			select {
+			case ch <- path:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		})
+	}()
+	return ch
+}
+
+// KShortestPaths returns up to k loopless paths between two nodes in order
+// of increasing cost.  See ShortestPaths for details
+func KShortestPaths(ctx context.Context, g graph.Graph, weight graph.WeightFunc, from, to int64, k int) ([]*Path, error) {
+	res := make([]*Path, 0, k)
+	if k <= 0 {
+		return res, nil
+	}
+	err := ShortestPaths(ctx, g, weight, from, to, func(path *Path) bool {
+		res = append(res, path)
+		return len(res) < k
+	})
+	return res, err
+}
+
+func newCandidate(source int, arcs []arc, cost float64) *candidate {
+	nodes := make([]int, 0, len(arcs)+1)
+	nodes = append(nodes, source)
+	for _, arc := range arcs {
+		nodes = append(nodes, arc.to)
+	}
+	return &candidate{
+		nodes: nodes,
+		arcs:  arcs,
+		cost:  cost,
+	}
+}
+
+// path converts a candidate to a path
+func (a *arcLists) path(c *candidate) *Path {
+	res := &Path{
+		Nodes: a.nodeIDs(c.nodes),
+		Edges: make([]graph.Edge, len(c.arcs)),
+		Cost:  c.cost,
+	}
+	for i, arc := range c.arcs {
+		res.Edges[i] = arc.edge
+	}
+	return res
+}
+
+func equal(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+type candidateQueue []*candidate
+
+func (q candidateQueue) Len() int { return len(q) }
+func (q candidateQueue) Less(i, j int) bool {
+	if q[i].cost != q[j].cost {
+		return q[i].cost < q[j].cost
+	}
+	a, b := q[i].nodes, q[j].nodes
+	for k := 0; k < len(a) && k < len(b); k++ {
+		if a[k] != b[k] {
+			return a[k] < b[k]
+		}
+	}
+	return len(a) < len(b)
+}
+func (q candidateQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *candidateQueue) Push(x interface{}) { *q = append(*q, x.(*candidate)) }
+func (q *candidateQueue) Pop() interface{} {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
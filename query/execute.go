@@ -0,0 +1,632 @@
+package query
+
+// Copyright © 2018 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/wealdtech/go-graph"
+)
+
+// Result is a table of the values returned by a query.  Nodes and edges
+// are returned as graph.Node and graph.Edge, paths matched by
+// variable-length relationships as []graph.Edge and collected values as
+// []interface{}
+type Result struct {
+	Columns []string
+	Rows    [][]interface{}
+}
+
+// indexed is implemented by graphs with attribute indexes, such as
+// graphs.DirectedGraph and graphs.UndirectedGraph
+type indexed interface {
+	NodesWhere(key, value interface{}) ([]graph.Node, error)
+	NodesBetween(key, min, max interface{}) ([]graph.Node, error)
+	NodesWithPrefix(key interface{}, prefix string) ([]graph.Node, error)
+}
+
+// Run parses a query and executes it against a graph
+func Run(g graph.Graph, text string) (*Result, error) {
+	q, err := Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	return q.Execute(g)
+}
+
+// Execute runs a query against a graph.  Each pattern is matched from its
+// first node, looking up candidates in the graph's node indexes on string
+// attribute keys where the pattern or the WHERE clause constrains that
+// node's attributes, and otherwise trying every node in order of ID.  An
+// edge is used at most once in each match.  Relationships in undirected
+// graphs match edges whichever way they point
+func (q *Query) Execute(g graph.Graph) (*Result, error) {
+	x := &executor{
+		q:        q,
+		g:        g,
+		directed: graph.IsDirected(g),
+		used:     make(map[[2]int64]bool),
+		rows:     make([]row, 0),
+	}
+	x.matchPatterns(0, row{})
+	if x.err != nil {
+		return nil, x.err
+	}
+	return x.project()
+}
+
+// tracksIncoming is implemented by graphs that track the edges arriving at
+// a node, such as graphs.DirectedGraph
+type tracksIncoming interface {
+	IncomingEdges(nid int64) []graph.Edge
+}
+
+type executor struct {
+	q        *Query
+	g        graph.Graph
+	directed bool
+	// incoming holds the edges arriving at each node of a directed graph
+	// that does not track them, built when first needed
+	incoming map[int64][]graph.Edge
+	used     map[[2]int64]bool
+	rows     []row
+	err      error
+}
+
+// matchPatterns matches the patterns from the i-th on, adding a row for
+// each complete match that passes the WHERE clause
+func (x *executor) matchPatterns(i int, r row) {
+	if x.err != nil {
+		return
+	}
+	if i == len(x.q.patterns) {
+		x.emit(r)
+		return
+	}
+	p := x.q.patterns[i]
+	for _, node := range x.candidates(p.nodes[0], r) {
+		if bound, ok := bindNode(p.nodes[0], node, r); ok {
+			x.matchChain(p, 0, node.Id(), bound, func(r row) { x.matchPatterns(i+1, r) })
+		}
+	}
+}
+
+func (x *executor) emit(r row) {
+	if x.q.where != nil {
+		value, err := x.q.where.eval(r)
+		if err != nil {
+			x.err = err
+			return
+		}
+		if !truthy(value) {
+			return
+		}
+	}
+	x.rows = append(x.rows, r)
+}
+
+// matchChain matches the j-th relationship of a pattern and the node after
+// it, starting from a node, and calls next for each match of the rest of
+// the pattern
+func (x *executor) matchChain(p *pattern, j int, nid int64, r row, next func(row)) {
+	if x.err != nil {
+		return
+	}
+	if j == len(p.rels) {
+		next(r)
+		return
+	}
+	rel := p.rels[j]
+	path := make([]graph.Edge, 0)
+	var walk func(nid int64)
+	walk = func(nid int64) {
+		if len(path) >= rel.min {
+			if bound, ok := bindNode(p.nodes[j+1], x.g.Node(nid), r); ok {
+				if bound, ok := bindRel(rel, path, bound); ok {
+					x.matchChain(p, j+1, nid, bound, next)
+				}
+			}
+		}
+		if len(path) == rel.max {
+			return
+		}
+		for _, step := range x.steps(nid, rel.direction) {
+			ends := [2]int64{step.edge.From(), step.edge.To()}
+			if x.used[ends] || !matches(step.edge, rel.props) {
+				continue
+			}
+			x.used[ends] = true
+			path = append(path, step.edge)
+			walk(step.to)
+			path = path[:len(path)-1]
+			delete(x.used, ends)
+		}
+	}
+	walk(nid)
+}
+
+// step is an edge that can be followed from a node, and the node it leads
+// to
+type step struct {
+	edge graph.Edge
+	to   int64
+}
+
+// steps returns the edges that can be followed from a node in a
+// direction, ordered by their ends
+func (x *executor) steps(nid int64, dir direction) []step {
+	res := make([]step, 0)
+	if !x.directed {
+		for _, edge := range x.g.Edges(nid) {
+			to := edge.To()
+			if to == nid {
+				to = edge.From()
+			}
+			res = append(res, step{edge: edge, to: to})
+		}
+	} else {
+		if dir != incoming {
+			for _, edge := range x.g.Edges(nid) {
+				res = append(res, step{edge: edge, to: edge.To()})
+			}
+		}
+		if dir != outgoing {
+			for _, edge := range x.incomingEdges(nid) {
+				if dir == either && edge.From() == nid {
+					// Self-loops have already been found as outgoing
+					continue
+				}
+				res = append(res, step{edge: edge, to: edge.From()})
+			}
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].edge.From() != res[j].edge.From() {
+			return res[i].edge.From() < res[j].edge.From()
+		}
+		return res[i].edge.To() < res[j].edge.To()
+	})
+	return res
+}
+
+// incomingEdges returns the edges arriving at a node, from the graph where
+// it tracks them and otherwise from a map built by scanning every edge
+func (x *executor) incomingEdges(nid int64) []graph.Edge {
+	if g, ok := x.g.(tracksIncoming); ok {
+		return g.IncomingEdges(nid)
+	}
+	if x.incoming == nil {
+		x.incoming = make(map[int64][]graph.Edge)
+		for _, node := range x.g.Nodes() {
+			for _, edge := range x.g.Edges(node.Id()) {
+				x.incoming[edge.To()] = append(x.incoming[edge.To()], edge)
+			}
+		}
+	}
+	return x.incoming[nid]
+}
+
+// candidates returns the nodes that could match the first node of a
+// pattern
+func (x *executor) candidates(n *nodePattern, r row) []graph.Node {
+	if bound, exists := r[n.name]; n.name != "" && exists {
+		return []graph.Node{bound.(graph.Node)}
+	}
+	if index, ok := x.g.(indexed); ok {
+		var best []graph.Node
+		for _, c := range x.constraints(n) {
+			var nodes []graph.Node
+			var err error
+			if c.prefix != nil {
+				nodes, err = index.NodesWithPrefix(c.key, *c.prefix)
+			} else if _, ok := c.min.(bool); ok {
+				nodes, err = index.NodesWhere(c.key, c.min)
+			} else {
+				nodes, err = index.NodesBetween(c.key, c.min, c.max)
+			}
+			if err == nil && (best == nil || len(nodes) < len(best)) {
+				best = nodes
+			}
+		}
+		if best != nil {
+			return best
+		}
+	}
+	res := x.g.Nodes()
+	sort.Slice(res, func(i, j int) bool { return res[i].Id() < res[j].Id() })
+	return res
+}
+
+// constraint bounds the value of an attribute, inclusively, or requires
+// it to start with a prefix.  A nil bound is open, and a boolean is
+// required to equal min
+type constraint struct {
+	key    string
+	min    interface{}
+	max    interface{}
+	prefix *string
+}
+
+// constraints returns the constraints that a node must meet for a match,
+// from its pattern and the top-level conditions of the WHERE clause
+func (x *executor) constraints(n *nodePattern) []*constraint {
+	res := make([]*constraint, 0)
+	for _, prop := range n.props {
+		if indexable(prop.value) {
+			res = append(res, &constraint{key: prop.key, min: prop.value, max: prop.value})
+		}
+	}
+	if n.name == "" {
+		return res
+	}
+	conditions := []expr{x.q.where}
+	for len(conditions) > 0 {
+		condition := conditions[0]
+		conditions = conditions[1:]
+		switch e := condition.(type) {
+		case *logical:
+			if e.op == "AND" {
+				conditions = append(conditions, e.left, e.right)
+			}
+		case *comparison:
+			if c := constrain(e, n.name); c != nil {
+				res = append(res, c)
+			}
+		}
+	}
+	return res
+}
+
+// constrain returns the constraint imposed by comparing an attribute of a
+// variable with a literal, or nil
+func constrain(e *comparison, name string) *constraint {
+	op := e.op
+	attr, ok := e.left.(*attribute)
+	value, isLiteral := e.right.(*literal)
+	if !ok || !isLiteral {
+		// Try the comparison the other way round
+		attr, ok = e.right.(*attribute)
+		value, isLiteral = e.left.(*literal)
+		if !ok || !isLiteral {
+			return nil
+		}
+		op = strings.NewReplacer("<", ">", ">", "<").Replace(op)
+	}
+	if v, ok := attr.expr.(*variable); !ok || v.name != name || !indexable(value.value) {
+		return nil
+	}
+	if _, ok := value.value.(bool); ok && op != "=" {
+		return nil
+	}
+	switch op {
+	case "=":
+		return &constraint{key: attr.key, min: value.value, max: value.value}
+	case "STARTS WITH":
+		if prefix, ok := value.value.(string); ok && e.left == attr {
+			return &constraint{key: attr.key, prefix: &prefix}
+		}
+	case "<", "<=":
+		return &constraint{key: attr.key, max: value.value}
+	case ">", ">=":
+		return &constraint{key: attr.key, min: value.value}
+	}
+	return nil
+}
+
+// indexable returns true for values that can be looked up in an index
+func indexable(value interface{}) bool {
+	switch value.(type) {
+	case string, bool:
+		return true
+	}
+	return isNumber(value)
+}
+
+// matches returns true if a node or edge has the given attributes
+func matches(item graph.Attributed, props []*prop) bool {
+	for _, prop := range props {
+		value := item.Attribute(prop.key)
+		if value == nil || !equal(value, prop.value) {
+			return false
+		}
+	}
+	return true
+}
+
+// bindNode binds a node to the variable of a node pattern if it matches,
+// returning the new row
+func bindNode(n *nodePattern, node graph.Node, r row) (row, bool) {
+	if !matches(node, n.props) {
+		return nil, false
+	}
+	if n.name == "" {
+		return r, true
+	}
+	if existing, exists := r[n.name]; exists {
+		return r, existing.(graph.Node).Id() == node.Id()
+	}
+	return r.with(n.name, node), true
+}
+
+// bindRel binds the edges of a path to the variable of a relationship
+// pattern, returning the new row
+func bindRel(rel *relPattern, path []graph.Edge, r row) (row, bool) {
+	if rel.name == "" {
+		return r, true
+	}
+	var value interface{}
+	if rel.variable {
+		value = append([]graph.Edge{}, path...)
+	} else {
+		value = path[0]
+	}
+	if existing, exists := r[rel.name]; exists {
+		return r, key(existing) == key(value)
+	}
+	return r.with(rel.name, value), true
+}
+
+// with returns a copy of a row with a variable bound to a value
+func (r row) with(name string, value interface{}) row {
+	res := make(row, len(r)+1)
+	for k, v := range r {
+		res[k] = v
+	}
+	res[name] = value
+	return res
+}
+
+// record is a row of the result, with the row its values are evaluated in
+// for ordering
+type record struct {
+	values []interface{}
+	env    row
+}
+
+// project turns the matched rows into the result, grouping them if any
+// column is an aggregate
+func (x *executor) project() (*Result, error) {
+	records, err := x.records()
+	if err != nil {
+		return nil, err
+	}
+	if len(x.q.order) > 0 {
+		if err := x.sort(records); err != nil {
+			return nil, err
+		}
+	}
+	res := &Result{
+		Columns: make([]string, len(x.q.items)),
+		Rows:    make([][]interface{}, 0),
+	}
+	for i, item := range x.q.items {
+		res.Columns[i] = item.name
+	}
+	for i, record := range records {
+		if int64(i) < x.q.skip {
+			continue
+		}
+		if x.q.limit >= 0 && int64(len(res.Rows)) == x.q.limit {
+			break
+		}
+		res.Rows = append(res.Rows, record.values)
+	}
+	return res, nil
+}
+
+func (x *executor) records() ([]*record, error) {
+	res := make([]*record, 0)
+	if !x.q.aggregated() {
+		for _, r := range x.rows {
+			values, err := x.evalItems(r, false)
+			if err != nil {
+				return nil, err
+			}
+			res = append(res, x.newRecord(values, r))
+		}
+		return res, nil
+	}
+
+	// Group rows by the values of the columns that are not aggregates
+	groups := make(map[string]int)
+	members := make([][]row, 0)
+	for _, r := range x.rows {
+		values, err := x.evalItems(r, true)
+		if err != nil {
+			return nil, err
+		}
+		keys := make([]string, len(values))
+		for i, value := range values {
+			keys[i] = key(value)
+		}
+		group := strings.Join(keys, "\x00")
+		if _, exists := groups[group]; !exists {
+			groups[group] = len(res)
+			res = append(res, &record{values: values})
+			members = append(members, nil)
+		}
+		members[groups[group]] = append(members[groups[group]], r)
+	}
+	if len(res) == 0 && x.onlyAggregates() {
+		// Aggregates over no rows still return a row
+		res = append(res, &record{values: make([]interface{}, len(x.q.items))})
+		members = append(members, nil)
+	}
+	for i, record := range res {
+		for j, item := range x.q.items {
+			if c, ok := item.expr.(*call); ok && aggregates[c.name] {
+				value, err := aggregate(c, members[i])
+				if err != nil {
+					return nil, err
+				}
+				record.values[j] = value
+			}
+		}
+		res[i] = x.newRecord(record.values, row{})
+	}
+	return res, nil
+}
+
+// evalItems evaluates the returned columns for a row, leaving aggregates
+// unset if asked to skip them
+func (x *executor) evalItems(r row, skipAggregates bool) ([]interface{}, error) {
+	values := make([]interface{}, len(x.q.items))
+	for i, item := range x.q.items {
+		if c, ok := item.expr.(*call); ok && skipAggregates && aggregates[c.name] {
+			continue
+		}
+		value, err := item.expr.eval(r)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = value
+	}
+	return values, nil
+}
+
+func (x *executor) onlyAggregates() bool {
+	for _, item := range x.q.items {
+		if c, ok := item.expr.(*call); !ok || !aggregates[c.name] {
+			return false
+		}
+	}
+	return true
+}
+
+// newRecord returns a record whose ordering environment is a row with the
+// returned columns added by name
+func (x *executor) newRecord(values []interface{}, r row) *record {
+	env := make(row, len(r)+len(values))
+	for k, v := range r {
+		env[k] = v
+	}
+	for i, item := range x.q.items {
+		env[item.name] = values[i]
+	}
+	return &record{values: values, env: env}
+}
+
+// sort orders records by the ORDER BY clause.  An ordering that names or
+// repeats a returned column orders by that column
+func (x *executor) sort(records []*record) error {
+	keys := make([][]interface{}, len(records))
+	for i, record := range records {
+		keys[i] = make([]interface{}, len(x.q.order))
+		for j, ordering := range x.q.order {
+			if ordering.column >= 0 {
+				keys[i][j] = record.values[ordering.column]
+				continue
+			}
+			value, err := ordering.expr.eval(record.env)
+			if err != nil {
+				return err
+			}
+			keys[i][j] = value
+		}
+	}
+	indices := make([]int, len(records))
+	for i := range indices {
+		indices[i] = i
+	}
+	sort.SliceStable(indices, func(a, b int) bool {
+		for j, ordering := range x.q.order {
+			res := sortOrder(keys[indices[a]][j], keys[indices[b]][j])
+			if ordering.descending {
+				res = -res
+			}
+			if res != 0 {
+				return res < 0
+			}
+		}
+		return false
+	})
+	sorted := make([]*record, len(records))
+	for i, index := range indices {
+		sorted[i] = records[index]
+	}
+	copy(records, sorted)
+	return nil
+}
+
+// aggregate evaluates an aggregate function over a group of rows.  Nulls
+// are ignored
+func aggregate(c *call, rows []row) (interface{}, error) {
+	if c.star {
+		return int64(len(rows)), nil
+	}
+	values := make([]interface{}, 0)
+	seen := make(map[string]bool)
+	for _, r := range rows {
+		value, err := c.args[0].eval(r)
+		if err != nil {
+			return nil, err
+		}
+		if value == nil {
+			continue
+		}
+		if c.distinct {
+			if seen[key(value)] {
+				continue
+			}
+			seen[key(value)] = true
+		}
+		values = append(values, value)
+	}
+
+	switch c.name {
+	case "count":
+		return int64(len(values)), nil
+	case "collect":
+		return values, nil
+	case "min", "max":
+		var res interface{}
+		for _, value := range values {
+			order := sortOrder(value, res)
+			if res == nil || (c.name == "min" && order < 0) || (c.name == "max" && order > 0) {
+				res = value
+			}
+		}
+		return res, nil
+	}
+
+	// sum and avg
+	intSum := int64(0)
+	floatSum := 0.0
+	integral := true
+	for _, value := range values {
+		if !isNumber(value) {
+			return nil, fmt.Errorf("Cannot %s non-numeric value %v", c.name, value)
+		}
+		v := reflect.ValueOf(value)
+		if isInt(v) && integral {
+			intSum += v.Int()
+		} else {
+			integral = false
+		}
+		floatSum += float(v)
+	}
+	if c.name == "avg" {
+		if len(values) == 0 {
+			return nil, nil
+		}
+		return floatSum / float64(len(values)), nil
+	}
+	if integral {
+		return intSum, nil
+	}
+	return floatSum, nil
+}
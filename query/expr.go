@@ -0,0 +1,374 @@
+package query
+
+// Copyright © 2018 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/wealdtech/go-graph"
+)
+
+// row binds the variables of a match to nodes, edges and paths, and the
+// names of returned columns to their values
+type row map[string]interface{}
+
+// expr is an expression evaluated against a row.  Nil is null; a
+// comparison involving null is null, which a WHERE clause treats as false
+type expr interface {
+	eval(r row) (interface{}, error)
+}
+
+type literal struct {
+	value interface{}
+}
+
+func (e *literal) eval(r row) (interface{}, error) {
+	return e.value, nil
+}
+
+type variable struct {
+	name string
+}
+
+func (e *variable) eval(r row) (interface{}, error) {
+	return r[e.name], nil
+}
+
+// attribute is an attribute of a node or edge, keyed by name
+type attribute struct {
+	expr expr
+	key  string
+}
+
+func (e *attribute) eval(r row) (interface{}, error) {
+	value, err := e.expr.eval(r)
+	if err != nil {
+		return nil, err
+	}
+	if item, ok := value.(graph.Attributed); ok {
+		return item.Attribute(e.key), nil
+	}
+	return nil, nil
+}
+
+type comparison struct {
+	op    string
+	left  expr
+	right expr
+}
+
+func (e *comparison) eval(r row) (interface{}, error) {
+	left, err := e.left.eval(r)
+	if err != nil {
+		return nil, err
+	}
+	right, err := e.right.eval(r)
+	if err != nil {
+		return nil, err
+	}
+	if left == nil || right == nil {
+		return nil, nil
+	}
+	switch e.op {
+	case "=":
+		return equal(left, right), nil
+	case "<>", "!=":
+		return !equal(left, right), nil
+	case "STARTS WITH", "ENDS WITH", "CONTAINS":
+		a, aok := left.(string)
+		b, bok := right.(string)
+		if !aok || !bok {
+			return nil, nil
+		}
+		switch e.op {
+		case "STARTS WITH":
+			return strings.HasPrefix(a, b), nil
+		case "ENDS WITH":
+			return strings.HasSuffix(a, b), nil
+		default:
+			return strings.Contains(a, b), nil
+		}
+	}
+	order, ok := compare(left, right)
+	if !ok {
+		return nil, nil
+	}
+	switch e.op {
+	case "<":
+		return order < 0, nil
+	case "<=":
+		return order <= 0, nil
+	case ">":
+		return order > 0, nil
+	default:
+		return order >= 0, nil
+	}
+}
+
+// logical is AND or OR, with null treated as unknown
+type logical struct {
+	op    string
+	left  expr
+	right expr
+}
+
+func (e *logical) eval(r row) (interface{}, error) {
+	left, err := e.left.eval(r)
+	if err != nil {
+		return nil, err
+	}
+	right, err := e.right.eval(r)
+	if err != nil {
+		return nil, err
+	}
+	// The value that decides the result on its own
+	decisive := e.op == "OR"
+	if left == decisive || right == decisive {
+		return decisive, nil
+	}
+	if left == !decisive && right == !decisive {
+		return !decisive, nil
+	}
+	return nil, nil
+}
+
+type not struct {
+	expr expr
+}
+
+func (e *not) eval(r row) (interface{}, error) {
+	value, err := e.expr.eval(r)
+	if err != nil {
+		return nil, err
+	}
+	if b, ok := value.(bool); ok {
+		return !b, nil
+	}
+	return nil, nil
+}
+
+// isNull is IS NULL, or IS NOT NULL when negated
+type isNull struct {
+	expr    expr
+	negated bool
+}
+
+func (e *isNull) eval(r row) (interface{}, error) {
+	value, err := e.expr.eval(r)
+	if err != nil {
+		return nil, err
+	}
+	return (value == nil) != e.negated, nil
+}
+
+// call is a function call.  Aggregate functions are evaluated over groups
+// of rows by the executor rather than here
+type call struct {
+	name     string
+	args     []expr
+	star     bool
+	distinct bool
+}
+
+// aggregates are the functions that combine the values of many rows
+var aggregates = map[string]bool{
+	"count":   true,
+	"sum":     true,
+	"avg":     true,
+	"min":     true,
+	"max":     true,
+	"collect": true,
+}
+
+func (e *call) eval(r row) (interface{}, error) {
+	if aggregates[e.name] {
+		return nil, fmt.Errorf("Aggregate function %s can only be returned", e.name)
+	}
+	value, err := e.args[0].eval(r)
+	if err != nil {
+		return nil, err
+	}
+	switch e.name {
+	case "id":
+		if node, ok := value.(graph.Node); ok {
+			return node.Id(), nil
+		}
+	case "length":
+		switch v := value.(type) {
+		case []graph.Edge:
+			return int64(len(v)), nil
+		case []interface{}:
+			return int64(len(v)), nil
+		case string:
+			return int64(len([]rune(v))), nil
+		}
+	}
+	return nil, nil
+}
+
+// truthy returns true only for the boolean true
+func truthy(value interface{}) bool {
+	b, ok := value.(bool)
+	return ok && b
+}
+
+// equal compares values, numbers by value whatever their type, nodes by
+// ID and edges by their ends
+func equal(a, b interface{}) bool {
+	if isNumber(a) && isNumber(b) {
+		order, _ := compare(a, b)
+		return order == 0
+	}
+	if na, ok := a.(graph.Node); ok {
+		nb, ok := b.(graph.Node)
+		return ok && na.Id() == nb.Id()
+	}
+	if ea, ok := a.(graph.Edge); ok {
+		eb, ok := b.(graph.Edge)
+		return ok && ea.From() == eb.From() && ea.To() == eb.To()
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// compare orders two numbers, two strings or two booleans, returning
+// false for values that cannot be ordered against each other
+func compare(a, b interface{}) (int, bool) {
+	switch {
+	case isNumber(a) && isNumber(b):
+		va := reflect.ValueOf(a)
+		vb := reflect.ValueOf(b)
+		if isInt(va) && isInt(vb) {
+			return order(va.Int() < vb.Int(), va.Int() > vb.Int()), true
+		}
+		fa := float(va)
+		fb := float(vb)
+		return order(fa < fb, fa > fb), true
+	}
+	switch va := a.(type) {
+	case string:
+		if vb, ok := b.(string); ok {
+			return strings.Compare(va, vb), true
+		}
+	case bool:
+		if vb, ok := b.(bool); ok {
+			return order(!va && vb, va && !vb), true
+		}
+	}
+	return 0, false
+}
+
+// sortOrder orders any two values for ORDER BY: values that compare are
+// ordered, others by kind, and nulls come last
+func sortOrder(a, b interface{}) int {
+	if res, ok := compare(a, b); ok {
+		return res
+	}
+	ka := rank(a)
+	kb := rank(b)
+	if ka != kb {
+		return ka - kb
+	}
+	switch va := a.(type) {
+	case graph.Node:
+		vb := b.(graph.Node)
+		return order(va.Id() < vb.Id(), va.Id() > vb.Id())
+	case graph.Edge:
+		vb := b.(graph.Edge)
+		if va.From() != vb.From() {
+			return order(va.From() < vb.From(), va.From() > vb.From())
+		}
+		return order(va.To() < vb.To(), va.To() > vb.To())
+	}
+	return strings.Compare(key(a), key(b))
+}
+
+func rank(value interface{}) int {
+	switch {
+	case isNumber(value):
+		return 0
+	case value == nil:
+		return 6
+	}
+	switch value.(type) {
+	case string:
+		return 1
+	case bool:
+		return 2
+	case graph.Node:
+		return 3
+	case graph.Edge:
+		return 4
+	default:
+		return 5
+	}
+}
+
+// key returns a string that identifies a value, for grouping and DISTINCT
+func key(value interface{}) string {
+	switch v := value.(type) {
+	case graph.Node:
+		return fmt.Sprintf("node:%d", v.Id())
+	case graph.Edge:
+		return fmt.Sprintf("edge:%d:%d", v.From(), v.To())
+	case []graph.Edge:
+		keys := make([]string, len(v))
+		for i, edge := range v {
+			keys[i] = key(edge)
+		}
+		return "path:" + strings.Join(keys, ",")
+	}
+	if isNumber(value) {
+		return fmt.Sprintf("number:%v", float(reflect.ValueOf(value)))
+	}
+	return fmt.Sprintf("%T:%v", value, value)
+}
+
+func isNumber(value interface{}) bool {
+	switch reflect.ValueOf(value).Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	default:
+		return false
+	}
+}
+
+func isInt(v reflect.Value) bool {
+	return v.Kind() >= reflect.Int && v.Kind() <= reflect.Int64
+}
+
+func float(v reflect.Value) float64 {
+	switch {
+	case isInt(v):
+		return float64(v.Int())
+	case v.Kind() >= reflect.Uint && v.Kind() <= reflect.Uintptr:
+		return float64(v.Uint())
+	default:
+		return v.Float()
+	}
+}
+
+func order(less, greater bool) int {
+	switch {
+	case less:
+		return -1
+	case greater:
+		return 1
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,137 @@
+package query
+
+// Copyright © 2018 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenNumber
+	tokenString
+	tokenSymbol
+)
+
+// token is a lexical token of a query, with its byte offsets in the text
+type token struct {
+	kind  tokenKind
+	text  string
+	value interface{}
+	start int
+	end   int
+}
+
+// symbols are the multi-character symbols, checked before single
+// characters
+var symbols = []string{"<>", "!=", "<=", ">=", ".."}
+
+// lex splits a query into tokens.  Keywords are returned as identifiers
+func lex(text string) ([]token, error) {
+	res := make([]token, 0)
+	// at returns the rune at an offset, or 0 at the end of the text
+	at := func(i int) rune {
+		if i >= len(text) {
+			return 0
+		}
+		r, _ := utf8.DecodeRuneInString(text[i:])
+		return r
+	}
+	// skip moves past runes that satisfy a test
+	skip := func(i int, test func(rune) bool) int {
+		for i < len(text) && test(at(i)) {
+			_, size := utf8.DecodeRuneInString(text[i:])
+			i += size
+		}
+		return i
+	}
+	isIdent := func(r rune) bool { return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) }
+
+	for i := 0; i < len(text); {
+		r := at(i)
+		start := i
+		var tok token
+		switch {
+		case unicode.IsSpace(r):
+			i = skip(i, unicode.IsSpace)
+			continue
+		case r == '_' || unicode.IsLetter(r):
+			i = skip(i, isIdent)
+			tok = token{kind: tokenIdent, text: text[start:i]}
+		case r == '`':
+			i = skip(i+1, func(r rune) bool { return r != '`' })
+			if i == len(text) {
+				return nil, fmt.Errorf("Unterminated identifier at offset %d", start)
+			}
+			i++
+			tok = token{kind: tokenIdent, text: text[start+1 : i-1]}
+		case unicode.IsDigit(r):
+			i = skip(i, unicode.IsDigit)
+			float := at(i) == '.' && unicode.IsDigit(at(i+1))
+			if float {
+				i = skip(i+1, unicode.IsDigit)
+			}
+			tok = token{kind: tokenNumber, text: text[start:i]}
+			var err error
+			if float {
+				tok.value, err = strconv.ParseFloat(tok.text, 64)
+			} else {
+				tok.value, err = strconv.ParseInt(tok.text, 10, 64)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("Invalid number %s at offset %d", tok.text, start)
+			}
+		case r == '"' || r == '\'':
+			var value strings.Builder
+			for i++; i < len(text) && at(i) != r; {
+				if at(i) == '\\' && i+1 < len(text) {
+					i++
+				}
+				c, size := utf8.DecodeRuneInString(text[i:])
+				value.WriteRune(c)
+				i += size
+			}
+			if i == len(text) {
+				return nil, fmt.Errorf("Unterminated string at offset %d", start)
+			}
+			i++
+			tok = token{kind: tokenString, text: text[start:i], value: value.String()}
+		default:
+			symbol := string(r)
+			for _, candidate := range symbols {
+				if strings.HasPrefix(text[i:], candidate) {
+					symbol = candidate
+					break
+				}
+			}
+			if symbol == "!" || !strings.Contains("()[]{},:.*-<>=!", symbol[:1]) {
+				return nil, fmt.Errorf("Unexpected character %q at offset %d", r, start)
+			}
+			i += len(symbol)
+			tok = token{kind: tokenSymbol, text: symbol}
+		}
+		tok.start = start
+		tok.end = i
+		res = append(res, tok)
+	}
+	res = append(res, token{kind: tokenEOF, start: len(text), end: len(text)})
+	return res, nil
+}
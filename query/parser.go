@@ -0,0 +1,721 @@
+package query
+
+// Copyright © 2018 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"fmt"
+	"strings"
+)
+
+// direction is the direction of a relationship in a pattern
+type direction int
+
+const (
+	outgoing direction = iota
+	incoming
+	either
+)
+
+// Query is a parsed query, which can be run against many graphs
+type Query struct {
+	patterns []*pattern
+	where    expr
+	items    []*item
+	order    []*ordering
+	skip     int64
+	limit    int64
+}
+
+// pattern is a chain of nodes joined by relationships
+type pattern struct {
+	nodes []*nodePattern
+	rels  []*relPattern
+}
+
+type nodePattern struct {
+	name  string
+	props []*prop
+}
+
+// relPattern matches an edge, or a path of edges when variable.  A max
+// of -1 is unbounded
+type relPattern struct {
+	name      string
+	direction direction
+	variable  bool
+	min       int
+	max       int
+	props     []*prop
+}
+
+// prop is an attribute that a node or edge must have
+type prop struct {
+	key   string
+	value interface{}
+}
+
+// item is a returned column
+type item struct {
+	expr expr
+	text string
+	name string
+}
+
+// ordering is an ORDER BY expression.  Column is the index of the returned
+// column it names or repeats, or -1
+type ordering struct {
+	expr       expr
+	text       string
+	column     int
+	descending bool
+}
+
+type parser struct {
+	text   string
+	tokens []token
+	pos    int
+	// kinds records whether each variable is a node or a relationship
+	kinds map[string]string
+}
+
+// Parse parses a query of the form
+//
+//	MATCH pattern, ... [WHERE condition]
+//	RETURN expression [AS name], ...
+//	[ORDER BY expression [ASC|DESC], ...] [SKIP n] [LIMIT n]
+//
+// Patterns chain nodes such as (a {team: "payments"}) with relationships
+// such as -[e]->, <-[e]-, -[e]- or -[*1..3]->.  Expressions compare
+// attributes such as a.tier, and may call id, length and the aggregate
+// functions count, sum, avg, min, max and collect
+func Parse(text string) (*Query, error) {
+	tokens, err := lex(text)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{
+		text:   text,
+		tokens: tokens,
+		kinds:  make(map[string]string),
+	}
+	return p.parseQuery()
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+// isKeyword returns true if the next token is a keyword, in any case
+func (p *parser) isKeyword(keyword string) bool {
+	tok := p.peek()
+	return tok.kind == tokenIdent && strings.EqualFold(tok.text, keyword)
+}
+
+func (p *parser) acceptKeyword(keyword string) bool {
+	if p.isKeyword(keyword) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expectKeyword(keyword string) error {
+	if !p.acceptKeyword(keyword) {
+		return p.unexpected(keyword)
+	}
+	return nil
+}
+
+func (p *parser) isSymbol(symbol string) bool {
+	tok := p.peek()
+	return tok.kind == tokenSymbol && tok.text == symbol
+}
+
+func (p *parser) acceptSymbol(symbol string) bool {
+	if p.isSymbol(symbol) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expectSymbol(symbol string) error {
+	if !p.acceptSymbol(symbol) {
+		return p.unexpected(symbol)
+	}
+	return nil
+}
+
+// unexpected returns an error for finding the next token where something
+// else was expected
+func (p *parser) unexpected(expected string) error {
+	tok := p.peek()
+	if tok.kind == tokenEOF {
+		return fmt.Errorf("Expected %s at end of query", expected)
+	}
+	return fmt.Errorf("Expected %s at offset %d, found %s", expected, tok.start, tok.text)
+}
+
+// keywords cannot be used as variable names
+var keywords = map[string]bool{
+	"MATCH": true, "WHERE": true, "RETURN": true, "ORDER": true, "BY": true,
+	"ASC": true, "DESC": true, "SKIP": true, "LIMIT": true, "AND": true,
+	"OR": true, "NOT": true, "AS": true, "IS": true, "NULL": true,
+	"TRUE": true, "FALSE": true, "DISTINCT": true, "STARTS": true,
+	"ENDS": true, "WITH": true, "CONTAINS": true,
+}
+
+// acceptName returns the next token's text if it is a name
+func (p *parser) acceptName() (string, bool) {
+	tok := p.peek()
+	if tok.kind != tokenIdent || keywords[strings.ToUpper(tok.text)] {
+		return "", false
+	}
+	p.pos++
+	return tok.text, true
+}
+
+func (p *parser) parseQuery() (*Query, error) {
+	q := &Query{skip: 0, limit: -1}
+	if err := p.expectKeyword("MATCH"); err != nil {
+		return nil, err
+	}
+	for {
+		pattern, err := p.parsePattern()
+		if err != nil {
+			return nil, err
+		}
+		q.patterns = append(q.patterns, pattern)
+		if !p.acceptSymbol(",") {
+			break
+		}
+	}
+	var err error
+	if p.acceptKeyword("WHERE") {
+		if q.where, err = p.parseExpr(); err != nil {
+			return nil, err
+		}
+	}
+	if err := p.expectKeyword("RETURN"); err != nil {
+		return nil, err
+	}
+	for {
+		start := p.peek().start
+		expr, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		text := strings.TrimSpace(p.text[start:p.tokens[p.pos-1].end])
+		item := &item{expr: expr, text: text, name: text}
+		if p.acceptKeyword("AS") {
+			name, ok := p.acceptName()
+			if !ok {
+				return nil, p.unexpected("column name")
+			}
+			item.name = name
+		}
+		q.items = append(q.items, item)
+		if !p.acceptSymbol(",") {
+			break
+		}
+	}
+	if p.acceptKeyword("ORDER") {
+		if err := p.expectKeyword("BY"); err != nil {
+			return nil, err
+		}
+		for {
+			start := p.peek().start
+			expr, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			ordering := &ordering{expr: expr, text: strings.TrimSpace(p.text[start:p.tokens[p.pos-1].end]), column: -1}
+			if p.acceptKeyword("DESC") || p.acceptKeyword("DESCENDING") {
+				ordering.descending = true
+			} else if !p.acceptKeyword("ASC") {
+				p.acceptKeyword("ASCENDING")
+			}
+			q.order = append(q.order, ordering)
+			if !p.acceptSymbol(",") {
+				break
+			}
+		}
+	}
+	if p.acceptKeyword("SKIP") {
+		if q.skip, err = p.parseCount("SKIP"); err != nil {
+			return nil, err
+		}
+	}
+	if p.acceptKeyword("LIMIT") {
+		if q.limit, err = p.parseCount("LIMIT"); err != nil {
+			return nil, err
+		}
+	}
+	if p.peek().kind != tokenEOF {
+		return nil, p.unexpected("end of query")
+	}
+	if err := p.checkVariables(q); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+func (p *parser) parseCount(clause string) (int64, error) {
+	tok := p.peek()
+	if tok.kind != tokenNumber {
+		return 0, p.unexpected(fmt.Sprintf("number after %s", clause))
+	}
+	count, ok := tok.value.(int64)
+	if !ok {
+		return 0, fmt.Errorf("%s must be a whole number", clause)
+	}
+	p.pos++
+	return count, nil
+}
+
+func (p *parser) parsePattern() (*pattern, error) {
+	res := &pattern{}
+	for {
+		node, err := p.parseNode()
+		if err != nil {
+			return nil, err
+		}
+		res.nodes = append(res.nodes, node)
+		if !p.isSymbol("-") && !p.isSymbol("<") {
+			return res, nil
+		}
+		rel, err := p.parseRel()
+		if err != nil {
+			return nil, err
+		}
+		res.rels = append(res.rels, rel)
+	}
+}
+
+func (p *parser) parseNode() (*nodePattern, error) {
+	if err := p.expectSymbol("("); err != nil {
+		return nil, err
+	}
+	res := &nodePattern{}
+	if name, ok := p.acceptName(); ok {
+		if err := p.declare(name, "node"); err != nil {
+			return nil, err
+		}
+		res.name = name
+	}
+	var err error
+	if p.isSymbol("{") {
+		if res.props, err = p.parseProps(); err != nil {
+			return nil, err
+		}
+	}
+	if err := p.expectSymbol(")"); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (p *parser) parseRel() (*relPattern, error) {
+	res := &relPattern{min: 1, max: 1}
+	left := p.acceptSymbol("<")
+	if err := p.expectSymbol("-"); err != nil {
+		return nil, err
+	}
+	if p.acceptSymbol("[") {
+		if name, ok := p.acceptName(); ok {
+			if err := p.declare(name, "relationship"); err != nil {
+				return nil, err
+			}
+			res.name = name
+		}
+		if p.acceptSymbol("*") {
+			if err := p.parseLength(res); err != nil {
+				return nil, err
+			}
+		}
+		var err error
+		if p.isSymbol("{") {
+			if res.props, err = p.parseProps(); err != nil {
+				return nil, err
+			}
+		}
+		if err := p.expectSymbol("]"); err != nil {
+			return nil, err
+		}
+	}
+	if err := p.expectSymbol("-"); err != nil {
+		return nil, err
+	}
+	right := p.acceptSymbol(">")
+	switch {
+	case left && right:
+		return nil, fmt.Errorf("Relationship at offset %d points both ways", p.tokens[p.pos-1].start)
+	case left:
+		res.direction = incoming
+	case right:
+		res.direction = outgoing
+	default:
+		res.direction = either
+	}
+	return res, nil
+}
+
+// parseLength parses the bounds of a variable-length relationship after
+// the star: nothing, n, n.., ..m or n..m
+func (p *parser) parseLength(rel *relPattern) error {
+	rel.variable = true
+	rel.max = -1
+	bound := func() (int, bool) {
+		tok := p.peek()
+		if value, ok := tok.value.(int64); ok && tok.kind == tokenNumber {
+			p.pos++
+			return int(value), true
+		}
+		return 0, false
+	}
+	if min, ok := bound(); ok {
+		rel.min = min
+		rel.max = min
+	}
+	if p.acceptSymbol("..") {
+		rel.max = -1
+		if max, ok := bound(); ok {
+			rel.max = max
+		}
+	}
+	if rel.max != -1 && rel.max < rel.min {
+		return fmt.Errorf("Relationship length %d..%d is empty", rel.min, rel.max)
+	}
+	return nil
+}
+
+func (p *parser) parseProps() ([]*prop, error) {
+	if err := p.expectSymbol("{"); err != nil {
+		return nil, err
+	}
+	res := make([]*prop, 0)
+	for !p.acceptSymbol("}") {
+		if len(res) > 0 {
+			if err := p.expectSymbol(","); err != nil {
+				return nil, err
+			}
+		}
+		tok := p.next()
+		if tok.kind != tokenIdent {
+			p.pos--
+			return nil, p.unexpected("attribute name")
+		}
+		if err := p.expectSymbol(":"); err != nil {
+			return nil, err
+		}
+		value, err := p.parseLiteral()
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, &prop{key: tok.text, value: value.value})
+	}
+	return res, nil
+}
+
+// parseLiteral parses a number, string, boolean or null
+func (p *parser) parseLiteral() (*literal, error) {
+	tok := p.peek()
+	switch {
+	case tok.kind == tokenNumber || tok.kind == tokenString:
+		p.pos++
+		return &literal{value: tok.value}, nil
+	case p.acceptSymbol("-"):
+		tok = p.peek()
+		if tok.kind != tokenNumber {
+			return nil, p.unexpected("number")
+		}
+		p.pos++
+		if value, ok := tok.value.(int64); ok {
+			return &literal{value: -value}, nil
+		}
+		return &literal{value: -tok.value.(float64)}, nil
+	case p.acceptKeyword("TRUE"):
+		return &literal{value: true}, nil
+	case p.acceptKeyword("FALSE"):
+		return &literal{value: false}, nil
+	case p.acceptKeyword("NULL"):
+		return &literal{value: nil}, nil
+	}
+	return nil, p.unexpected("value")
+}
+
+// declare records the kind of a variable, which must not change
+func (p *parser) declare(name string, kind string) error {
+	if existing, exists := p.kinds[name]; exists && existing != kind {
+		return fmt.Errorf("Variable %s is used for both a node and a relationship", name)
+	}
+	p.kinds[name] = kind
+	return nil
+}
+
+func (p *parser) parseExpr() (expr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.acceptKeyword("OR") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &logical{op: "OR", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (expr, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.acceptKeyword("AND") {
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = &logical{op: "AND", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseNot() (expr, error) {
+	if p.acceptKeyword("NOT") {
+		e, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &not{expr: e}, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (expr, error) {
+	left, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	var op string
+	switch {
+	case p.acceptKeyword("IS"):
+		negated := p.acceptKeyword("NOT")
+		if err := p.expectKeyword("NULL"); err != nil {
+			return nil, err
+		}
+		return &isNull{expr: left, negated: negated}, nil
+	case p.acceptKeyword("STARTS"):
+		op = "STARTS WITH"
+	case p.acceptKeyword("ENDS"):
+		op = "ENDS WITH"
+	case p.acceptKeyword("CONTAINS"):
+		op = "CONTAINS"
+	default:
+		for _, symbol := range []string{"=", "<>", "!=", "<=", ">=", "<", ">"} {
+			if p.acceptSymbol(symbol) {
+				op = symbol
+				break
+			}
+		}
+		if op == "" {
+			return left, nil
+		}
+	}
+	if strings.HasSuffix(op, "WITH") {
+		if err := p.expectKeyword("WITH"); err != nil {
+			return nil, err
+		}
+	}
+	right, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	return &comparison{op: op, left: left, right: right}, nil
+}
+
+func (p *parser) parsePrimary() (expr, error) {
+	if p.acceptSymbol("(") {
+		e, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expectSymbol(")"); err != nil {
+			return nil, err
+		}
+		return e, nil
+	}
+	name, ok := p.acceptName()
+	if !ok {
+		return p.parseLiteral()
+	}
+	if p.acceptSymbol("(") {
+		return p.parseCall(strings.ToLower(name))
+	}
+	var res expr = &variable{name: name}
+	for p.acceptSymbol(".") {
+		tok := p.next()
+		if tok.kind != tokenIdent {
+			p.pos--
+			return nil, p.unexpected("attribute name")
+		}
+		res = &attribute{expr: res, key: tok.text}
+	}
+	return res, nil
+}
+
+// parseCall parses the arguments of a function call after the opening
+// parenthesis
+func (p *parser) parseCall(name string) (expr, error) {
+	res := &call{name: name}
+	switch {
+	case name == "count" && p.acceptSymbol("*"):
+		res.star = true
+	case aggregates[name]:
+		res.distinct = p.acceptKeyword("DISTINCT")
+		fallthrough
+	case name == "id" || name == "length":
+		arg, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		res.args = []expr{arg}
+	default:
+		return nil, fmt.Errorf("Unknown function %s", name)
+	}
+	if err := p.expectSymbol(")"); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// checkVariables ensures that expressions only use variables declared in
+// the patterns.  ORDER BY may also use the names of returned columns, and
+// when results are aggregated may use nothing else
+func (p *parser) checkVariables(q *Query) error {
+	names := make(map[string]bool)
+	for name := range p.kinds {
+		names[name] = true
+	}
+	if err := check(q.where, names); err != nil {
+		return err
+	}
+	for _, item := range q.items {
+		if err := check(item.expr, names); err != nil {
+			return err
+		}
+	}
+	columns := make(map[string]bool)
+	for _, item := range q.items {
+		names[item.name] = true
+		columns[item.name] = true
+	}
+	for _, ordering := range q.order {
+		for i, item := range q.items {
+			if ordering.text == item.name || ordering.text == item.text {
+				ordering.column = i
+				break
+			}
+		}
+		if ordering.column >= 0 {
+			continue
+		}
+		if err := check(ordering.expr, names); err != nil {
+			return err
+		}
+		if q.aggregated() && (check(ordering.expr, columns) != nil || hasAggregate(ordering.expr)) {
+			return fmt.Errorf("ORDER BY %s is neither a grouping key nor a returned aggregate", ordering.text)
+		}
+	}
+	return nil
+}
+
+// aggregated returns true if any returned column is an aggregate, in which
+// case rows are grouped by the other columns
+func (q *Query) aggregated() bool {
+	for _, item := range q.items {
+		if c, ok := item.expr.(*call); ok && aggregates[c.name] {
+			return true
+		}
+	}
+	return false
+}
+
+// hasAggregate returns true if an expression calls an aggregate function
+func hasAggregate(e expr) bool {
+	switch v := e.(type) {
+	case *attribute:
+		return hasAggregate(v.expr)
+	case *comparison:
+		return hasAggregate(v.left) || hasAggregate(v.right)
+	case *logical:
+		return hasAggregate(v.left) || hasAggregate(v.right)
+	case *not:
+		return hasAggregate(v.expr)
+	case *isNull:
+		return hasAggregate(v.expr)
+	case *call:
+		if aggregates[v.name] {
+			return true
+		}
+		for _, arg := range v.args {
+			if hasAggregate(arg) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func check(e expr, names map[string]bool) error {
+	switch v := e.(type) {
+	case *variable:
+		if !names[v.name] {
+			return fmt.Errorf("Unknown variable %s", v.name)
+		}
+	case *attribute:
+		return check(v.expr, names)
+	case *comparison:
+		if err := check(v.left, names); err != nil {
+			return err
+		}
+		return check(v.right, names)
+	case *logical:
+		if err := check(v.left, names); err != nil {
+			return err
+		}
+		return check(v.right, names)
+	case *not:
+		return check(v.expr, names)
+	case *isNull:
+		return check(v.expr, names)
+	case *call:
+		for _, arg := range v.args {
+			if err := check(arg, names); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
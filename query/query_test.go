@@ -0,0 +1,249 @@
+package query
+
+// Copyright © 2018 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wealdtech/go-graph"
+	"github.com/wealdtech/go-graph/edges"
+	"github.com/wealdtech/go-graph/graphs"
+	"github.com/wealdtech/go-graph/nodes"
+)
+
+// services returns a graph of services calling each other:
+//
+//	1 checkout (payments, tier 1) -> 2 ledger (payments, tier 1)
+//	1 checkout -> 3 search (discovery, tier 2)
+//	2 ledger -> 4 audit (compliance, tier 3)
+//	3 search -> 4 audit
+//	4 audit -> 5 archive (compliance, tier 3)
+func services(t *testing.T) *graphs.DirectedGraph {
+	g := graphs.NewDirectedGraph()
+	for i, service := range []struct {
+		name string
+		team string
+		tier int
+	}{
+		{"checkout", "payments", 1},
+		{"ledger", "payments", 1},
+		{"search", "discovery", 2},
+		{"audit", "compliance", 3},
+		{"archive", "compliance", 3},
+	} {
+		node := nodes.NewSimpleNode(int64(i + 1))
+		node.SetAttribute("name", service.name)
+		node.SetAttribute("team", service.team)
+		node.SetAttribute("tier", service.tier)
+		assert.NoError(t, g.AddNode(node))
+	}
+	for _, link := range []struct {
+		from    int64
+		to      int64
+		latency float64
+	}{{1, 2, 10}, {1, 3, 20}, {2, 4, 5}, {3, 4, 15}, {4, 5, 1}} {
+		edge := edges.NewDirectedEdge(link.from, link.to)
+		edge.SetAttribute("type", "calls")
+		edge.SetAttribute("latency", link.latency)
+		assert.NoError(t, g.AddEdge(edge))
+	}
+	return g
+}
+
+func run(t *testing.T, g graph.Graph, text string) [][]interface{} {
+	res, err := Run(g, text)
+	if !assert.NoError(t, err, text) {
+		return nil
+	}
+	return res.Rows
+}
+
+func TestMatch(t *testing.T) {
+	g := services(t)
+
+	res, err := Run(g, `MATCH (a {team:"payments"})-[e]->(b) WHERE b.tier > 1 RETURN a.name, b.name AS callee, e.latency`)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a.name", "callee", "e.latency"}, res.Columns)
+	assert.Equal(t, [][]interface{}{{"checkout", "search", float64(20)}, {"ledger", "audit", float64(5)}}, res.Rows)
+
+	// Incoming relationships, with AND binding tighter than OR
+	assert.Equal(t, [][]interface{}{{"checkout"}, {"ledger"}, {"search"}},
+		run(t, g, `MATCH (b)<--(a) WHERE b.name = 'audit' OR b.name = 'ledger' AND a.tier = 1 RETURN a.name ORDER BY a.name`))
+
+	// Several patterns sharing variables
+	assert.Equal(t, [][]interface{}{{"ledger", int64(4)}, {"search", int64(4)}},
+		run(t, g, `MATCH (a)-->(b)-->(c), (c)-->(d {name: "archive"}) WHERE a.name STARTS WITH "check" RETURN b.name, id(c) ORDER BY b.name`))
+
+	// Null handling
+	assert.Equal(t, [][]interface{}{{"archive"}},
+		run(t, g, `MATCH (a) WHERE a.tier >= 3 AND NOT id(a) = 4 AND a.missing IS NULL RETURN a.name`))
+	assert.Equal(t, [][]interface{}{}, run(t, g, `MATCH (a) WHERE a.missing = 1 OR a.missing <> 1 RETURN a`))
+
+	// Either direction, with each edge used once per match
+	assert.Equal(t, [][]interface{}{{"checkout"}, {"audit"}},
+		run(t, g, `MATCH (a {name: "ledger"})-[e]-(b) RETURN b.name ORDER BY e.latency DESC`))
+	assert.Equal(t, [][]interface{}{}, run(t, g, `MATCH (a)-[e]->(b)<-[f]-(a) RETURN a`))
+}
+
+func TestVariableLength(t *testing.T) {
+	g := services(t)
+
+	rows := run(t, g, `MATCH (a {name: "checkout"})-[p*2..3]->(b) RETURN b.name, length(p) ORDER BY length(p), b.name`)
+	assert.Equal(t, [][]interface{}{{"audit", int64(2)}, {"audit", int64(2)}, {"archive", int64(3)}, {"archive", int64(3)}}, rows)
+
+	rows = run(t, g, `MATCH (a)-[*]->(b {name: "archive"}) RETURN a.name, count(*) ORDER BY a.name`)
+	assert.Equal(t, [][]interface{}{{"audit", int64(1)}, {"checkout", int64(2)}, {"ledger", int64(1)}, {"search", int64(1)}}, rows)
+
+	rows = run(t, g, `MATCH (a {name: "audit"})-[*0..1 {type: "calls"}]->(b) RETURN b.name ORDER BY b.name`)
+	assert.Equal(t, [][]interface{}{{"archive"}, {"audit"}}, rows)
+
+	res, err := Run(g, `MATCH (a {name: "ledger"})-[p*2]->(b) RETURN p`)
+	assert.NoError(t, err)
+	assert.Len(t, res.Rows, 1)
+	path := res.Rows[0][0].([]graph.Edge)
+	assert.Equal(t, int64(4), path[1].From())
+	assert.Equal(t, int64(5), path[1].To())
+}
+
+func TestAggregation(t *testing.T) {
+	g := services(t)
+
+	res, err := Run(g, `MATCH (a) RETURN a.team AS team, count(*), collect(a.name), avg(a.tier), sum(a.tier), min(a.name), max(a.tier) ORDER BY count(*) DESC, team`)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"team", "count(*)", "collect(a.name)", "avg(a.tier)", "sum(a.tier)", "min(a.name)", "max(a.tier)"}, res.Columns)
+	assert.Equal(t, [][]interface{}{
+		{"compliance", int64(2), []interface{}{"audit", "archive"}, 3.0, int64(6), "archive", 3},
+		{"payments", int64(2), []interface{}{"checkout", "ledger"}, 1.0, int64(2), "checkout", 1},
+		{"discovery", int64(1), []interface{}{"search"}, 2.0, int64(2), "search", 2},
+	}, res.Rows)
+
+	assert.Equal(t, [][]interface{}{{int64(2), float64(41)}},
+		run(t, g, `MATCH (a)-[e]->(b) WHERE b.tier > 1 RETURN count(DISTINCT b.team), sum(e.latency)`))
+
+	// Grouping keys may be ordered by their expressions as well as names
+	assert.Equal(t, [][]interface{}{{"payments", int64(2)}, {"discovery", int64(1)}, {"compliance", int64(2)}},
+		run(t, g, `MATCH (a) RETURN a.team AS team, count(*) ORDER BY a.team DESC`))
+
+	// Aggregates over nothing
+	assert.Equal(t, [][]interface{}{{int64(0), nil, []interface{}{}}},
+		run(t, g, `MATCH (a {team: "nobody"}) RETURN count(a), avg(a.tier), collect(a)`))
+	assert.Equal(t, [][]interface{}{}, run(t, g, `MATCH (a {team: "nobody"}) RETURN a.team, count(a)`))
+
+	// Skip and limit
+	assert.Equal(t, [][]interface{}{{"checkout"}, {"ledger"}},
+		run(t, g, `MATCH (a) RETURN a.name ORDER BY a.tier DESC, a.name SKIP 3 LIMIT 5`))
+	assert.Equal(t, [][]interface{}{{"search"}},
+		run(t, g, `MATCH (a) RETURN a.name AS name ORDER BY name DESC LIMIT 1`))
+}
+
+// counting counts calls to Nodes, to show when a query scans the graph
+type counting struct {
+	*graphs.DirectedGraph
+	scans int
+}
+
+func (g *counting) Nodes() []graph.Node {
+	g.scans++
+	return g.DirectedGraph.Nodes()
+}
+
+func TestIndexes(t *testing.T) {
+	g := &counting{DirectedGraph: services(t)}
+	queries := []string{
+		`MATCH (a {team: "compliance"})-->(b) RETURN a.name, b.name`,
+		`MATCH (a)-->(b) WHERE a.tier = 3 RETURN a.name, b.name`,
+		`MATCH (a)-->(b) WHERE 2 < a.tier AND b.name <> "x" RETURN a.name, b.name`,
+		`MATCH (a)-->(b) WHERE a.team STARTS WITH "comp" RETURN a.name, b.name`,
+	}
+	expected := [][]interface{}{{"audit", "archive"}}
+	for _, query := range queries {
+		assert.Equal(t, expected, run(t, g, query), query)
+	}
+	assert.Equal(t, len(queries), g.scans)
+
+	g.IndexNodes("team")
+	g.IndexNodes("tier")
+	g.scans = 0
+	for _, query := range queries {
+		assert.Equal(t, expected, run(t, g, query), query)
+	}
+	assert.Equal(t, 0, g.scans)
+
+	// Indexes narrow the candidates without replacing the conditions
+	assert.Equal(t, [][]interface{}{{"search"}}, run(t, g, `MATCH (a) WHERE a.tier > 1 AND a.tier < 3 RETURN a.name`))
+	assert.Equal(t, [][]interface{}{{"search"}}, run(t, g, `MATCH (a) WHERE a.tier > 1 AND a.team <> "compliance" RETURN a.name`))
+	assert.Equal(t, 0, g.scans)
+	assert.Equal(t, [][]interface{}{{"audit"}, {"archive"}},
+		run(t, g, `MATCH (a) WHERE a.tier = 3 OR a.name = "none" RETURN a.name`))
+	assert.Equal(t, 1, g.scans)
+
+	// Incoming edges come from the graph rather than a scan
+	g.scans = 0
+	assert.Equal(t, [][]interface{}{{"archive", "audit"}, {"audit", "ledger"}, {"audit", "search"}},
+		run(t, g, `MATCH (a {team: "compliance"})<--(b) RETURN a.name, b.name ORDER BY a.name, b.name`))
+	assert.Equal(t, 0, g.scans)
+}
+
+func TestUndirected(t *testing.T) {
+	g := graphs.NewUndirectedGraph()
+	for i := int64(1); i <= 4; i++ {
+		node := nodes.NewSimpleNode(i)
+		node.SetAttribute("name", string(rune('a'+i-1)))
+		assert.NoError(t, g.AddNode(node))
+	}
+	for _, link := range [][2]int64{{1, 2}, {2, 3}, {3, 4}} {
+		assert.NoError(t, g.AddEdge(edges.NewUndirectedEdge(link[0], link[1])))
+	}
+	assert.Equal(t, [][]interface{}{{"a"}, {"c"}}, run(t, g, `MATCH (x {name: "b"})-->(y) RETURN y.name ORDER BY y.name`))
+	assert.Equal(t, [][]interface{}{{"a"}, {"c"}}, run(t, g, `MATCH (x {name: "b"})<-[]-(y) RETURN y.name ORDER BY y.name`))
+	assert.Equal(t, [][]interface{}{{"d", int64(3)}},
+		run(t, g, `MATCH (x {name: "a"})-[p*]-(y) WHERE length(p) > 2 RETURN y.name, length(p)`))
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := map[string]string{
+		`RETURN a`:                                               "Expected MATCH at offset 0, found RETURN",
+		`MATCH (a) RETURN b`:                                     "Unknown variable b",
+		`MATCH (a)-[a]->(b) RETURN a`:                            "Variable a is used for both a node and a relationship",
+		`MATCH (a)<-[e]->(b) RETURN a`:                           "Relationship at offset 15 points both ways",
+		`MATCH (a)-[*3..2]->(b) RETURN a`:                        "Relationship length 3..2 is empty",
+		`MATCH (a) RETURN upper(a.name)`:                         "Unknown function upper",
+		`MATCH (a) WHERE a.x = "open RETURN a`:                   "Unterminated string at offset 22",
+		`MATCH (a) RETURN a LIMIT 1.5`:                           "LIMIT must be a whole number",
+		`MATCH (a) RETURN a;`:                                    "Unexpected character ';' at offset 18",
+		`MATCH (a) RETURN a ORDER a`:                             "Expected BY at offset 25, found a",
+		`MATCH (a {x: }) RETURN a`:                               "Expected value at offset 13, found }",
+		`MATCH (a) WHERE a.x = 1 RETURN a.x AS`:                  "Expected column name at end of query",
+		`MATCH (a) WHERE count(*) > 1 RETURN a`:                  "",
+		`MATCH (a) RETURN a, count(*) ORDER BY`:                  "Expected value at end of query",
+		`MATCH (a) WHERE NOT a.x IS NOT RETURN a`:                "Expected NULL at offset 31, found RETURN",
+		`MATCH (a) RETURN count(*) ORDER BY a.tier`:              "ORDER BY a.tier is neither a grouping key nor a returned aggregate",
+		`MATCH (a) RETURN a.team, count(*) ORDER BY sum(a.tier)`: "ORDER BY sum(a.tier) is neither a grouping key nor a returned aggregate",
+		`MATCH (a) RETURN a, count(*) ORDER BY a.tier`:           "",
+	}
+	for text, message := range tests {
+		_, err := Parse(text)
+		if message == "" {
+			assert.NoError(t, err, text)
+			continue
+		}
+		assert.EqualError(t, err, message, text)
+	}
+
+	_, err := Run(services(t), `MATCH (a) WHERE count(*) > 1 RETURN a`)
+	assert.EqualError(t, err, "Aggregate function count can only be returned")
+	_, err = Run(services(t), `MATCH (a) RETURN sum(a.name)`)
+	assert.EqualError(t, err, "Cannot sum non-numeric value checkout")
+}
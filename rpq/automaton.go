@@ -0,0 +1,242 @@
+package rpq
+
+// Copyright © 2018 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// automaton is a nondeterministic finite automaton over edge labels, with
+// one start state and one accepting state
+type automaton struct {
+	states []*state
+	start  int
+	accept int
+	// closures holds the states reachable from each state by epsilon
+	// transitions, including the state itself
+	closures [][]int
+}
+
+type state struct {
+	epsilons    []int
+	transitions []*transition
+}
+
+// transition follows an edge with a label, or any edge if wildcard
+type transition struct {
+	label    string
+	wildcard bool
+	to       int
+}
+
+// fragment is a part of an automaton under construction, from its entry
+// to its exit state
+type fragment struct {
+	start int
+	end   int
+}
+
+type parser struct {
+	text string
+	pos  int
+	nfa  *automaton
+}
+
+// compile parses a regular path expression into an automaton
+func compile(text string) (*automaton, error) {
+	p := &parser{text: text, nfa: &automaton{}}
+	p.skipSpace()
+	if p.pos == len(p.text) {
+		return nil, fmt.Errorf("Empty expression")
+	}
+	frag, err := p.parseAlternation()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos != len(p.text) {
+		return nil, p.unexpected()
+	}
+	p.nfa.start = frag.start
+	p.nfa.accept = frag.end
+	p.nfa.closures = make([][]int, len(p.nfa.states))
+	for i := range p.nfa.states {
+		p.nfa.closures[i] = p.nfa.closure(i)
+	}
+	return p.nfa, nil
+}
+
+func (a *automaton) newState() int {
+	a.states = append(a.states, &state{})
+	return len(a.states) - 1
+}
+
+func (a *automaton) epsilon(from, to int) {
+	a.states[from].epsilons = append(a.states[from].epsilons, to)
+}
+
+// closure returns the states reachable from a state by epsilon
+// transitions, in order of discovery
+func (a *automaton) closure(from int) []int {
+	res := []int{from}
+	seen := map[int]bool{from: true}
+	for i := 0; i < len(res); i++ {
+		for _, to := range a.states[res[i]].epsilons {
+			if !seen[to] {
+				seen[to] = true
+				res = append(res, to)
+			}
+		}
+	}
+	return res
+}
+
+func (p *parser) skipSpace() {
+	for p.pos < len(p.text) && unicode.IsSpace(rune(p.text[p.pos])) {
+		p.pos++
+	}
+}
+
+// accept moves past a symbol if it is next
+func (p *parser) accept(symbol byte) bool {
+	if p.pos < len(p.text) && p.text[p.pos] == symbol {
+		p.pos++
+		p.skipSpace()
+		return true
+	}
+	return false
+}
+
+func (p *parser) unexpected() error {
+	if p.pos == len(p.text) {
+		return fmt.Errorf("Unexpected end of expression")
+	}
+	return fmt.Errorf("Unexpected %q at offset %d", p.text[p.pos], p.pos)
+}
+
+// parseAlternation parses paths separated by |
+func (p *parser) parseAlternation() (fragment, error) {
+	frag, err := p.parseSequence()
+	if err != nil {
+		return frag, err
+	}
+	for p.accept('|') {
+		other, err := p.parseSequence()
+		if err != nil {
+			return frag, err
+		}
+		start := p.nfa.newState()
+		end := p.nfa.newState()
+		p.nfa.epsilon(start, frag.start)
+		p.nfa.epsilon(start, other.start)
+		p.nfa.epsilon(frag.end, end)
+		p.nfa.epsilon(other.end, end)
+		frag = fragment{start: start, end: end}
+	}
+	return frag, nil
+}
+
+// parseSequence parses paths separated by /
+func (p *parser) parseSequence() (fragment, error) {
+	frag, err := p.parseRepetition()
+	if err != nil {
+		return frag, err
+	}
+	for p.accept('/') {
+		other, err := p.parseRepetition()
+		if err != nil {
+			return frag, err
+		}
+		p.nfa.epsilon(frag.end, other.start)
+		frag = fragment{start: frag.start, end: other.end}
+	}
+	return frag, nil
+}
+
+// parseRepetition parses a path followed by any of *, + and ?
+func (p *parser) parseRepetition() (fragment, error) {
+	frag, err := p.parseAtom()
+	if err != nil {
+		return frag, err
+	}
+	for {
+		var zero, many bool
+		switch {
+		case p.accept('*'):
+			zero, many = true, true
+		case p.accept('+'):
+			many = true
+		case p.accept('?'):
+			zero = true
+		default:
+			return frag, nil
+		}
+		start := p.nfa.newState()
+		end := p.nfa.newState()
+		p.nfa.epsilon(start, frag.start)
+		p.nfa.epsilon(frag.end, end)
+		if zero {
+			p.nfa.epsilon(start, end)
+		}
+		if many {
+			p.nfa.epsilon(frag.end, frag.start)
+		}
+		frag = fragment{start: start, end: end}
+	}
+}
+
+// parseAtom parses a label, a quoted label, the wildcard _ or a
+// parenthesised expression
+func (p *parser) parseAtom() (fragment, error) {
+	if p.accept('(') {
+		frag, err := p.parseAlternation()
+		if err != nil {
+			return frag, err
+		}
+		if !p.accept(')') {
+			return frag, p.unexpected()
+		}
+		return frag, nil
+	}
+	var label string
+	if p.pos < len(p.text) && p.text[p.pos] == '"' {
+		end := strings.IndexByte(p.text[p.pos+1:], '"')
+		if end == -1 {
+			return fragment{}, fmt.Errorf("Unterminated label at offset %d", p.pos)
+		}
+		label = p.text[p.pos+1 : p.pos+1+end]
+		p.pos += end + 2
+	} else {
+		start := p.pos
+		for p.pos < len(p.text) && isLabel(rune(p.text[p.pos])) {
+			p.pos++
+		}
+		if p.pos == start {
+			return fragment{}, p.unexpected()
+		}
+		label = p.text[start:p.pos]
+	}
+	wildcard := label == "_" && p.text[p.pos-1] != '"'
+	p.skipSpace()
+	start := p.nfa.newState()
+	end := p.nfa.newState()
+	p.nfa.states[start].transitions = append(p.nfa.states[start].transitions, &transition{label: label, wildcard: wildcard, to: end})
+	return fragment{start: start, end: end}, nil
+}
+
+// isLabel returns true for the characters of unquoted labels
+func isLabel(r rune) bool {
+	return r == '_' || r == '-' || r == '.' || r == ':' || (r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)))
+}
@@ -0,0 +1,143 @@
+package rpq
+
+// Copyright © 2018 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/wealdtech/go-graph"
+)
+
+// LabelAttribute is the edge attribute holding an edge's label
+const LabelAttribute = "type"
+
+// Query is a compiled regular path query, which can be evaluated from
+// many start nodes
+type Query struct {
+	nfa *automaton
+}
+
+// Result holds the nodes reached by a regular path query, in order of ID,
+// and the witness paths to them if they were asked for
+type Result struct {
+	Nodes []int64
+	// Paths holds a shortest path of edges to each reached node.  The path
+	// to the start node is empty if the expression matches the empty path
+	Paths map[int64][]graph.Edge
+}
+
+// Compile compiles a regular path expression over edge labels.  Labels
+// are letters, digits and any of _-.:, or any text in double quotes; the
+// wildcard _ matches any edge.  Paths are joined with / and alternatives
+// with |; a path followed by * repeats any number of times, + at least
+// once and ? at most once, so for example
+//
+//	(calls|publishes)+ / reads
+//
+// follows one or more calls or publishes edges and then a reads edge
+func Compile(expr string) (*Query, error) {
+	nfa, err := compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	return &Query{nfa: nfa}, nil
+}
+
+// Evaluate returns the nodes that can be reached from a start node along a
+// path whose edge labels match the query, and a shortest such path to
+// each if witnesses are asked for.  Labels are read from LabelAttribute;
+// edges without a string label only match the wildcard.  The search runs
+// breadth first over pairs of graph node and automaton state, so visits
+// each pair at most once
+func (q *Query) Evaluate(g graph.Graph, start int64, witnesses bool) (*Result, error) {
+	if !graph.IsDirected(g) {
+		return nil, fmt.Errorf("Regular path queries require a directed graph")
+	}
+	if !g.HasNode(start) {
+		return nil, fmt.Errorf("Unknown node %v", start)
+	}
+
+	// via records how each pair was first reached, for witness paths
+	type pair struct {
+		nid   int64
+		state int
+	}
+	type step struct {
+		from pair
+		edge graph.Edge
+	}
+	via := make(map[pair]*step)
+	queue := make([]pair, 0)
+	visit := func(p pair, s *step) {
+		if _, visited := via[p]; !visited {
+			via[p] = s
+			queue = append(queue, p)
+		}
+	}
+	for _, state := range q.nfa.closures[q.nfa.start] {
+		visit(pair{start, state}, nil)
+	}
+
+	res := &Result{Nodes: make([]int64, 0)}
+	reached := make(map[int64]pair)
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		if current.state == q.nfa.accept {
+			if _, exists := reached[current.nid]; !exists {
+				reached[current.nid] = current
+				res.Nodes = append(res.Nodes, current.nid)
+			}
+		}
+		transitions := q.nfa.states[current.state].transitions
+		if len(transitions) == 0 {
+			continue
+		}
+		for _, edge := range sortedEdges(g, current.nid) {
+			label, labelled := edge.Attribute(LabelAttribute).(string)
+			for _, t := range transitions {
+				if !t.wildcard && (!labelled || label != t.label) {
+					continue
+				}
+				for _, state := range q.nfa.closures[t.to] {
+					visit(pair{edge.To(), state}, &step{from: current, edge: edge})
+				}
+			}
+		}
+	}
+	sort.Slice(res.Nodes, func(i, j int) bool { return res.Nodes[i] < res.Nodes[j] })
+
+	if witnesses {
+		res.Paths = make(map[int64][]graph.Edge, len(reached))
+		for nid, end := range reached {
+			path := make([]graph.Edge, 0)
+			for s := via[end]; s != nil; s = via[s.from] {
+				path = append(path, s.edge)
+			}
+			for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+				path[i], path[j] = path[j], path[i]
+			}
+			res.Paths[nid] = path
+		}
+	}
+	return res, nil
+}
+
+// sortedEdges returns the edges leaving a node, ordered by their ends
+func sortedEdges(g graph.Graph, nid int64) []graph.Edge {
+	res := g.Edges(nid)
+	sort.Slice(res, func(i, j int) bool { return res[i].To() < res[j].To() })
+	return res
+}
@@ -0,0 +1,137 @@
+package rpq
+
+// Copyright © 2018 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wealdtech/go-graph"
+	"github.com/wealdtech/go-graph/edges"
+	"github.com/wealdtech/go-graph/graphs"
+	"github.com/wealdtech/go-graph/nodes"
+)
+
+// system returns a graph of services and the topics and tables they use:
+//
+//	1 -calls-> 2 -calls-> 3 -calls-> 1
+//	2 -publishes-> 4 (topic) -publishes-> 5 (consumer)
+//	1 -reads-> 10, 3 -reads-> 30, 5 -reads-> 50
+//	5 -owns-> 30
+func system(t *testing.T) *graphs.DirectedGraph {
+	g := graphs.NewDirectedGraph()
+	for _, nid := range []int64{1, 2, 3, 4, 5, 10, 30, 50} {
+		assert.NoError(t, g.AddNode(nodes.NewSimpleNode(nid)))
+	}
+	for _, link := range []struct {
+		from  int64
+		to    int64
+		label string
+	}{
+		{1, 2, "calls"}, {2, 3, "calls"}, {3, 1, "calls"},
+		{2, 4, "publishes"}, {4, 5, "publishes"},
+		{1, 10, "reads"}, {3, 30, "reads"}, {5, 50, "reads"},
+		{5, 30, "owns"},
+	} {
+		edge := edges.NewDirectedEdge(link.from, link.to)
+		edge.SetAttribute(LabelAttribute, link.label)
+		assert.NoError(t, g.AddEdge(edge))
+	}
+	return g
+}
+
+func ends(path []graph.Edge) [][2]int64 {
+	res := make([][2]int64, len(path))
+	for i, edge := range path {
+		res[i] = [2]int64{edge.From(), edge.To()}
+	}
+	return res
+}
+
+func TestEvaluate(t *testing.T) {
+	g := system(t)
+	tests := []struct {
+		expr  string
+		start int64
+		nodes []int64
+	}{
+		{"calls", 1, []int64{2}},
+		{"calls+", 1, []int64{1, 2, 3}},
+		{"calls*", 4, []int64{4}},
+		{"(calls|publishes)+ / reads", 1, []int64{10, 30, 50}},
+		{"(calls|publishes)+ / reads", 4, []int64{50}},
+		{"calls / calls? / reads", 1, []int64{30}},
+		{"_ / _", 4, []int64{30, 50}},
+		{"publishes / publishes / owns", 2, []int64{30}},
+		{"publishes / owns", 2, []int64{}},
+		{`"calls" / ("reads")`, 3, []int64{10}},
+	}
+	for _, test := range tests {
+		q, err := Compile(test.expr)
+		if !assert.NoError(t, err, test.expr) {
+			continue
+		}
+		res, err := q.Evaluate(g, test.start, false)
+		assert.NoError(t, err, test.expr)
+		assert.Equal(t, test.nodes, res.Nodes, test.expr)
+		assert.Nil(t, res.Paths)
+	}
+
+	// Unlabelled edges only match the wildcard
+	assert.NoError(t, g.AddNode(nodes.NewSimpleNode(6)))
+	assert.NoError(t, g.AddEdge(edges.NewDirectedEdge(5, 6)))
+	q, _ := Compile("publishes / _")
+	res, _ := q.Evaluate(g, 4, false)
+	assert.Equal(t, []int64{6, 30, 50}, res.Nodes)
+}
+
+func TestWitnesses(t *testing.T) {
+	g := system(t)
+	q, err := Compile("(calls|publishes)+ / reads")
+	assert.NoError(t, err)
+	res, err := q.Evaluate(g, 1, true)
+	assert.NoError(t, err)
+	assert.Equal(t, [][2]int64{{1, 2}, {2, 3}, {3, 1}, {1, 10}}, ends(res.Paths[10]))
+	assert.Equal(t, [][2]int64{{1, 2}, {2, 3}, {3, 30}}, ends(res.Paths[30]))
+	assert.Equal(t, [][2]int64{{1, 2}, {2, 4}, {4, 5}, {5, 50}}, ends(res.Paths[50]))
+	assert.Len(t, res.Paths, 3)
+
+	q, _ = Compile("calls*")
+	res, _ = q.Evaluate(g, 2, true)
+	assert.Equal(t, []int64{1, 2, 3}, res.Nodes)
+	assert.Equal(t, [][2]int64{}, ends(res.Paths[2]))
+	assert.Equal(t, [][2]int64{{2, 3}, {3, 1}}, ends(res.Paths[1]))
+}
+
+func TestErrors(t *testing.T) {
+	tests := map[string]string{
+		"":              "Empty expression",
+		"  ":            "Empty expression",
+		"calls |":       "Unexpected end of expression",
+		"(calls":        "Unexpected end of expression",
+		"calls)":        "Unexpected ')' at offset 5",
+		"calls / *":     "Unexpected '*' at offset 8",
+		`"calls / read`: "Unterminated label at offset 0",
+	}
+	for expr, message := range tests {
+		_, err := Compile(expr)
+		assert.EqualError(t, err, message, expr)
+	}
+
+	q, _ := Compile("calls")
+	_, err := q.Evaluate(system(t), 7, false)
+	assert.EqualError(t, err, "Unknown node 7")
+	_, err = q.Evaluate(graphs.NewUndirectedGraph(), 1, false)
+	assert.EqualError(t, err, "Regular path queries require a directed graph")
+}
@@ -0,0 +1,110 @@
+package similarity
+
+// Copyright © 2018 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"container/heap"
+	"sort"
+)
+
+// Prediction is a suggested link between two nodes that are not already
+// joined by an edge
+type Prediction struct {
+	// From is the lower of the two node IDs
+	From int64
+	// To is the higher of the two node IDs
+	To    int64
+	Score float64
+}
+
+// PredictLinks returns the k pairs of distinct, unconnected nodes with the
+// highest positive scores, highest first.  Pairs with equal scores are in
+// order of their node IDs.  A k of 0 or less returns every pair with a
+// positive score.
+//
+// Neighbourhood measures only consider nodes two steps apart, as no other
+// pairs can share a neighbour
+func (s *Similarity) PredictLinks(measure Measure, k int) []Prediction {
+	top := &predictionHeap{}
+	consider := func(x, y int) {
+		score := s.score(measure, x, y)
+		if score <= 0 {
+			return
+		}
+		heap.Push(top, Prediction{From: s.ids[x], To: s.ids[y], Score: score})
+		if k > 0 && top.Len() > k {
+			heap.Pop(top)
+		}
+	}
+
+	switch measure {
+	case CommonNeighbours, Jaccard, AdamicAdar, ResourceAllocation:
+		mark := make([]int, len(s.ids))
+		for i := range mark {
+			mark[i] = -1
+		}
+		for x := range s.ids {
+			candidates := make([]int, 0)
+			for _, z := range s.neighbours[x] {
+				for _, y := range s.neighbours[z] {
+					if y > x && mark[y] != x && !s.adjacent(x, y) {
+						mark[y] = x
+						candidates = append(candidates, y)
+					}
+				}
+			}
+			sort.Ints(candidates)
+			for _, y := range candidates {
+				consider(x, y)
+			}
+		}
+	default:
+		for x := range s.ids {
+			for y := x + 1; y < len(s.ids); y++ {
+				if !s.adjacent(x, y) {
+					consider(x, y)
+				}
+			}
+		}
+	}
+
+	res := make([]Prediction, top.Len())
+	for i := len(res) - 1; i >= 0; i-- {
+		res[i] = heap.Pop(top).(Prediction)
+	}
+	return res
+}
+
+// predictionHeap keeps the worst prediction at the top so that it can be
+// dropped when a better one arrives
+type predictionHeap []Prediction
+
+func (h predictionHeap) Len() int { return len(h) }
+func (h predictionHeap) Less(i, j int) bool {
+	if h[i].Score != h[j].Score {
+		return h[i].Score < h[j].Score
+	}
+	if h[i].From != h[j].From {
+		return h[i].From > h[j].From
+	}
+	return h[i].To > h[j].To
+}
+func (h predictionHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *predictionHeap) Push(x interface{}) { *h = append(*h, x.(Prediction)) }
+func (h *predictionHeap) Pop() interface{} {
+	old := *h
+	item := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return item
+}
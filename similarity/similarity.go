@@ -0,0 +1,191 @@
+package similarity
+
+// Copyright © 2018 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"math"
+	"sort"
+
+	"github.com/wealdtech/go-graph"
+)
+
+// Measure is a way of scoring the similarity of two nodes
+type Measure int
+
+const (
+	// CommonNeighbours is the number of neighbours the nodes share
+	CommonNeighbours Measure = iota
+	// Jaccard is the number of shared neighbours divided by the number of
+	// nodes neighbouring either node
+	Jaccard
+	// AdamicAdar sums 1/log(degree) over the shared neighbours, so that
+	// sharing a rarely-connected neighbour counts for more
+	AdamicAdar
+	// ResourceAllocation sums 1/degree over the shared neighbours
+	ResourceAllocation
+	// PreferentialAttachment is the product of the nodes' degrees
+	PreferentialAttachment
+	// SimRank scores nodes as similar if their predecessors are similar
+	SimRank
+)
+
+// Similarity scores pairs of nodes in a graph.  Neighbours are the nodes
+// joined to a node by an edge in either direction, ignoring self-loops.
+// SimRank instead follows edges backwards in directed graphs, as in its
+// original definition.  The graph should not change while it is in use
+type Similarity struct {
+	ids        []int64
+	index      map[int64]int
+	neighbours [][]int
+	// Predecessors, for SimRank
+	in         [][]int
+	decay      float64
+	iterations int
+	simRank    [][]float64
+}
+
+// New creates a similarity scorer for a graph.  SimRank uses a decay of
+// 0.8 and 10 iterations unless changed with SetSimRank
+func New(g graph.Graph) *Similarity {
+	directed := graph.IsDirected(g)
+	nodes := g.Nodes()
+	s := &Similarity{
+		ids:        make([]int64, 0, len(nodes)),
+		index:      make(map[int64]int, len(nodes)),
+		decay:      0.8,
+		iterations: 10,
+	}
+	for _, node := range nodes {
+		s.ids = append(s.ids, node.Id())
+	}
+	sort.Slice(s.ids, func(i, j int) bool { return s.ids[i] < s.ids[j] })
+	for i, nid := range s.ids {
+		s.index[nid] = i
+	}
+
+	s.neighbours = make([][]int, len(s.ids))
+	s.in = make([][]int, len(s.ids))
+	for v, nid := range s.ids {
+		for _, edge := range g.Edges(nid) {
+			a, aok := s.index[edge.From()]
+			b, bok := s.index[edge.To()]
+			if !aok || !bok || a != v || a == b {
+				continue
+			}
+			s.neighbours[a] = append(s.neighbours[a], b)
+			s.neighbours[b] = append(s.neighbours[b], a)
+			s.in[b] = append(s.in[b], a)
+			if !directed {
+				s.in[a] = append(s.in[a], b)
+			}
+		}
+	}
+	for _, lists := range [][][]int{s.neighbours, s.in} {
+		for i := range lists {
+			lists[i] = dedupe(lists[i])
+		}
+	}
+	return s
+}
+
+func dedupe(list []int) []int {
+	sort.Ints(list)
+	res := list[:0]
+	for i, v := range list {
+		if i == 0 || list[i-1] != v {
+			res = append(res, v)
+		}
+	}
+	return res
+}
+
+// SetSimRank sets the decay factor and number of iterations used by
+// SimRank, discarding any scores already calculated
+func (s *Similarity) SetSimRank(decay float64, iterations int) {
+	s.decay = decay
+	s.iterations = iterations
+	s.simRank = nil
+}
+
+// Score returns the similarity of two nodes.  Unknown nodes score 0
+func (s *Similarity) Score(measure Measure, a, b int64) float64 {
+	x, aok := s.index[a]
+	y, bok := s.index[b]
+	if !aok || !bok {
+		return 0
+	}
+	return s.score(measure, x, y)
+}
+
+func (s *Similarity) score(measure Measure, x, y int) float64 {
+	switch measure {
+	case CommonNeighbours:
+		return float64(len(s.common(x, y)))
+	case Jaccard:
+		shared := len(s.common(x, y))
+		union := len(s.neighbours[x]) + len(s.neighbours[y]) - shared
+		if union == 0 {
+			return 0
+		}
+		return float64(shared) / float64(union)
+	case AdamicAdar:
+		total := 0.0
+		for _, z := range s.common(x, y) {
+			// Shared neighbours of two distinct nodes have degree at least 2
+			if degree := len(s.neighbours[z]); degree > 1 {
+				total += 1 / math.Log(float64(degree))
+			}
+		}
+		return total
+	case ResourceAllocation:
+		total := 0.0
+		for _, z := range s.common(x, y) {
+			total += 1 / float64(len(s.neighbours[z]))
+		}
+		return total
+	case PreferentialAttachment:
+		return float64(len(s.neighbours[x]) * len(s.neighbours[y]))
+	case SimRank:
+		return s.simRanks()[x][y]
+	default:
+		return 0
+	}
+}
+
+// common returns the shared neighbours of two nodes
+func (s *Similarity) common(x, y int) []int {
+	a, b := s.neighbours[x], s.neighbours[y]
+	res := make([]int, 0)
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i] < b[j]:
+			i++
+		case a[i] > b[j]:
+			j++
+		default:
+			res = append(res, a[i])
+			i++
+			j++
+		}
+	}
+	return res
+}
+
+// adjacent returns true if two nodes are joined by an edge in either
+// direction
+func (s *Similarity) adjacent(x, y int) bool {
+	list := s.neighbours[x]
+	i := sort.SearchInts(list, y)
+	return i < len(list) && list[i] == y
+}
@@ -0,0 +1,102 @@
+package similarity
+
+// Copyright © 2018 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wealdtech/go-graph/edges"
+	"github.com/wealdtech/go-graph/graphs"
+	"github.com/wealdtech/go-graph/nodes"
+)
+
+// services creates an undirected graph where 1 and 2 both talk to 3, 4
+// and 5, 5 also talks to 6, and 6 talks to 7
+func services(t *testing.T) *graphs.UndirectedGraph {
+	g := graphs.NewUndirectedGraph()
+	for i := int64(1); i <= 7; i++ {
+		assert.NoError(t, g.AddNode(nodes.NewSimpleNode(i)))
+	}
+	for _, pair := range [][2]int64{{1, 3}, {1, 4}, {1, 5}, {2, 3}, {2, 4}, {2, 5}, {5, 6}, {6, 7}} {
+		assert.NoError(t, g.AddEdge(edges.NewUndirectedEdge(pair[0], pair[1])))
+	}
+	return g
+}
+
+func TestNeighbourhoodMeasures(t *testing.T) {
+	s := New(services(t))
+
+	assert.Equal(t, 3.0, s.Score(CommonNeighbours, 1, 2))
+	assert.Equal(t, 1.0, s.Score(Jaccard, 1, 2))
+	assert.Equal(t, 0.25, s.Score(Jaccard, 1, 6))
+	assert.InDelta(t, 2/math.Log(2)+1/math.Log(3), s.Score(AdamicAdar, 1, 2), 1e-9)
+	assert.InDelta(t, 0.5+0.5+1.0/3, s.Score(ResourceAllocation, 1, 2), 1e-9)
+	assert.Equal(t, 9.0, s.Score(PreferentialAttachment, 1, 2))
+	assert.Equal(t, 0.0, s.Score(CommonNeighbours, 1, 7))
+	assert.Equal(t, 0.0, s.Score(CommonNeighbours, 1, 8))
+}
+
+func TestSimRank(t *testing.T) {
+	// 1 points to 2 and 3, so 2 and 3 are similar
+	g := graphs.NewDirectedGraph()
+	for i := int64(1); i <= 4; i++ {
+		assert.NoError(t, g.AddNode(nodes.NewSimpleNode(i)))
+	}
+	for _, pair := range [][2]int64{{1, 2}, {1, 3}, {4, 3}} {
+		assert.NoError(t, g.AddEdge(edges.NewDirectedEdge(pair[0], pair[1])))
+	}
+	s := New(g)
+	// 2's predecessors are {1}, 3's are {1, 4}: 0.8 * (1 + 0) / 2
+	assert.InDelta(t, 0.4, s.Score(SimRank, 2, 3), 1e-9)
+	assert.Equal(t, 1.0, s.Score(SimRank, 4, 4))
+	assert.Equal(t, 0.0, s.Score(SimRank, 1, 4))
+
+	s.SetSimRank(0.5, 10)
+	assert.InDelta(t, 0.25, s.Score(SimRank, 2, 3), 1e-9)
+}
+
+func TestPredictLinks(t *testing.T) {
+	s := New(services(t))
+
+	predictions := s.PredictLinks(CommonNeighbours, 2)
+	assert.Equal(t, []Prediction{
+		{From: 1, To: 2, Score: 3},
+		{From: 3, To: 4, Score: 2},
+	}, predictions)
+
+	// Existing edges are never suggested
+	for _, p := range s.PredictLinks(PreferentialAttachment, 0) {
+		assert.False(t, p.From == 1 && p.To == 3)
+		assert.True(t, p.From < p.To)
+	}
+
+	all := s.PredictLinks(ResourceAllocation, 0)
+	assert.Equal(t, Prediction{From: 1, To: 2, Score: 0.5 + 0.5 + 1.0/3}, all[0])
+	for i := 1; i < len(all); i++ {
+		assert.True(t, all[i-1].Score >= all[i].Score)
+	}
+	// Pairs two steps apart: 1-2, 3-4, 3-5, 4-5, 1-6, 2-6, 5-7
+	assert.Len(t, all, 7)
+
+	// 3 and 4 share both of their neighbours, so beat 1 and 2 which share
+	// three of three but average over more pairs
+	simRank := s.PredictLinks(SimRank, 2)
+	assert.Len(t, simRank, 2)
+	assert.Equal(t, int64(3), simRank[0].From)
+	assert.Equal(t, int64(4), simRank[0].To)
+	assert.Equal(t, int64(1), simRank[1].From)
+	assert.Equal(t, int64(2), simRank[1].To)
+}
@@ -0,0 +1,75 @@
+package similarity
+
+// Copyright © 2018 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// simRanks returns the SimRank score of every pair of nodes, calculating
+// them on first use.  Each node scores 1 with itself; other pairs score
+// the decay times the average score of their predecessors' pairs.  This
+// takes space quadratic in the number of nodes, so suits graphs of up to
+// a few thousand nodes
+func (s *Similarity) simRanks() [][]float64 {
+	if s.simRank != nil {
+		return s.simRank
+	}
+	n := len(s.ids)
+	current := identity(n)
+	// partial[a][j] is the sum of the scores of j with a's predecessors
+	partial := make([][]float64, n)
+	for a := range partial {
+		partial[a] = make([]float64, n)
+	}
+	for iteration := 0; iteration < s.iterations; iteration++ {
+		for a := 0; a < n; a++ {
+			row := partial[a]
+			for j := range row {
+				row[j] = 0
+			}
+			for _, i := range s.in[a] {
+				for j, score := range current[i] {
+					row[j] += score
+				}
+			}
+		}
+		next := identity(n)
+		for a := 0; a < n; a++ {
+			if len(s.in[a]) == 0 {
+				continue
+			}
+			for b := a + 1; b < n; b++ {
+				if len(s.in[b]) == 0 {
+					continue
+				}
+				total := 0.0
+				for _, j := range s.in[b] {
+					total += partial[a][j]
+				}
+				score := s.decay * total / float64(len(s.in[a])*len(s.in[b]))
+				next[a][b] = score
+				next[b][a] = score
+			}
+		}
+		current = next
+	}
+	s.simRank = current
+	return current
+}
+
+func identity(n int) [][]float64 {
+	res := make([][]float64, n)
+	for i := range res {
+		res[i] = make([]float64, n)
+		res[i][i] = 1
+	}
+	return res
+}
@@ -0,0 +1,142 @@
+package structure
+
+// Copyright © 2018 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"sort"
+
+	"github.com/wealdtech/go-graph"
+	"github.com/wealdtech/go-graph/internal/adjacency"
+)
+
+// lists are a graph's adjacency lists, with each link between two nodes
+// numbered for the structural measures
+type lists struct {
+	*adjacency.Lists
+	// offsets number the links consecutively, so the link from i to its
+	// k-th neighbour is offsets[i]+k
+	offsets []int
+}
+
+func newLists(g graph.Graph) *lists {
+	a := &lists{Lists: adjacency.New(g)}
+	a.offsets = make([]int, len(a.IDs)+1)
+	for i, list := range a.Neighbours {
+		a.offsets[i+1] = a.offsets[i] + len(list)
+	}
+	return a
+}
+
+func (a *lists) size() int {
+	return len(a.IDs)
+}
+
+// links returns the number of links, which is twice the number of edges
+func (a *lists) links() int {
+	return a.offsets[len(a.IDs)]
+}
+
+func (a *lists) degree(i int32) int {
+	return len(a.Neighbours[i])
+}
+
+func (a *lists) adjacent(i int32) []int32 {
+	return a.Neighbours[i]
+}
+
+// position returns the index in neighbours of the link from i to j, or -1
+// if there is no such link
+func (a *lists) position(i, j int32) int {
+	list := a.adjacent(i)
+	k := sort.Search(len(list), func(x int) bool { return list[x] >= j })
+	if k < len(list) && list[k] == j {
+		return a.offsets[i] + k
+	}
+	return -1
+}
+
+// intersect calls fn for each common neighbour of i and j
+func (a *lists) intersect(i, j int32, fn func(w int32)) {
+	x := a.adjacent(i)
+	y := a.adjacent(j)
+	for len(x) > 0 && len(y) > 0 {
+		switch {
+		case x[0] < y[0]:
+			x = x[1:]
+		case x[0] > y[0]:
+			y = y[1:]
+		default:
+			fn(x[0])
+			x = x[1:]
+			y = y[1:]
+		}
+	}
+}
+
+// bucketQueue orders items by an integer key, allowing keys to be
+// decremented while items are processed in order of increasing key.  This
+// is the bucket structure of Batagelj and Zaversnik
+type bucketQueue struct {
+	keys  []int
+	order []int
+	pos   []int
+	bins  []int
+}
+
+func newBucketQueue(keys []int) *bucketQueue {
+	max := 0
+	for _, k := range keys {
+		if k > max {
+			max = k
+		}
+	}
+	q := &bucketQueue{
+		keys:  keys,
+		order: make([]int, len(keys)),
+		pos:   make([]int, len(keys)),
+		bins:  make([]int, max+1),
+	}
+	for _, k := range keys {
+		q.bins[k]++
+	}
+	start := 0
+	for k := range q.bins {
+		count := q.bins[k]
+		q.bins[k] = start
+		start += count
+	}
+	next := make([]int, len(q.bins))
+	copy(next, q.bins)
+	for i, k := range keys {
+		q.pos[i] = next[k]
+		q.order[q.pos[i]] = i
+		next[k]++
+	}
+	return q
+}
+
+// decrement reduces the key of an item by one, keeping the order sorted
+func (q *bucketQueue) decrement(i int) {
+	k := q.keys[i]
+	first := q.bins[k]
+	other := q.order[first]
+	if other != i {
+		q.order[q.pos[i]] = other
+		q.pos[other] = q.pos[i]
+		q.order[first] = i
+		q.pos[i] = first
+	}
+	q.bins[k]++
+	q.keys[i]--
+}
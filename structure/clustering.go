@@ -0,0 +1,77 @@
+package structure
+
+// Copyright © 2018 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"github.com/wealdtech/go-graph"
+)
+
+// Clustering returns the local clustering coefficient of each node in an
+// undirected graph.  Nodes with fewer than two neighbours have a
+// coefficient of 0
+func Clustering(g graph.Graph) map[int64]float64 {
+	a := newLists(g)
+	coefficients := a.clustering()
+	res := make(map[int64]float64, a.size())
+	for i, nid := range a.IDs {
+		res[nid] = coefficients[i]
+	}
+	return res
+}
+
+// AverageClustering returns the mean local clustering coefficient of the
+// nodes in an undirected graph
+func AverageClustering(g graph.Graph) float64 {
+	a := newLists(g)
+	if a.size() == 0 {
+		return 0
+	}
+	total := 0.0
+	for _, c := range a.clustering() {
+		total += c
+	}
+	return total / float64(a.size())
+}
+
+// Transitivity returns the global clustering coefficient of an undirected
+// graph: the fraction of connected triples of nodes that form triangles
+func Transitivity(g graph.Graph) float64 {
+	a := newLists(g)
+	triangles := int64(0)
+	a.triangles(func(x, y, z int32) bool {
+		triangles++
+		return true
+	})
+	triples := int64(0)
+	for i := int32(0); int(i) < a.size(); i++ {
+		d := int64(a.degree(i))
+		triples += d * (d - 1) / 2
+	}
+	if triples == 0 {
+		return 0
+	}
+	return float64(3*triangles) / float64(triples)
+}
+
+func (a *lists) clustering() []float64 {
+	triangles := a.nodeTriangles()
+	res := make([]float64, a.size())
+	for i := range res {
+		d := float64(a.degree(int32(i)))
+		if d >= 2 {
+			res[i] = 2 * float64(triangles[i]) / (d * (d - 1))
+		}
+	}
+	return res
+}
@@ -0,0 +1,61 @@
+package structure
+
+// Copyright © 2018 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"github.com/wealdtech/go-graph"
+)
+
+// CoreNumbers returns the core number of each node in an undirected graph:
+// the largest k for which the node is part of the k-core.  This runs in
+// O(m) time
+func CoreNumbers(g graph.Graph) map[int64]int {
+	a := newLists(g)
+	cores := a.coreNumbers()
+	res := make(map[int64]int, a.size())
+	for i, nid := range a.IDs {
+		res[nid] = cores[i]
+	}
+	return res
+}
+
+// KCore returns the IDs of the nodes in the k-core of an undirected graph:
+// the largest subgraph in which every node has at least k neighbours.  IDs
+// are in ascending order
+func KCore(g graph.Graph, k int) []int64 {
+	a := newLists(g)
+	res := make([]int64, 0)
+	for i, core := range a.coreNumbers() {
+		if core >= k {
+			res = append(res, a.IDs[i])
+		}
+	}
+	return res
+}
+
+func (a *lists) coreNumbers() []int {
+	keys := make([]int, a.size())
+	for i := range keys {
+		keys[i] = a.degree(int32(i))
+	}
+	q := newBucketQueue(keys)
+	for _, v := range q.order {
+		for _, w := range a.adjacent(int32(v)) {
+			if q.keys[w] > q.keys[v] {
+				q.decrement(int(w))
+			}
+		}
+	}
+	return q.keys
+}
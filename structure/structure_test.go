@@ -0,0 +1,101 @@
+package structure
+
+// Copyright © 2018 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wealdtech/go-graph/edges"
+	"github.com/wealdtech/go-graph/graphs"
+	"github.com/wealdtech/go-graph/nodes"
+)
+
+// testGraph creates a 4-clique (1-4), a triangle (4-5-6) and a tail (6-7)
+func testGraph(t *testing.T) *graphs.UndirectedGraph {
+	g := graphs.NewUndirectedGraph()
+	for i := int64(1); i <= 7; i++ {
+		assert.NoError(t, g.AddNode(nodes.NewSimpleNode(i)))
+	}
+	for _, pair := range [][2]int64{{1, 2}, {1, 3}, {1, 4}, {2, 3}, {2, 4}, {3, 4}, {4, 5}, {5, 6}, {4, 6}, {6, 7}} {
+		assert.NoError(t, g.AddEdge(edges.NewUndirectedEdge(pair[0], pair[1])))
+	}
+	return g
+}
+
+func TestTriangles(t *testing.T) {
+	g := testGraph(t)
+	assert.Equal(t, int64(5), TriangleCount(g))
+
+	triangles := make(map[[3]int64]bool)
+	Triangles(g, func(a, b, c int64) bool {
+		triangles[[3]int64{a, b, c}] = true
+		return true
+	})
+	assert.Equal(t, map[[3]int64]bool{
+		{1, 2, 3}: true,
+		{1, 2, 4}: true,
+		{1, 3, 4}: true,
+		{2, 3, 4}: true,
+		{4, 5, 6}: true,
+	}, triangles)
+
+	// Stop early
+	count := 0
+	Triangles(g, func(a, b, c int64) bool {
+		count++
+		return false
+	})
+	assert.Equal(t, 1, count)
+
+	assert.Equal(t, map[int64]int64{1: 3, 2: 3, 3: 3, 4: 4, 5: 1, 6: 1, 7: 0}, NodeTriangles(g))
+}
+
+func TestClustering(t *testing.T) {
+	g := testGraph(t)
+	clustering := Clustering(g)
+	assert.InDelta(t, 1.0, clustering[1], 1e-9)
+	assert.InDelta(t, 0.4, clustering[4], 1e-9)
+	assert.InDelta(t, 1.0, clustering[5], 1e-9)
+	assert.InDelta(t, 1.0/3.0, clustering[6], 1e-9)
+	assert.InDelta(t, 0.0, clustering[7], 1e-9)
+
+	assert.InDelta(t, (3+0.4+1+1.0/3.0)/7, AverageClustering(g), 1e-9)
+	assert.InDelta(t, 15.0/23.0, Transitivity(g), 1e-9)
+
+	assert.Equal(t, 0.0, AverageClustering(graphs.NewUndirectedGraph()))
+	assert.Equal(t, 0.0, Transitivity(graphs.NewUndirectedGraph()))
+}
+
+func TestCores(t *testing.T) {
+	g := testGraph(t)
+	assert.Equal(t, map[int64]int{1: 3, 2: 3, 3: 3, 4: 3, 5: 2, 6: 2, 7: 1}, CoreNumbers(g))
+	assert.Equal(t, []int64{1, 2, 3, 4}, KCore(g, 3))
+	assert.Equal(t, []int64{1, 2, 3, 4, 5, 6}, KCore(g, 2))
+	assert.Len(t, KCore(g, 4), 0)
+}
+
+func TestTruss(t *testing.T) {
+	g := testGraph(t)
+	trusses := TrussNumbers(g)
+	assert.Len(t, trusses, 10)
+	assert.Equal(t, 4, trusses[EdgeKey{From: 1, To: 2}])
+	assert.Equal(t, 4, trusses[EdgeKey{From: 3, To: 4}])
+	assert.Equal(t, 3, trusses[EdgeKey{From: 4, To: 5}])
+	assert.Equal(t, 3, trusses[EdgeKey{From: 4, To: 6}])
+	assert.Equal(t, 2, trusses[EdgeKey{From: 6, To: 7}])
+
+	assert.Equal(t, []EdgeKey{{1, 2}, {1, 3}, {1, 4}, {2, 3}, {2, 4}, {3, 4}}, KTruss(g, 4))
+	assert.Len(t, KTruss(g, 3), 9)
+}
@@ -0,0 +1,120 @@
+package structure
+
+// Copyright © 2018 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"sort"
+
+	"github.com/wealdtech/go-graph"
+)
+
+// Triangles calls fn once for each triangle in an undirected graph, with
+// the node IDs in ascending order.  Enumeration stops if fn returns false
+func Triangles(g graph.Graph, fn func(a, b, c int64) bool) {
+	a := newLists(g)
+	a.triangles(func(x, y, z int32) bool {
+		ids := []int64{a.IDs[x], a.IDs[y], a.IDs[z]}
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+		return fn(ids[0], ids[1], ids[2])
+	})
+}
+
+// TriangleCount returns the number of triangles in an undirected graph
+func TriangleCount(g graph.Graph) int64 {
+	count := int64(0)
+	newLists(g).triangles(func(x, y, z int32) bool {
+		count++
+		return true
+	})
+	return count
+}
+
+// NodeTriangles returns the number of triangles that each node in an
+// undirected graph is part of
+func NodeTriangles(g graph.Graph) map[int64]int64 {
+	a := newLists(g)
+	counts := a.nodeTriangles()
+	res := make(map[int64]int64, a.size())
+	for i, nid := range a.IDs {
+		res[nid] = counts[i]
+	}
+	return res
+}
+
+func (a *lists) nodeTriangles() []int64 {
+	counts := make([]int64, a.size())
+	a.triangles(func(x, y, z int32) bool {
+		counts[x]++
+		counts[y]++
+		counts[z]++
+		return true
+	})
+	return counts
+}
+
+// triangles enumerates each triangle once using the forward algorithm,
+// orienting every edge from lower to higher degree so that the work is
+// O(m^1.5)
+func (a *lists) triangles(fn func(x, y, z int32) bool) {
+	n := a.size()
+	rank := make([]int, n)
+	order := make([]int32, n)
+	for i := range order {
+		order[i] = int32(i)
+	}
+	sort.Slice(order, func(x, y int) bool {
+		dx := a.degree(order[x])
+		dy := a.degree(order[y])
+		if dx != dy {
+			return dx < dy
+		}
+		return order[x] < order[y]
+	})
+	for r, i := range order {
+		rank[i] = r
+	}
+
+	// Build the oriented adjacency lists, sorted by rank
+	forward := make([][]int32, n)
+	for i := int32(0); int(i) < n; i++ {
+		for _, j := range a.adjacent(i) {
+			if rank[j] > rank[i] {
+				forward[i] = append(forward[i], j)
+			}
+		}
+		list := forward[i]
+		sort.Slice(list, func(x, y int) bool { return rank[list[x]] < rank[list[y]] })
+	}
+
+	for _, u := range order {
+		for _, v := range forward[u] {
+			x := forward[u]
+			y := forward[v]
+			for len(x) > 0 && len(y) > 0 {
+				switch {
+				case rank[x[0]] < rank[y[0]]:
+					x = x[1:]
+				case rank[x[0]] > rank[y[0]]:
+					y = y[1:]
+				default:
+					if !fn(u, v, x[0]) {
+						return
+					}
+					x = x[1:]
+					y = y[1:]
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package structure
+
+// Copyright © 2018 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"sort"
+
+	"github.com/wealdtech/go-graph"
+)
+
+// EdgeKey identifies an undirected edge by its end nodes, lowest ID first
+type EdgeKey struct {
+	From int64
+	To   int64
+}
+
+// TrussNumbers returns the truss number of each edge in an undirected
+// graph: the largest k for which the edge is part of the k-truss
+func TrussNumbers(g graph.Graph) map[EdgeKey]int {
+	a := newLists(g)
+	ends, trusses := a.trussNumbers()
+	res := make(map[EdgeKey]int, len(trusses))
+	for e, truss := range trusses {
+		res[EdgeKey{From: a.IDs[ends[e][0]], To: a.IDs[ends[e][1]]}] = truss
+	}
+	return res
+}
+
+// KTruss returns the edges in the k-truss of an undirected graph: the
+// largest subgraph in which every edge is part of at least k-2 triangles.
+// Edges are in ascending order
+func KTruss(g graph.Graph, k int) []EdgeKey {
+	a := newLists(g)
+	ends, trusses := a.trussNumbers()
+	res := make([]EdgeKey, 0)
+	for e, truss := range trusses {
+		if truss >= k {
+			res = append(res, EdgeKey{From: a.IDs[ends[e][0]], To: a.IDs[ends[e][1]]})
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].From != res[j].From {
+			return res[i].From < res[j].From
+		}
+		return res[i].To < res[j].To
+	})
+	return res
+}
+
+// trussNumbers returns the end nodes and truss number of each edge, by
+// repeatedly peeling the edge with the lowest triangle support
+func (a *lists) trussNumbers() ([][2]int32, []int) {
+	// Number each edge, and map both directions of the link to it
+	edgeIDs := make([]int, a.links())
+	ends := make([][2]int32, 0, a.links()/2)
+	for i := int32(0); int(i) < a.size(); i++ {
+		for k, j := range a.adjacent(i) {
+			if j > i {
+				edgeIDs[a.offsets[i]+k] = len(ends)
+				ends = append(ends, [2]int32{i, j})
+			}
+		}
+	}
+	for i := int32(0); int(i) < a.size(); i++ {
+		for k, j := range a.adjacent(i) {
+			if j < i {
+				edgeIDs[a.offsets[i]+k] = edgeIDs[a.position(j, i)]
+			}
+		}
+	}
+
+	support := make([]int, len(ends))
+	for e, end := range ends {
+		a.intersect(end[0], end[1], func(w int32) {
+			support[e]++
+		})
+	}
+
+	q := newBucketQueue(support)
+	removed := make([]bool, len(ends))
+	for _, e := range q.order {
+		u, v := ends[e][0], ends[e][1]
+		a.intersect(u, v, func(w int32) {
+			uw := edgeIDs[a.position(u, w)]
+			vw := edgeIDs[a.position(v, w)]
+			if removed[uw] || removed[vw] {
+				return
+			}
+			if q.keys[uw] > q.keys[e] {
+				q.decrement(uw)
+			}
+			if q.keys[vw] > q.keys[e] {
+				q.decrement(vw)
+			}
+		})
+		removed[e] = true
+	}
+
+	trusses := make([]int, len(ends))
+	for e := range trusses {
+		trusses[e] = q.keys[e] + 2
+	}
+	return ends, trusses
+}
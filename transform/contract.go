@@ -0,0 +1,256 @@
+package transform
+
+// Copyright © 2018 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/wealdtech/go-graph"
+	"github.com/wealdtech/go-graph/internal/build"
+)
+
+// Merge combines the values that merged nodes or edges have for an
+// attribute.  The values are in order of the merged nodes' IDs, leaving
+// out those without the attribute, and there are always at least two
+type Merge func(key interface{}, values []interface{}) interface{}
+
+// First keeps the first value
+func First(key interface{}, values []interface{}) interface{} {
+	return values[0]
+}
+
+// Last keeps the last value
+func Last(key interface{}, values []interface{}) interface{} {
+	return values[len(values)-1]
+}
+
+// Sum adds up the numeric values as a float64, ignoring other values
+func Sum(key interface{}, values []interface{}) interface{} {
+	res := 0.0
+	for _, value := range values {
+		switch v := value.(type) {
+		case float64:
+			res += v
+		case float32:
+			res += float64(v)
+		case int:
+			res += float64(v)
+		case int64:
+			res += float64(v)
+		case int32:
+			res += float64(v)
+		case uint:
+			res += float64(v)
+		case uint64:
+			res += float64(v)
+		case uint32:
+			res += float64(v)
+		}
+	}
+	return res
+}
+
+// Collect keeps all of the values in a slice
+func Collect(key interface{}, values []interface{}) interface{} {
+	return append([]interface{}{}, values...)
+}
+
+// mergeAttributes combines sets of attributes, merging the values of any
+// attribute in more than one set
+func mergeAttributes(sets []*map[interface{}]interface{}, merge Merge) map[interface{}]interface{} {
+	values := make(map[interface{}][]interface{})
+	keys := make([]interface{}, 0)
+	for _, attrs := range sets {
+		if attrs == nil {
+			continue
+		}
+		for key, value := range *attrs {
+			if _, exists := values[key]; !exists {
+				keys = append(keys, key)
+			}
+			values[key] = append(values[key], value)
+		}
+	}
+	res := make(map[interface{}]interface{}, len(keys))
+	for _, key := range keys {
+		if len(values[key]) == 1 {
+			res[key] = values[key][0]
+		} else {
+			res[key] = merge(key, values[key])
+		}
+	}
+	return res
+}
+
+// ContractEdge returns a graph with an edge contracted, merging its end
+// into its start as with MergeNodes
+func ContractEdge(g graph.Graph, from, to int64, merge Merge) (graph.Graph, error) {
+	if !g.HasEdge(from, to) {
+		return nil, fmt.Errorf("Edge from %v to %v does not exist", from, to)
+	}
+	return MergeNodes(g, []int64{from, to}, from, merge)
+}
+
+// MergeNodes returns a graph with the given nodes replaced by a single
+// node with a new ID, which may be one of the merged IDs.  Edges to the
+// merged nodes are moved to the new node, and edges between them are
+// dropped, though self-loops are kept.  The new node combines the
+// attributes of the merged nodes, as do edges combined by the move, using
+// the merge function for attributes that more than one of them has; a nil
+// merge function is the same as First.  Everything else keeps its
+// attributes and the graph keeps its defaults
+func MergeNodes(g graph.Graph, ids []int64, newID int64, merge Merge) (graph.Graph, error) {
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("No nodes to merge")
+	}
+	merged := make(map[int64]bool)
+	for _, nid := range ids {
+		if !g.HasNode(nid) {
+			return nil, fmt.Errorf("Unknown node %v", nid)
+		}
+		merged[nid] = true
+	}
+	if g.HasNode(newID) && !merged[newID] {
+		return nil, fmt.Errorf("Node %v already exists", newID)
+	}
+	return regroup(g, func(nid int64) int64 {
+		if merged[nid] {
+			return newID
+		}
+		return nid
+	}, merge)
+}
+
+// regroup returns a graph with each node replaced by the node for its
+// group, merging attributes where several nodes or edges fall together
+// and dropping edges within a group
+func regroup(g graph.Graph, group func(int64) int64, merge Merge) (graph.Graph, error) {
+	if merge == nil {
+		merge = First
+	}
+	directed := graph.IsDirected(g)
+	res := build.New(directed)
+	build.CopyDefaults(res, g)
+
+	members := make(map[int64][]*map[interface{}]interface{})
+	groups := make([]int64, 0)
+	for _, nid := range build.NodeIDs(g) {
+		target := group(nid)
+		if _, exists := members[target]; !exists {
+			groups = append(groups, target)
+		}
+		members[target] = append(members[target], g.Node(nid).Attributes())
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i] < groups[j] })
+	for _, target := range groups {
+		if err := build.AddNode(res, target, mergeAttributes(members[target], merge)); err != nil {
+			return nil, err
+		}
+	}
+
+	edgeAttrs := make(map[[2]int64][]*map[interface{}]interface{})
+	keys := make([][2]int64, 0)
+	for _, nid := range build.NodeIDs(g) {
+		for _, edge := range build.OwnedEdges(g, nid) {
+			a, b := group(edge.From()), group(edge.To())
+			if a == b && edge.From() != edge.To() {
+				continue
+			}
+			if !directed && a > b {
+				a, b = b, a
+			}
+			key := [2]int64{a, b}
+			if _, exists := edgeAttrs[key]; !exists {
+				keys = append(keys, key)
+			}
+			edgeAttrs[key] = append(edgeAttrs[key], edge.Attributes())
+		}
+	}
+	for _, key := range keys {
+		if err := build.AddEdge(res, key[0], key[1], mergeAttributes(edgeAttrs[key], merge)); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+// Quotient returns the quotient graph for a partition of a graph's nodes,
+// which has a node for each part, with the ID the partition function
+// gives it, and an edge between two parts wherever the graph has edges
+// between their members.  Each node has a "members" attribute listing the
+// IDs of its members in order and a "count" attribute with their number.
+// Each edge has a "count" attribute with the number of edges it
+// aggregates and a "weight" attribute with the sum of their weights, using
+// unit weights if weight is nil.  Edges within a part are left out.  The
+// quotient keeps the graph's defaults
+func Quotient(g graph.Graph, partition func(graph.Node) int64, weight graph.WeightFunc) (graph.Graph, error) {
+	if weight == nil {
+		weight = graph.UnitWeight
+	}
+	directed := graph.IsDirected(g)
+	res := build.New(directed)
+	build.CopyDefaults(res, g)
+
+	parts := make(map[int64]int64)
+	members := make(map[int64][]int64)
+	for _, nid := range build.NodeIDs(g) {
+		part := partition(g.Node(nid))
+		parts[nid] = part
+		members[part] = append(members[part], nid)
+	}
+	ids := make([]int64, 0, len(members))
+	for part := range members {
+		ids = append(ids, part)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	for _, part := range ids {
+		if err := build.AddNode(res, part, map[interface{}]interface{}{
+			"members": members[part],
+			"count":   len(members[part]),
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	counts := make(map[[2]int64]int)
+	weights := make(map[[2]int64]float64)
+	keys := make([][2]int64, 0)
+	for _, nid := range build.NodeIDs(g) {
+		for _, edge := range build.OwnedEdges(g, nid) {
+			a, b := parts[edge.From()], parts[edge.To()]
+			if a == b {
+				continue
+			}
+			if !directed && a > b {
+				a, b = b, a
+			}
+			key := [2]int64{a, b}
+			if _, exists := counts[key]; !exists {
+				keys = append(keys, key)
+			}
+			counts[key]++
+			weights[key] += weight(edge)
+		}
+	}
+	for _, key := range keys {
+		if err := build.AddEdge(res, key[0], key[1], map[interface{}]interface{}{
+			"count":  counts[key],
+			"weight": weights[key],
+		}); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
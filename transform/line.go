@@ -0,0 +1,77 @@
+package transform
+
+// Copyright © 2018 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"github.com/wealdtech/go-graph"
+	"github.com/wealdtech/go-graph/internal/build"
+)
+
+// LineGraph returns the line graph of a graph, which has a node for each
+// edge.  In an undirected graph two edges are joined if they share an end;
+// in a directed graph there is an edge from each edge to the edges that
+// continue from its end.  Nodes are numbered from 1 in order of the edges'
+// ends, and the ends of the edge behind each node are returned alongside.
+//
+// Each node takes the attributes of its edge, and the node defaults are
+// the original edge defaults.  The graph keeps its graph defaults; the new
+// edges and edge defaults are empty
+func LineGraph(g graph.Graph) (graph.Graph, map[int64][2]int64, error) {
+	directed := graph.IsDirected(g)
+	res := build.New(directed)
+	res.SetGraphDefaults(build.CopyAttributes(g.GraphDefaults()))
+	res.SetNodeDefaults(build.CopyAttributes(g.EdgeDefaults()))
+
+	pairs := make(map[int64][2]int64)
+	// byNode lists the line graph nodes for the edges at each node; for
+	// directed graphs only the edges leaving it
+	byNode := make(map[int64][]int64)
+	next := int64(1)
+	for _, nid := range build.NodeIDs(g) {
+		for _, edge := range build.OwnedEdges(g, nid) {
+			pair := [2]int64{edge.From(), edge.To()}
+			pairs[next] = pair
+			if err := build.AddNode(res, next, build.CopyAttributes(edge.Attributes())); err != nil {
+				return nil, nil, err
+			}
+			byNode[edge.From()] = append(byNode[edge.From()], next)
+			if !directed && edge.To() != edge.From() {
+				byNode[edge.To()] = append(byNode[edge.To()], next)
+			}
+			next++
+		}
+	}
+
+	for id := int64(1); id < next; id++ {
+		pair := pairs[id]
+		if directed {
+			for _, other := range byNode[pair[1]] {
+				if err := build.AddEdge(res, id, other, make(map[interface{}]interface{})); err != nil {
+					return nil, nil, err
+				}
+			}
+			continue
+		}
+		for _, end := range pair {
+			for _, other := range byNode[end] {
+				if other != id {
+					if err := build.AddEdge(res, id, other, make(map[interface{}]interface{})); err != nil {
+						return nil, nil, err
+					}
+				}
+			}
+		}
+	}
+	return res, pairs, nil
+}
@@ -0,0 +1,143 @@
+package transform
+
+// Copyright © 2018 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/wealdtech/go-graph"
+	"github.com/wealdtech/go-graph/internal/build"
+)
+
+// Products have a node for each pair of nodes, one from each graph.  The
+// nodes are numbered from 1 in order of the pairs, and the pair behind
+// each node is returned alongside.  Product nodes have no attributes of
+// their own; the returned pairs can be used to look up the originals.
+// The product takes its defaults from the first graph, so edges from the
+// second graph are given that graph's edge defaults where they differ.
+
+// CartesianProduct returns the Cartesian product of two graphs, which
+// joins (a, b) to (a, d) wherever the second graph joins b to d, and
+// joins (a, b) to (c, b) wherever the first graph joins a to c.  Each
+// edge takes the attributes of the edge it comes from
+func CartesianProduct(g1, g2 graph.Graph) (graph.Graph, map[int64][2]int64, error) {
+	return product(g1, g2, true, false)
+}
+
+// TensorProduct returns the tensor product of two graphs, which joins
+// (a, b) to (c, d) wherever the first graph joins a to c and the second
+// joins b to d.  The edges have no attributes
+func TensorProduct(g1, g2 graph.Graph) (graph.Graph, map[int64][2]int64, error) {
+	return product(g1, g2, false, true)
+}
+
+// StrongProduct returns the strong product of two graphs, which has the
+// edges of both the Cartesian and the tensor products, with attributes as
+// they have them
+func StrongProduct(g1, g2 graph.Graph) (graph.Graph, map[int64][2]int64, error) {
+	return product(g1, g2, true, true)
+}
+
+// arc is an edge followed in one direction
+type arc struct {
+	to   int64
+	edge graph.Edge
+}
+
+// arcs returns the edges leaving each node, in both directions for
+// undirected graphs
+func arcs(g graph.Graph) map[int64][]arc {
+	res := make(map[int64][]arc)
+	for _, nid := range build.NodeIDs(g) {
+		for _, edge := range g.Edges(nid) {
+			to := edge.To()
+			if edge.From() != nid {
+				to = edge.From()
+			}
+			res[nid] = append(res[nid], arc{to: to, edge: edge})
+		}
+	}
+	return res
+}
+
+func product(g1, g2 graph.Graph, cartesian bool, tensor bool) (graph.Graph, map[int64][2]int64, error) {
+	if graph.IsDirected(g1) != graph.IsDirected(g2) {
+		return nil, nil, fmt.Errorf("Graphs must both be directed or both be undirected")
+	}
+	res := build.New(graph.IsDirected(g1))
+	build.CopyDefaults(res, g1)
+
+	ids1, ids2 := build.NodeIDs(g1), build.NodeIDs(g2)
+	pairs := make(map[int64][2]int64)
+	ids := make(map[[2]int64]int64)
+	next := int64(1)
+	for _, a := range ids1 {
+		for _, b := range ids2 {
+			pairs[next] = [2]int64{a, b}
+			ids[[2]int64{a, b}] = next
+			if err := build.AddNode(res, next, make(map[interface{}]interface{})); err != nil {
+				return nil, nil, err
+			}
+			next++
+		}
+	}
+
+	arcs1, arcs2 := arcs(g1), arcs(g2)
+	defaults2 := make(map[interface{}]interface{})
+	for key, value := range *g2.EdgeDefaults() {
+		if !reflect.DeepEqual(value, g1.EdgeDefault(key)) {
+			defaults2[key] = value
+		}
+	}
+	for _, a := range ids1 {
+		for _, b := range ids2 {
+			from := ids[[2]int64{a, b}]
+			if cartesian {
+				for _, step := range arcs2[b] {
+					if err := build.AddEdge(res, from, ids[[2]int64{a, step.to}], withDefaults(step.edge, defaults2)); err != nil {
+						return nil, nil, err
+					}
+				}
+				for _, step := range arcs1[a] {
+					if err := build.AddEdge(res, from, ids[[2]int64{step.to, b}], build.CopyAttributes(step.edge.Attributes())); err != nil {
+						return nil, nil, err
+					}
+				}
+			}
+			if tensor {
+				for _, step1 := range arcs1[a] {
+					for _, step2 := range arcs2[b] {
+						if err := build.AddEdge(res, from, ids[[2]int64{step1.to, step2.to}], make(map[interface{}]interface{})); err != nil {
+							return nil, nil, err
+						}
+					}
+				}
+			}
+		}
+	}
+	return res, pairs, nil
+}
+
+// withDefaults returns a copy of an edge's attributes with defaults added
+// for the keys it does not set
+func withDefaults(edge graph.Edge, defaults map[interface{}]interface{}) map[interface{}]interface{} {
+	res := build.CopyAttributes(edge.Attributes())
+	for key, value := range defaults {
+		if _, exists := res[key]; !exists {
+			res[key] = value
+		}
+	}
+	return res
+}
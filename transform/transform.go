@@ -0,0 +1,137 @@
+package transform
+
+// Copyright © 2018 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/wealdtech/go-graph"
+	"github.com/wealdtech/go-graph/internal/build"
+)
+
+// successors returns the nodes that edges lead to from a node, sorted.
+// For undirected graphs these are the node's neighbours
+func successors(g graph.Graph, nid int64) []int64 {
+	res := make([]int64, 0)
+	for _, edge := range g.Edges(nid) {
+		if edge.From() == nid {
+			res = append(res, edge.To())
+		} else {
+			res = append(res, edge.From())
+		}
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i] < res[j] })
+	return res
+}
+
+// copyNodes copies the nodes of one graph to another, with their
+// attributes
+func copyNodes(g build.Graph, from graph.Graph) error {
+	for _, nid := range build.NodeIDs(from) {
+		if err := build.AddNode(g, nid, build.CopyAttributes(from.Node(nid).Attributes())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Complement returns a graph with the same nodes, joining each pair of
+// distinct nodes that the original graph does not join.  Nodes keep their
+// attributes and the graph keeps its defaults; the new edges have no
+// attributes of their own
+func Complement(g graph.Graph) (graph.Graph, error) {
+	res := build.New(graph.IsDirected(g))
+	build.CopyDefaults(res, g)
+	if err := copyNodes(res, g); err != nil {
+		return nil, err
+	}
+	ids := build.NodeIDs(g)
+	for _, a := range ids {
+		for _, b := range ids {
+			if a != b && !g.HasEdge(a, b) {
+				if err := build.AddEdge(res, a, b, make(map[interface{}]interface{})); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	return res, nil
+}
+
+// Reverse returns a directed graph with the direction of every edge
+// reversed.  Nodes and edges keep their attributes and the graph keeps
+// its defaults
+func Reverse(g graph.Graph) (graph.Graph, error) {
+	if !graph.IsDirected(g) {
+		return nil, fmt.Errorf("Reverse requires a directed graph")
+	}
+	res := build.New(true)
+	build.CopyDefaults(res, g)
+	if err := copyNodes(res, g); err != nil {
+		return nil, err
+	}
+	for _, nid := range build.NodeIDs(g) {
+		for _, edge := range build.OwnedEdges(g, nid) {
+			if err := build.AddEdge(res, edge.To(), edge.From(), build.CopyAttributes(edge.Attributes())); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return res, nil
+}
+
+// Power returns the k-th power of a graph, which joins each node to every
+// other node it can reach in at most k steps.  Nodes keep their
+// attributes, edges already in the graph keep theirs, and the graph keeps
+// its defaults; the new edges have no attributes of their own.  Self-loops
+// are kept but no new ones are added
+func Power(g graph.Graph, k int) (graph.Graph, error) {
+	if k < 1 {
+		return nil, fmt.Errorf("Power must be at least 1")
+	}
+	res := build.New(graph.IsDirected(g))
+	build.CopyDefaults(res, g)
+	if err := copyNodes(res, g); err != nil {
+		return nil, err
+	}
+	for _, nid := range build.NodeIDs(g) {
+		for _, edge := range build.OwnedEdges(g, nid) {
+			if err := build.AddEdge(res, edge.From(), edge.To(), build.CopyAttributes(edge.Attributes())); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for _, start := range build.NodeIDs(g) {
+		distances := map[int64]int{start: 0}
+		queue := []int64{start}
+		for len(queue) > 0 {
+			nid := queue[0]
+			queue = queue[1:]
+			if distances[nid] == k {
+				continue
+			}
+			for _, next := range successors(g, nid) {
+				if _, visited := distances[next]; !visited {
+					distances[next] = distances[nid] + 1
+					queue = append(queue, next)
+					if err := build.AddEdge(res, start, next, make(map[interface{}]interface{})); err != nil {
+						return nil, err
+					}
+				}
+			}
+		}
+	}
+	return res, nil
+}
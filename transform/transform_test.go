@@ -0,0 +1,330 @@
+package transform
+
+// Copyright © 2018 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wealdtech/go-graph"
+	"github.com/wealdtech/go-graph/edges"
+	"github.com/wealdtech/go-graph/exporters/dot"
+	"github.com/wealdtech/go-graph/graphs"
+	"github.com/wealdtech/go-graph/nodes"
+)
+
+func directed(t *testing.T, ids []int64, links [][2]int64) *graphs.DirectedGraph {
+	g := graphs.NewDirectedGraph()
+	for _, id := range ids {
+		assert.NoError(t, g.AddNode(nodes.NewSimpleNode(id)))
+	}
+	for _, link := range links {
+		assert.NoError(t, g.AddEdge(edges.NewDirectedEdge(link[0], link[1])))
+	}
+	return g
+}
+
+func undirected(t *testing.T, ids []int64, links [][2]int64) *graphs.UndirectedGraph {
+	g := graphs.NewUndirectedGraph()
+	for _, id := range ids {
+		assert.NoError(t, g.AddNode(nodes.NewSimpleNode(id)))
+	}
+	for _, link := range links {
+		assert.NoError(t, g.AddEdge(edges.NewUndirectedEdge(link[0], link[1])))
+	}
+	return g
+}
+
+func marshal(g graph.Graph) string {
+	return string(dot.Marshal(g))
+}
+
+func edgeCount(g graph.Graph) int {
+	res := 0
+	for _, node := range g.Nodes() {
+		for _, edge := range g.Edges(node.Id()) {
+			if edge.From() == node.Id() {
+				res++
+			}
+		}
+	}
+	return res
+}
+
+func TestComplement(t *testing.T) {
+	g := undirected(t, []int64{1, 2, 3, 4}, [][2]int64{{1, 2}, {2, 3}, {3, 3}})
+	g.Node(1).SetAttribute("label", "a")
+	g.SetEdgeDefaults(map[interface{}]interface{}{"color": "grey"})
+	res, err := Complement(g)
+	assert.NoError(t, err)
+	assert.Equal(t, `graph g {
+  edge [ color="grey" ];
+  1 [ label="a" ];
+  1 -- 3;
+  1 -- 4;
+  2;
+  2 -- 4;
+  3;
+  3 -- 4;
+  4;
+}`, marshal(res))
+
+	d := directed(t, []int64{1, 2, 3}, [][2]int64{{1, 2}, {2, 3}, {3, 1}})
+	res, err = Complement(d)
+	assert.NoError(t, err)
+	assert.Equal(t, `digraph g {
+  1;
+  1 -> 3;
+  2;
+  2 -> 1;
+  3;
+  3 -> 2;
+}`, marshal(res))
+}
+
+func TestReverse(t *testing.T) {
+	d := directed(t, []int64{1, 2, 3}, [][2]int64{{1, 2}, {1, 3}})
+	d.Edge(1, 2).SetAttribute("weight", 5)
+	res, err := Reverse(d)
+	assert.NoError(t, err)
+	assert.Equal(t, `digraph g {
+  1;
+  2;
+  2 -> 1 [ weight="5" ];
+  3;
+  3 -> 1;
+}`, marshal(res))
+
+	_, err = Reverse(undirected(t, nil, nil))
+	assert.EqualError(t, err, "Reverse requires a directed graph")
+}
+
+func TestPower(t *testing.T) {
+	path := undirected(t, []int64{1, 2, 3, 4, 5}, [][2]int64{{1, 2}, {2, 3}, {3, 4}, {4, 5}})
+	path.Edge(1, 2).SetAttribute("weight", 1)
+	res, err := Power(path, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, `graph g {
+  1;
+  1 -- 2 [ weight="1" ];
+  1 -- 3;
+  2;
+  2 -- 3;
+  2 -- 4;
+  3;
+  3 -- 4;
+  3 -- 5;
+  4;
+  4 -- 5;
+  5;
+}`, marshal(res))
+	res, err = Power(path, 4)
+	assert.NoError(t, err)
+	assert.Equal(t, 10, edgeCount(res))
+
+	cycle := directed(t, []int64{1, 2, 3}, [][2]int64{{1, 2}, {2, 3}, {3, 1}})
+	res, err = Power(cycle, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, 6, edgeCount(res))
+	assert.False(t, res.HasEdge(1, 1))
+
+	_, err = Power(path, 0)
+	assert.EqualError(t, err, "Power must be at least 1")
+}
+
+func TestLineGraph(t *testing.T) {
+	star := undirected(t, []int64{1, 2, 3, 4}, [][2]int64{{1, 2}, {1, 3}, {1, 4}})
+	star.Edge(1, 3).SetAttribute("label", "b")
+	star.SetEdgeDefaults(map[interface{}]interface{}{"color": "grey"})
+	res, pairs, err := LineGraph(star)
+	assert.NoError(t, err)
+	assert.Equal(t, map[int64][2]int64{1: {1, 2}, 2: {1, 3}, 3: {1, 4}}, pairs)
+	assert.Equal(t, `graph g {
+  node [ color="grey" ];
+  1;
+  1 -- 2;
+  1 -- 3;
+  2 [ label="b" ];
+  2 -- 3;
+  3;
+}`, marshal(res))
+
+	d := directed(t, []int64{1, 2, 3}, [][2]int64{{1, 2}, {2, 3}, {2, 1}})
+	res, pairs, err = LineGraph(d)
+	assert.NoError(t, err)
+	assert.Equal(t, map[int64][2]int64{1: {1, 2}, 2: {2, 1}, 3: {2, 3}}, pairs)
+	assert.Equal(t, `digraph g {
+  1;
+  1 -> 2;
+  1 -> 3;
+  2;
+  2 -> 1;
+  3;
+}`, marshal(res))
+}
+
+func TestProducts(t *testing.T) {
+	edge := undirected(t, []int64{1, 2}, [][2]int64{{1, 2}})
+	edge.Edge(1, 2).SetAttribute("label", "x")
+	path := undirected(t, []int64{1, 2, 3}, [][2]int64{{1, 2}, {2, 3}})
+
+	res, pairs, err := CartesianProduct(edge, path)
+	assert.NoError(t, err)
+	assert.Equal(t, map[int64][2]int64{1: {1, 1}, 2: {1, 2}, 3: {1, 3}, 4: {2, 1}, 5: {2, 2}, 6: {2, 3}}, pairs)
+	// A ladder
+	assert.Equal(t, `graph g {
+  1;
+  1 -- 2;
+  1 -- 4 [ label="x" ];
+  2;
+  2 -- 3;
+  2 -- 5 [ label="x" ];
+  3;
+  3 -- 6 [ label="x" ];
+  4;
+  4 -- 5;
+  5;
+  5 -- 6;
+  6;
+}`, marshal(res))
+
+	res, _, err = TensorProduct(edge, path)
+	assert.NoError(t, err)
+	assert.Equal(t, `graph g {
+  1;
+  1 -- 5;
+  2;
+  2 -- 4;
+  2 -- 6;
+  3;
+  3 -- 5;
+  4;
+  5;
+  6;
+}`, marshal(res))
+
+	res, _, err = StrongProduct(edge, path)
+	assert.NoError(t, err)
+	assert.Equal(t, 11, edgeCount(res))
+
+	// Directed products follow edge directions
+	d := directed(t, []int64{1, 2}, [][2]int64{{1, 2}})
+	res, _, err = TensorProduct(d, d)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, edgeCount(res))
+	assert.True(t, res.HasEdge(1, 4))
+
+	_, _, err = CartesianProduct(d, path)
+	assert.EqualError(t, err, "Graphs must both be directed or both be undirected")
+
+	// Edges from the second graph keep its edge defaults
+	edge.SetEdgeDefaults(map[interface{}]interface{}{"colour": "red", "style": "bold"})
+	path.SetEdgeDefaults(map[interface{}]interface{}{"colour": "blue", "style": "bold"})
+	path.Edge(2, 3).SetAttribute("colour", "green")
+	for _, product := range []func(g1, g2 graph.Graph) (graph.Graph, map[int64][2]int64, error){CartesianProduct, StrongProduct} {
+		res, _, err = product(edge, path)
+		assert.NoError(t, err)
+		assert.Equal(t, map[interface{}]interface{}{"colour": "blue"}, *res.Edge(1, 2).Attributes())
+		assert.Equal(t, map[interface{}]interface{}{"colour": "green"}, *res.Edge(2, 3).Attributes())
+		assert.Equal(t, map[interface{}]interface{}{"label": "x"}, *res.Edge(1, 4).Attributes())
+		assert.Equal(t, "red", res.EdgeDefault("colour"))
+	}
+}
+
+func TestMergeNodes(t *testing.T) {
+	g := undirected(t, []int64{1, 2, 3, 4}, [][2]int64{{1, 2}, {1, 3}, {2, 3}, {3, 4}, {2, 2}})
+	g.Node(1).SetAttribute("cpus", 4)
+	g.Node(1).SetAttribute("os", "linux")
+	g.Node(2).SetAttribute("cpus", 8)
+	g.Edge(1, 3).SetAttribute("bandwidth", 10)
+	g.Edge(2, 3).SetAttribute("bandwidth", 40)
+
+	res, err := MergeNodes(g, []int64{2, 1}, 10, Sum)
+	assert.NoError(t, err)
+	assert.Equal(t, `graph g {
+  3;
+  3 -- 4;
+  3 -- 10 [ bandwidth="50" ];
+  4;
+  10 [ cpus="12" os="linux" ];
+  10 -- 10;
+}`, marshal(res))
+
+	res, err = MergeNodes(g, []int64{1, 2}, 1, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, res.Node(1).Attribute("cpus"))
+	res, err = MergeNodes(g, []int64{1, 2}, 1, Last)
+	assert.NoError(t, err)
+	assert.Equal(t, 8, res.Node(1).Attribute("cpus"))
+	res, err = MergeNodes(g, []int64{1, 2}, 1, Collect)
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{4, 8}, res.Node(1).Attribute("cpus"))
+	// The original is unchanged
+	assert.True(t, g.HasNode(2))
+
+	_, err = MergeNodes(g, []int64{1, 5}, 1, nil)
+	assert.EqualError(t, err, "Unknown node 5")
+	_, err = MergeNodes(g, []int64{1, 2}, 3, nil)
+	assert.EqualError(t, err, "Node 3 already exists")
+	_, err = MergeNodes(g, nil, 3, nil)
+	assert.EqualError(t, err, "No nodes to merge")
+}
+
+func TestContractEdge(t *testing.T) {
+	d := directed(t, []int64{1, 2, 3, 4}, [][2]int64{{1, 2}, {2, 3}, {3, 1}, {4, 2}, {4, 1}})
+	res, err := ContractEdge(d, 1, 2, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, `digraph g {
+  1;
+  1 -> 3;
+  3;
+  3 -> 1;
+  4;
+  4 -> 1;
+}`, marshal(res))
+
+	_, err = ContractEdge(d, 2, 1, nil)
+	assert.EqualError(t, err, "Edge from 2 to 1 does not exist")
+}
+
+func TestQuotient(t *testing.T) {
+	// Hosts 1-4 in rack 100, hosts 5-6 in rack 200
+	g := undirected(t, []int64{1, 2, 3, 4, 5, 6}, [][2]int64{{1, 2}, {1, 5}, {2, 5}, {3, 6}, {5, 6}})
+	for nid := int64(1); nid <= 6; nid++ {
+		rack := int64(100)
+		if nid > 4 {
+			rack = 200
+		}
+		g.Node(nid).SetAttribute("rack", rack)
+	}
+	g.Edge(1, 5).SetAttribute("weight", 2.5)
+	rack := func(node graph.Node) int64 { return node.Attribute("rack").(int64) }
+
+	res, err := Quotient(g, rack, graph.AttributeWeight("weight"))
+	assert.NoError(t, err)
+	assert.Equal(t, `graph g {
+  100 [ count="4" members="[1 2 3 4]" ];
+  100 -- 200 [ count="3" weight="4.5" ];
+  200 [ count="2" members="[5 6]" ];
+}`, marshal(res))
+
+	d := directed(t, []int64{1, 2, 3}, [][2]int64{{1, 2}, {1, 3}, {3, 1}})
+	res, err = Quotient(d, func(node graph.Node) int64 { return node.Id() % 2 }, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, `digraph g {
+  0 [ count="1" members="[2]" ];
+  1 [ count="2" members="[1 3]" ];
+  1 -> 0 [ count="1" weight="1" ];
+}`, marshal(res))
+}
@@ -0,0 +1,199 @@
+package tsp
+
+// Copyright © 2018 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/wealdtech/go-graph"
+)
+
+// maxExactMatching is the largest number of odd-degree nodes for which
+// Christofides finds an exact minimum matching; beyond this matching is
+// greedy
+const maxExactMatching = 20
+
+// Christofides builds a tour of an undirected graph from a minimum
+// spanning tree and a minimum matching of its odd-degree nodes.  The tour
+// is at most 1.5 times the optimal length, provided that there are no more
+// than 20 odd-degree nodes in the spanning tree; beyond that the matching
+// is greedy and the bound does not hold.  A nil weight function treats all
+// edges as having weight 1
+func Christofides(g graph.Graph, weight graph.WeightFunc) (*Tour, error) {
+	m := newMetric(g, weight)
+	if m.directed {
+		return nil, fmt.Errorf("Christofides requires an undirected graph")
+	}
+	n := m.size()
+	if n <= 3 {
+		return m.tour(identity(n))
+	}
+
+	// Minimum spanning tree, using Prim's algorithm
+	adj := make([][]int, n)
+	inTree := make([]bool, n)
+	best := make([]float64, n)
+	parent := make([]int, n)
+	for i := range best {
+		best[i] = math.Inf(1)
+		parent[i] = -1
+	}
+	best[0] = 0
+	for added := 0; added < n; added++ {
+		v := -1
+		for i := range best {
+			if !inTree[i] && (v < 0 || best[i] < best[v]) {
+				v = i
+			}
+		}
+		if math.IsInf(best[v], 1) {
+			return nil, fmt.Errorf("Not all nodes can be reached from each other")
+		}
+		inTree[v] = true
+		if parent[v] >= 0 {
+			adj[v] = append(adj[v], parent[v])
+			adj[parent[v]] = append(adj[parent[v]], v)
+		}
+		for w := range best {
+			if !inTree[w] && m.distance[v][w] < best[w] {
+				best[w] = m.distance[v][w]
+				parent[w] = v
+			}
+		}
+	}
+
+	// Match the odd-degree nodes
+	odd := make([]int, 0)
+	for v := range adj {
+		if len(adj[v])%2 == 1 {
+			odd = append(odd, v)
+		}
+	}
+	for _, pair := range m.matching(odd) {
+		adj[pair[0]] = append(adj[pair[0]], pair[1])
+		adj[pair[1]] = append(adj[pair[1]], pair[0])
+	}
+
+	// Shortcut an Eulerian circuit of the combined graph
+	visited := make([]bool, n)
+	order := make([]int, 0, n)
+	for _, v := range eulerCircuit(adj, 0) {
+		if !visited[v] {
+			visited[v] = true
+			order = append(order, v)
+		}
+	}
+	return m.tour(order)
+}
+
+// matching pairs up nodes with minimum total distance
+func (m *metric) matching(nodes []int) [][2]int {
+	pairs := make([][2]int, 0, len(nodes)/2)
+	if len(nodes) > maxExactMatching {
+		// Greedily pair the closest remaining nodes
+		matched := make([]bool, len(nodes))
+		for len(pairs) < len(nodes)/2 {
+			a, b := -1, -1
+			for i := range nodes {
+				for j := i + 1; j < len(nodes); j++ {
+					if matched[i] || matched[j] {
+						continue
+					}
+					if a < 0 || m.distance[nodes[i]][nodes[j]] < m.distance[nodes[a]][nodes[b]] {
+						a, b = i, j
+					}
+				}
+			}
+			matched[a] = true
+			matched[b] = true
+			pairs = append(pairs, [2]int{nodes[a], nodes[b]})
+		}
+		return pairs
+	}
+
+	size := 1 << uint(len(nodes))
+	cost := make([]float64, size)
+	choice := make([]int, size)
+	for mask := 1; mask < size; mask++ {
+		cost[mask] = math.Inf(1)
+		i := lowestBit(mask)
+		for j := i + 1; j < len(nodes); j++ {
+			if mask&(1<<uint(j)) == 0 {
+				continue
+			}
+			rest := mask &^ (1 << uint(i)) &^ (1 << uint(j))
+			if c := cost[rest] + m.distance[nodes[i]][nodes[j]]; c < cost[mask] {
+				cost[mask] = c
+				choice[mask] = j
+			}
+		}
+	}
+	for mask := size - 1; mask != 0; {
+		i := lowestBit(mask)
+		j := choice[mask]
+		pairs = append(pairs, [2]int{nodes[i], nodes[j]})
+		mask = mask &^ (1 << uint(i)) &^ (1 << uint(j))
+	}
+	return pairs
+}
+
+func lowestBit(mask int) int {
+	i := 0
+	for mask&(1<<uint(i)) == 0 {
+		i++
+	}
+	return i
+}
+
+// eulerCircuit returns an Eulerian circuit of an undirected multigraph
+// given as adjacency lists, using Hierholzer's algorithm
+func eulerCircuit(adj [][]int, start int) []int {
+	remaining := make([]map[int]int, len(adj))
+	for v := range adj {
+		remaining[v] = make(map[int]int)
+		for _, w := range adj[v] {
+			remaining[v][w]++
+		}
+	}
+	stack := []int{start}
+	circuit := make([]int, 0)
+	for len(stack) > 0 {
+		v := stack[len(stack)-1]
+		next := -1
+		for _, w := range adj[v] {
+			if remaining[v][w] > 0 {
+				next = w
+				break
+			}
+		}
+		if next < 0 {
+			stack = stack[:len(stack)-1]
+			circuit = append(circuit, v)
+			continue
+		}
+		remaining[v][next]--
+		remaining[next][v]--
+		stack = append(stack, next)
+	}
+	return circuit
+}
+
+func identity(n int) []int {
+	res := make([]int, n)
+	for i := range res {
+		res[i] = i
+	}
+	return res
+}
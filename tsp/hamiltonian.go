@@ -0,0 +1,247 @@
+package tsp
+
+// Copyright © 2018 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"sort"
+
+	"github.com/wealdtech/go-graph"
+)
+
+// HamiltonianPath searches for a path along the edges of the graph that
+// visits every node exactly once, returning the node IDs in order.  The
+// search backtracks, so can take exponential time
+func HamiltonianPath(g graph.Graph) ([]int64, bool) {
+	h := newHamiltonian(g, false)
+	if len(h.ids) == 0 {
+		return []int64{}, true
+	}
+	starts := make([]int, len(h.ids))
+	for i := range starts {
+		starts[i] = i
+	}
+	// Nodes that are hard to enter are likely to be at the start
+	sort.SliceStable(starts, func(i, j int) bool { return len(h.in[starts[i]]) < len(h.in[starts[j]]) })
+	for _, start := range starts {
+		if h.search(start) {
+			return h.result(), true
+		}
+	}
+	return nil, false
+}
+
+// HamiltonianCycle searches for a cycle along the edges of the graph that
+// visits every node exactly once, returning the node IDs in order.  The
+// cycle returns from the last node to the first.  The search backtracks,
+// so can take exponential time
+func HamiltonianCycle(g graph.Graph) ([]int64, bool) {
+	h := newHamiltonian(g, true)
+	if len(h.ids) == 0 {
+		return []int64{}, true
+	}
+	if len(h.ids) == 1 {
+		return h.ids, true
+	}
+	if h.search(0) {
+		return h.result(), true
+	}
+	return nil, false
+}
+
+// hamiltonian holds the state of a Hamiltonian path or cycle search
+type hamiltonian struct {
+	cycle   bool
+	ids     []int64
+	out     [][]int
+	in      [][]int
+	visited []bool
+	path    []int
+}
+
+func newHamiltonian(g graph.Graph, cycle bool) *hamiltonian {
+	directed := graph.IsDirected(g)
+	h := &hamiltonian{
+		cycle: cycle,
+		ids:   make([]int64, 0),
+	}
+	for _, node := range g.Nodes() {
+		h.ids = append(h.ids, node.Id())
+	}
+	sort.Slice(h.ids, func(i, j int) bool { return h.ids[i] < h.ids[j] })
+	index := make(map[int64]int, len(h.ids))
+	for i, nid := range h.ids {
+		index[nid] = i
+	}
+	outSets := make([]map[int]bool, len(h.ids))
+	inSets := make([]map[int]bool, len(h.ids))
+	for i := range h.ids {
+		outSets[i] = make(map[int]bool)
+		inSets[i] = make(map[int]bool)
+	}
+	for _, nid := range h.ids {
+		for _, edge := range g.Edges(nid) {
+			a, aok := index[edge.From()]
+			b, bok := index[edge.To()]
+			if !aok || !bok || a == b {
+				continue
+			}
+			outSets[a][b] = true
+			inSets[b][a] = true
+			if !directed {
+				outSets[b][a] = true
+				inSets[a][b] = true
+			}
+		}
+	}
+	h.out = make([][]int, len(h.ids))
+	h.in = make([][]int, len(h.ids))
+	for i := range h.ids {
+		h.out[i] = sortedKeys(outSets[i])
+		h.in[i] = sortedKeys(inSets[i])
+	}
+	h.visited = make([]bool, len(h.ids))
+	return h
+}
+
+func (h *hamiltonian) result() []int64 {
+	res := make([]int64, len(h.path))
+	for i, v := range h.path {
+		res[i] = h.ids[v]
+	}
+	return res
+}
+
+func (h *hamiltonian) search(start int) bool {
+	h.visited[start] = true
+	h.path = append(h.path, start)
+	if h.extend() {
+		return true
+	}
+	h.path = h.path[:0]
+	h.visited[start] = false
+	return false
+}
+
+func (h *hamiltonian) extend() bool {
+	end := h.path[len(h.path)-1]
+	if len(h.path) == len(h.ids) {
+		if !h.cycle {
+			return true
+		}
+		for _, w := range h.out[end] {
+			if w == h.path[0] {
+				return true
+			}
+		}
+		return false
+	}
+	if !h.feasible() {
+		return false
+	}
+
+	// Try the most constrained candidates first
+	candidates := make([]int, 0)
+	for _, w := range h.out[end] {
+		if !h.visited[w] {
+			candidates = append(candidates, w)
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return h.onward(candidates[i]) < h.onward(candidates[j])
+	})
+	for _, w := range candidates {
+		h.visited[w] = true
+		h.path = append(h.path, w)
+		if h.extend() {
+			return true
+		}
+		h.path = h.path[:len(h.path)-1]
+		h.visited[w] = false
+	}
+	return false
+}
+
+// onward returns the number of unvisited nodes reachable in one step
+func (h *hamiltonian) onward(v int) int {
+	count := 0
+	for _, w := range h.out[v] {
+		if !h.visited[w] {
+			count++
+		}
+	}
+	return count
+}
+
+// feasible prunes partial paths that cannot be completed: every unvisited
+// node must still be enterable and leavable, and reachable from the end of
+// the path
+func (h *hamiltonian) feasible() bool {
+	start := h.path[0]
+	end := h.path[len(h.path)-1]
+	deadEnds := 0
+	for v := range h.ids {
+		if h.visited[v] {
+			continue
+		}
+		enterable := false
+		for _, u := range h.in[v] {
+			if !h.visited[u] || u == end {
+				enterable = true
+				break
+			}
+		}
+		if !enterable {
+			return false
+		}
+		leavable := false
+		for _, w := range h.out[v] {
+			if !h.visited[w] || (h.cycle && w == start) {
+				leavable = true
+				break
+			}
+		}
+		if !leavable {
+			deadEnds++
+			if h.cycle || deadEnds > 1 {
+				return false
+			}
+		}
+	}
+
+	// All unvisited nodes must be reachable from the end of the path
+	seen := make([]bool, len(h.ids))
+	stack := []int{end}
+	reached := 0
+	for len(stack) > 0 {
+		v := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		for _, w := range h.out[v] {
+			if !h.visited[w] && !seen[w] {
+				seen[w] = true
+				reached++
+				stack = append(stack, w)
+			}
+		}
+	}
+	return reached == len(h.ids)-len(h.path)
+}
+
+func sortedKeys(set map[int]bool) []int {
+	res := make([]int, 0, len(set))
+	for k := range set {
+		res = append(res, k)
+	}
+	sort.Ints(res)
+	return res
+}
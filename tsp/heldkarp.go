@@ -0,0 +1,95 @@
+package tsp
+
+// Copyright © 2018 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/wealdtech/go-graph"
+)
+
+// maxHeldKarpNodes is the largest graph that HeldKarp will solve
+const maxHeldKarpNodes = 16
+
+// HeldKarp finds an optimal tour using the Held-Karp dynamic programming
+// algorithm.  This takes O(2^n n^2) time so is limited to graphs of 16
+// nodes or fewer.  A nil weight function treats all edges as having
+// weight 1
+func HeldKarp(g graph.Graph, weight graph.WeightFunc) (*Tour, error) {
+	m := newMetric(g, weight)
+	n := m.size()
+	if n > maxHeldKarpNodes {
+		return nil, fmt.Errorf("Too many nodes (%d) to solve exactly", n)
+	}
+	if n <= 2 {
+		return m.tour(identity(n))
+	}
+
+	// cost[mask][v] is the cheapest path from node 0 through the nodes in
+	// mask (which excludes node 0) ending at v
+	size := 1 << uint(n-1)
+	cost := make([][]float64, size)
+	via := make([][]int, size)
+	for mask := range cost {
+		cost[mask] = make([]float64, n)
+		via[mask] = make([]int, n)
+		for v := range cost[mask] {
+			cost[mask][v] = math.Inf(1)
+			via[mask][v] = -1
+		}
+	}
+	for v := 1; v < n; v++ {
+		cost[1<<uint(v-1)][v] = m.distance[0][v]
+	}
+	for mask := 1; mask < size; mask++ {
+		for v := 1; v < n; v++ {
+			bit := 1 << uint(v-1)
+			if mask&bit == 0 || math.IsInf(cost[mask][v], 1) {
+				continue
+			}
+			for w := 1; w < n; w++ {
+				wbit := 1 << uint(w-1)
+				if mask&wbit != 0 {
+					continue
+				}
+				if c := cost[mask][v] + m.distance[v][w]; c < cost[mask|wbit][w] {
+					cost[mask|wbit][w] = c
+					via[mask|wbit][w] = v
+				}
+			}
+		}
+	}
+
+	full := size - 1
+	last := -1
+	best := math.Inf(1)
+	for v := 1; v < n; v++ {
+		if c := cost[full][v] + m.distance[v][0]; c < best {
+			best = c
+			last = v
+		}
+	}
+	if last < 0 {
+		return nil, fmt.Errorf("Not all nodes can be reached from each other")
+	}
+	order := make([]int, n)
+	for mask, v, i := full, last, n-1; v > 0; i-- {
+		order[i] = v
+		prev := via[mask][v]
+		mask &^= 1 << uint(v-1)
+		v = prev
+	}
+	return m.tour(order)
+}
@@ -0,0 +1,124 @@
+package tsp
+
+// Copyright © 2018 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"github.com/wealdtech/go-graph"
+)
+
+// improvement is the minimum reduction in cost for a move to be made
+const improvement = 1e-9
+
+// TwoOpt improves a tour by repeatedly reversing the section of the tour
+// between two legs while that shortens it.  For directed graphs the cost
+// of the reversed section is recalculated in full.  A nil weight function
+// treats all edges as having weight 1
+func TwoOpt(g graph.Graph, weight graph.WeightFunc, tour *Tour) (*Tour, error) {
+	m := newMetric(g, weight)
+	order, err := m.order(tour)
+	if err != nil {
+		return nil, err
+	}
+	m.twoOpt(order)
+	return m.tour(order)
+}
+
+// ThreeOpt improves a tour by repeatedly applying 2-opt moves and 3-opt
+// segment exchanges, which cut three legs and reconnect the tour without
+// reversing any section, while that shortens it.  A nil weight function
+// treats all edges as having weight 1
+func ThreeOpt(g graph.Graph, weight graph.WeightFunc, tour *Tour) (*Tour, error) {
+	m := newMetric(g, weight)
+	order, err := m.order(tour)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		m.twoOpt(order)
+		if !m.threeOpt(order) {
+			break
+		}
+	}
+	return m.tour(order)
+}
+
+func (m *metric) twoOpt(order []int) {
+	n := len(order)
+	if n < 4 {
+		return
+	}
+	d := m.distance
+	for improved := true; improved; {
+		improved = false
+		for i := 0; i < n-1; i++ {
+			for j := i + 2; j < n; j++ {
+				if i == 0 && j == n-1 {
+					continue
+				}
+				// Reverse order[i+1..j]
+				a, b := order[i], order[i+1]
+				c, e := order[j], order[(j+1)%n]
+				delta := d[a][c] + d[b][e] - d[a][b] - d[c][e]
+				if m.directed {
+					for k := i + 1; k < j; k++ {
+						delta += d[order[k+1]][order[k]] - d[order[k]][order[k+1]]
+					}
+				}
+				if delta < -improvement {
+					reverse(order[i+1 : j+1])
+					improved = true
+				}
+			}
+		}
+	}
+}
+
+// threeOpt tries segment exchanges of the form a-b..c-d..e-f to
+// a-d..e-b..c-f, returning true if any was made
+func (m *metric) threeOpt(order []int) bool {
+	n := len(order)
+	if n < 5 {
+		return false
+	}
+	d := m.distance
+	changed := false
+	for improved := true; improved; {
+		improved = false
+		for i := 0; i < n-2; i++ {
+			for j := i + 1; j < n-1; j++ {
+				for k := j + 1; k < n; k++ {
+					a, b := order[i], order[i+1]
+					c, e := order[j], order[j+1]
+					f, h := order[k], order[(k+1)%n]
+					delta := d[a][e] + d[f][b] + d[c][h] - d[a][b] - d[c][e] - d[f][h]
+					if delta < -improvement {
+						// Swap the segments b..c and e..f
+						segment := append([]int(nil), order[i+1:j+1]...)
+						copy(order[i+1:], order[j+1:k+1])
+						copy(order[i+1+k-j:], segment)
+						improved = true
+						changed = true
+					}
+				}
+			}
+		}
+	}
+	return changed
+}
+
+func reverse(s []int) {
+	for i, j := 0, len(s)-1; i < j; i, j = i+1, j-1 {
+		s[i], s[j] = s[j], s[i]
+	}
+}
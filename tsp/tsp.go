@@ -0,0 +1,175 @@
+package tsp
+
+// Copyright © 2018 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/wealdtech/go-graph"
+)
+
+// Tour is a closed route visiting every node of a graph.  The solvers work
+// on the shortest-path distances between nodes, so consecutive nodes in a
+// tour are not necessarily adjacent in the graph
+type Tour struct {
+	// Nodes are the IDs of the nodes in the order visited; the tour returns
+	// from the last node to the first
+	Nodes []int64
+	// Cost is the total distance of the tour
+	Cost float64
+}
+
+// metric holds the shortest-path distances between all pairs of nodes
+type metric struct {
+	directed bool
+	ids      []int64
+	index    map[int64]int
+	distance [][]float64
+}
+
+// newMetric calculates the shortest-path distances between all pairs of
+// nodes using the Floyd-Warshall algorithm
+func newMetric(g graph.Graph, weight graph.WeightFunc) *metric {
+	if weight == nil {
+		weight = graph.UnitWeight
+	}
+	m := &metric{
+		directed: graph.IsDirected(g),
+		ids:      make([]int64, 0),
+		index:    make(map[int64]int),
+	}
+	for _, node := range g.Nodes() {
+		m.ids = append(m.ids, node.Id())
+	}
+	sort.Slice(m.ids, func(i, j int) bool { return m.ids[i] < m.ids[j] })
+	n := len(m.ids)
+	m.distance = make([][]float64, n)
+	for i, nid := range m.ids {
+		m.index[nid] = i
+		m.distance[i] = make([]float64, n)
+		for j := range m.distance[i] {
+			if i != j {
+				m.distance[i][j] = math.Inf(1)
+			}
+		}
+	}
+	for _, nid := range m.ids {
+		for _, edge := range g.Edges(nid) {
+			a, aok := m.index[edge.From()]
+			b, bok := m.index[edge.To()]
+			if !aok || !bok || a == b {
+				continue
+			}
+			if w := weight(edge); w < m.distance[a][b] {
+				m.distance[a][b] = w
+				if !m.directed {
+					m.distance[b][a] = w
+				}
+			}
+		}
+	}
+	for k := 0; k < n; k++ {
+		for i := 0; i < n; i++ {
+			if math.IsInf(m.distance[i][k], 1) {
+				continue
+			}
+			for j := 0; j < n; j++ {
+				if d := m.distance[i][k] + m.distance[k][j]; d < m.distance[i][j] {
+					m.distance[i][j] = d
+				}
+			}
+		}
+	}
+	return m
+}
+
+func (m *metric) size() int {
+	return len(m.ids)
+}
+
+// cost returns the total distance of a closed tour of indices
+func (m *metric) cost(order []int) float64 {
+	if len(order) < 2 {
+		return 0
+	}
+	total := 0.0
+	for i := range order {
+		total += m.distance[order[i]][order[(i+1)%len(order)]]
+	}
+	return total
+}
+
+// tour converts a tour of indices to a Tour, failing if any leg of the tour
+// cannot be travelled
+func (m *metric) tour(order []int) (*Tour, error) {
+	cost := m.cost(order)
+	if math.IsInf(cost, 1) {
+		return nil, fmt.Errorf("Not all nodes can be reached from each other")
+	}
+	nodes := make([]int64, len(order))
+	for i, v := range order {
+		nodes[i] = m.ids[v]
+	}
+	return &Tour{Nodes: nodes, Cost: cost}, nil
+}
+
+// order converts a Tour to a tour of indices, checking that it visits each
+// node exactly once
+func (m *metric) order(tour *Tour) ([]int, error) {
+	if tour == nil || len(tour.Nodes) != m.size() {
+		return nil, fmt.Errorf("Tour does not visit every node")
+	}
+	order := make([]int, len(tour.Nodes))
+	seen := make(map[int]bool)
+	for i, nid := range tour.Nodes {
+		v, exists := m.index[nid]
+		if !exists {
+			return nil, fmt.Errorf("Unknown node %v in tour", nid)
+		}
+		if seen[v] {
+			return nil, fmt.Errorf("Node %v visited more than once in tour", nid)
+		}
+		seen[v] = true
+		order[i] = v
+	}
+	return order, nil
+}
+
+// NearestNeighbour builds a tour by starting at the given node and
+// repeatedly travelling to the nearest unvisited node.  A nil weight
+// function treats all edges as having weight 1
+func NearestNeighbour(g graph.Graph, weight graph.WeightFunc, start int64) (*Tour, error) {
+	m := newMetric(g, weight)
+	current, exists := m.index[start]
+	if !exists {
+		return nil, fmt.Errorf("Unknown start node %v", start)
+	}
+	visited := make([]bool, m.size())
+	visited[current] = true
+	order := []int{current}
+	for len(order) < m.size() {
+		next := -1
+		for j := range visited {
+			if !visited[j] && (next < 0 || m.distance[current][j] < m.distance[current][next]) {
+				next = j
+			}
+		}
+		visited[next] = true
+		order = append(order, next)
+		current = next
+	}
+	return m.tour(order)
+}
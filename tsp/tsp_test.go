@@ -0,0 +1,171 @@
+package tsp
+
+// Copyright © 2018 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"math"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wealdtech/go-graph"
+	"github.com/wealdtech/go-graph/edges"
+	"github.com/wealdtech/go-graph/graphs"
+	"github.com/wealdtech/go-graph/nodes"
+)
+
+var weight = graph.AttributeWeight("weight")
+
+// sites creates a complete undirected graph of points in the plane,
+// weighted by the distance between them
+func sites(t *testing.T) *graphs.UndirectedGraph {
+	points := [][2]float64{{0, 0}, {3, 1}, {6, 0}, {7, 4}, {5, 7}, {2, 6}, {-1, 4}, {3, 4}, {1, 2}}
+	g := graphs.NewUndirectedGraph()
+	for i := range points {
+		assert.NoError(t, g.AddNode(nodes.NewSimpleNode(int64(i+1))))
+	}
+	for i := range points {
+		for j := i + 1; j < len(points); j++ {
+			edge := edges.NewUndirectedEdge(int64(i+1), int64(j+1))
+			edge.SetAttribute("weight", math.Hypot(points[i][0]-points[j][0], points[i][1]-points[j][1]))
+			assert.NoError(t, g.AddEdge(edge))
+		}
+	}
+	return g
+}
+
+func assertTour(t *testing.T, g graph.Graph, tour *Tour) {
+	assert.Len(t, tour.Nodes, len(g.Nodes()))
+	ids := append([]int64(nil), tour.Nodes...)
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	for i := range ids {
+		assert.Equal(t, int64(i+1), ids[i])
+	}
+}
+
+func TestSolvers(t *testing.T) {
+	g := sites(t)
+
+	optimal, err := HeldKarp(g, weight)
+	assert.NoError(t, err)
+	assertTour(t, g, optimal)
+
+	nn, err := NearestNeighbour(g, weight, 1)
+	assert.NoError(t, err)
+	assertTour(t, g, nn)
+	assert.Equal(t, int64(1), nn.Nodes[0])
+	assert.True(t, nn.Cost >= optimal.Cost-1e-9)
+
+	twoOpt, err := TwoOpt(g, weight, nn)
+	assert.NoError(t, err)
+	assertTour(t, g, twoOpt)
+	assert.True(t, twoOpt.Cost <= nn.Cost+1e-9)
+	assert.True(t, twoOpt.Cost >= optimal.Cost-1e-9)
+
+	threeOpt, err := ThreeOpt(g, weight, nn)
+	assert.NoError(t, err)
+	assertTour(t, g, threeOpt)
+	assert.True(t, threeOpt.Cost <= twoOpt.Cost+1e-9)
+	assert.True(t, threeOpt.Cost >= optimal.Cost-1e-9)
+
+	christofides, err := Christofides(g, weight)
+	assert.NoError(t, err)
+	assertTour(t, g, christofides)
+	assert.True(t, christofides.Cost <= 1.5*optimal.Cost+1e-9)
+	assert.True(t, christofides.Cost >= optimal.Cost-1e-9)
+}
+
+func TestDirectedSolvers(t *testing.T) {
+	// A cheap cycle 1 -> 2 -> 3 -> 4 -> 1 with expensive reverse edges
+	g := graphs.NewDirectedGraph()
+	for i := int64(1); i <= 4; i++ {
+		assert.NoError(t, g.AddNode(nodes.NewSimpleNode(i)))
+	}
+	for i := int64(1); i <= 4; i++ {
+		next := i%4 + 1
+		edge := edges.NewDirectedEdge(i, next)
+		edge.SetAttribute("weight", 1)
+		assert.NoError(t, g.AddEdge(edge))
+		edge = edges.NewDirectedEdge(next, i)
+		edge.SetAttribute("weight", 10)
+		assert.NoError(t, g.AddEdge(edge))
+	}
+
+	optimal, err := HeldKarp(g, weight)
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{1, 2, 3, 4}, optimal.Nodes)
+	assert.Equal(t, 4.0, optimal.Cost)
+
+	improved, err := TwoOpt(g, weight, &Tour{Nodes: []int64{1, 4, 3, 2}})
+	assert.NoError(t, err)
+	assert.True(t, improved.Cost < 40)
+
+	_, err = Christofides(g, weight)
+	assert.Error(t, err)
+
+	_, err = TwoOpt(g, weight, &Tour{Nodes: []int64{1, 2, 3}})
+	assert.Error(t, err)
+}
+
+func TestUnreachable(t *testing.T) {
+	g := graphs.NewUndirectedGraph()
+	for i := int64(1); i <= 3; i++ {
+		assert.NoError(t, g.AddNode(nodes.NewSimpleNode(i)))
+	}
+	assert.NoError(t, g.AddEdge(edges.NewUndirectedEdge(1, 2)))
+	_, err := NearestNeighbour(g, nil, 1)
+	assert.Error(t, err)
+	_, err = HeldKarp(g, nil)
+	assert.Error(t, err)
+}
+
+func TestHamiltonian(t *testing.T) {
+	// The Petersen graph has a Hamiltonian path but no Hamiltonian cycle
+	g := graphs.NewUndirectedGraph()
+	for i := int64(1); i <= 10; i++ {
+		assert.NoError(t, g.AddNode(nodes.NewSimpleNode(i)))
+	}
+	for _, pair := range [][2]int64{
+		{1, 2}, {2, 3}, {3, 4}, {4, 5}, {5, 1},
+		{1, 6}, {2, 7}, {3, 8}, {4, 9}, {5, 10},
+		{6, 8}, {8, 10}, {10, 7}, {7, 9}, {9, 6},
+	} {
+		assert.NoError(t, g.AddEdge(edges.NewUndirectedEdge(pair[0], pair[1])))
+	}
+	path, found := HamiltonianPath(g)
+	assert.True(t, found)
+	assert.Len(t, path, 10)
+	for i := 0; i < len(path)-1; i++ {
+		assert.True(t, g.HasEdge(path[i], path[i+1]))
+	}
+	_, found = HamiltonianCycle(g)
+	assert.False(t, found)
+
+	// Directed
+	d := graphs.NewDirectedGraph()
+	for i := int64(1); i <= 3; i++ {
+		assert.NoError(t, d.AddNode(nodes.NewSimpleNode(i)))
+	}
+	assert.NoError(t, d.AddEdge(edges.NewDirectedEdge(2, 3)))
+	assert.NoError(t, d.AddEdge(edges.NewDirectedEdge(1, 2)))
+	path, found = HamiltonianPath(d)
+	assert.True(t, found)
+	assert.Equal(t, []int64{1, 2, 3}, path)
+	_, found = HamiltonianCycle(d)
+	assert.False(t, found)
+	assert.NoError(t, d.AddEdge(edges.NewDirectedEdge(3, 1)))
+	cycle, found := HamiltonianCycle(d)
+	assert.True(t, found)
+	assert.Equal(t, []int64{1, 2, 3}, cycle)
+}
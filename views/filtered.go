@@ -0,0 +1,183 @@
+package views
+
+// Copyright © 2018 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"github.com/wealdtech/go-graph"
+)
+
+// Filtered is a view of the nodes and edges of a graph that pass filters.
+// Nothing is copied: the view reflects later changes to the graph, and
+// its nodes, edges and defaults are the graph's own, so changing their
+// attributes or the defaults changes the graph
+type Filtered struct {
+	g    graph.Graph
+	node func(graph.Node) bool
+	edge func(graph.Edge) bool
+}
+
+// InducedSubgraph returns a view of the given nodes of a graph and the
+// edges between them.  The view holds the nodes' IDs, so a node removed
+// from the graph leaves the view until a node with the same ID is added
+func InducedSubgraph(g graph.Graph, ids []int64) *Filtered {
+	set := make(map[int64]bool, len(ids))
+	for _, nid := range ids {
+		set[nid] = true
+	}
+	return &Filtered{
+		g: g,
+		node: func(node graph.Node) bool {
+			return set[node.Id()]
+		},
+	}
+}
+
+// FilteredView returns a view of the nodes of a graph that pass a node
+// filter and the edges between them that pass an edge filter.  A nil
+// filter passes everything.  Filters are applied on every access, so
+// they can look at attributes that change later
+func FilteredView(g graph.Graph, node func(graph.Node) bool, edge func(graph.Edge) bool) *Filtered {
+	return &Filtered{
+		g:    g,
+		node: node,
+		edge: edge,
+	}
+}
+
+// IsDirected returns true if the underlying graph is directed
+func (v *Filtered) IsDirected() bool {
+	return graph.IsDirected(v.g)
+}
+
+func (v *Filtered) GraphDefaults() *map[interface{}]interface{} {
+	return v.g.GraphDefaults()
+}
+
+func (v *Filtered) GraphDefault(key interface{}) interface{} {
+	return v.g.GraphDefault(key)
+}
+
+func (v *Filtered) SetGraphDefaults(defaults map[interface{}]interface{}) {
+	v.g.SetGraphDefaults(defaults)
+}
+
+func (v *Filtered) NodeDefaults() *map[interface{}]interface{} {
+	return v.g.NodeDefaults()
+}
+
+func (v *Filtered) NodeDefault(key interface{}) interface{} {
+	return v.g.NodeDefault(key)
+}
+
+func (v *Filtered) SetNodeDefaults(defaults map[interface{}]interface{}) {
+	v.g.SetNodeDefaults(defaults)
+}
+
+func (v *Filtered) EdgeDefaults() *map[interface{}]interface{} {
+	return v.g.EdgeDefaults()
+}
+
+func (v *Filtered) EdgeDefault(key interface{}) interface{} {
+	return v.g.EdgeDefault(key)
+}
+
+func (v *Filtered) SetEdgeDefaults(defaults map[interface{}]interface{}) {
+	v.g.SetEdgeDefaults(defaults)
+}
+
+func (v *Filtered) HasNode(nid int64) bool {
+	return v.Node(nid) != nil
+}
+
+func (v *Filtered) HasEdge(aid, bid int64) bool {
+	return v.Edge(aid, bid) != nil
+}
+
+func (v *Filtered) Node(nid int64) graph.Node {
+	node := v.g.Node(nid)
+	if node == nil || (v.node != nil && !v.node(node)) {
+		return nil
+	}
+	return node
+}
+
+func (v *Filtered) Nodes() []graph.Node {
+	res := make([]graph.Node, 0)
+	for _, node := range v.g.Nodes() {
+		if v.node == nil || v.node(node) {
+			res = append(res, node)
+		}
+	}
+	return res
+}
+
+func (v *Filtered) ConnectedNodes(nid int64, distance int64) []graph.Node {
+	return connectedNodes(v, nid, distance)
+}
+
+func (v *Filtered) Edge(aid, bid int64) graph.Edge {
+	if !v.HasNode(aid) || !v.HasNode(bid) {
+		return nil
+	}
+	edge := v.g.Edge(aid, bid)
+	if edge == nil || (v.edge != nil && !v.edge(edge)) {
+		return nil
+	}
+	return edge
+}
+
+// Edges returns the edges of a node in the view, as the underlying graph
+// would
+func (v *Filtered) Edges(nid int64) []graph.Edge {
+	res := make([]graph.Edge, 0)
+	if !v.HasNode(nid) {
+		return res
+	}
+	for _, edge := range v.g.Edges(nid) {
+		if v.HasNode(edge.From()) && v.HasNode(edge.To()) && (v.edge == nil || v.edge(edge)) {
+			res = append(res, edge)
+		}
+	}
+	return res
+}
+
+// connectedNodes returns the nodes within a given number of steps of a
+// node, including the node itself
+func connectedNodes(g graph.Graph, nid int64, distance int64) []graph.Node {
+	res := make([]graph.Node, 0)
+	if !g.HasNode(nid) {
+		return res
+	}
+	distances := map[int64]int64{nid: 0}
+	queue := []int64{nid}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		res = append(res, g.Node(current))
+		if distances[current] == distance {
+			continue
+		}
+		for _, edge := range g.Edges(current) {
+			next := edge.To()
+			if next == current {
+				next = edge.From()
+			}
+			if _, visited := distances[next]; !visited {
+				distances[next] = distances[current] + 1
+				queue = append(queue, next)
+			}
+		}
+	}
+	return res
+}
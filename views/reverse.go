@@ -0,0 +1,167 @@
+package views
+
+// Copyright © 2018 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"fmt"
+
+	"github.com/wealdtech/go-graph"
+)
+
+// Reversed is a view of a directed graph with the direction of every edge
+// reversed.  Nothing is copied: the view reflects later changes to the
+// graph, and its nodes, edge attributes and defaults are the graph's own.
+// Finding the edges leaving a node of the view uses the graph's incoming
+// edges where it tracks them, and otherwise looks at every edge of the graph
+type Reversed struct {
+	g graph.Graph
+}
+
+// incoming is implemented by graphs that track the edges arriving at a node
+type incoming interface {
+	IncomingEdges(nid int64) []graph.Edge
+}
+
+// reversedEdge is an edge of a graph seen from the other end.  It is a
+// value, so two views of the same edge compare equal
+type reversedEdge struct {
+	graph.Edge
+}
+
+func (e reversedEdge) From() int64 {
+	return e.Edge.To()
+}
+
+func (e reversedEdge) To() int64 {
+	return e.Edge.From()
+}
+
+// observableReversedEdge is a reversed view of an edge that reports
+// changes to its attributes
+type observableReversedEdge struct {
+	reversedEdge
+}
+
+func (e observableReversedEdge) Observe(observer graph.AttributeObserver) func() {
+	return e.Edge.(graph.Observable).Observe(observer)
+}
+
+// reverse returns an edge seen from the other end, which is observable if
+// the edge is
+func reverse(edge graph.Edge) graph.Edge {
+	if _, ok := edge.(graph.Observable); ok {
+		return observableReversedEdge{reversedEdge{edge}}
+	}
+	return reversedEdge{edge}
+}
+
+// ReverseView returns a view of a directed graph with its edges reversed
+func ReverseView(g graph.Graph) (*Reversed, error) {
+	if !graph.IsDirected(g) {
+		return nil, fmt.Errorf("Reverse view requires a directed graph")
+	}
+	return &Reversed{g: g}, nil
+}
+
+// IsDirected returns true, as the underlying graph is directed
+func (v *Reversed) IsDirected() bool {
+	return true
+}
+
+func (v *Reversed) GraphDefaults() *map[interface{}]interface{} {
+	return v.g.GraphDefaults()
+}
+
+func (v *Reversed) GraphDefault(key interface{}) interface{} {
+	return v.g.GraphDefault(key)
+}
+
+func (v *Reversed) SetGraphDefaults(defaults map[interface{}]interface{}) {
+	v.g.SetGraphDefaults(defaults)
+}
+
+func (v *Reversed) NodeDefaults() *map[interface{}]interface{} {
+	return v.g.NodeDefaults()
+}
+
+func (v *Reversed) NodeDefault(key interface{}) interface{} {
+	return v.g.NodeDefault(key)
+}
+
+func (v *Reversed) SetNodeDefaults(defaults map[interface{}]interface{}) {
+	v.g.SetNodeDefaults(defaults)
+}
+
+func (v *Reversed) EdgeDefaults() *map[interface{}]interface{} {
+	return v.g.EdgeDefaults()
+}
+
+func (v *Reversed) EdgeDefault(key interface{}) interface{} {
+	return v.g.EdgeDefault(key)
+}
+
+func (v *Reversed) SetEdgeDefaults(defaults map[interface{}]interface{}) {
+	v.g.SetEdgeDefaults(defaults)
+}
+
+func (v *Reversed) HasNode(nid int64) bool {
+	return v.g.HasNode(nid)
+}
+
+func (v *Reversed) HasEdge(aid, bid int64) bool {
+	return v.g.HasEdge(bid, aid)
+}
+
+func (v *Reversed) Node(nid int64) graph.Node {
+	return v.g.Node(nid)
+}
+
+func (v *Reversed) Nodes() []graph.Node {
+	return v.g.Nodes()
+}
+
+func (v *Reversed) ConnectedNodes(nid int64, distance int64) []graph.Node {
+	return connectedNodes(v, nid, distance)
+}
+
+func (v *Reversed) Edge(aid, bid int64) graph.Edge {
+	edge := v.g.Edge(bid, aid)
+	if edge == nil {
+		return nil
+	}
+	return reverse(edge)
+}
+
+// Edges returns the edges leaving a node in the view, which are the edges
+// arriving at it in the graph
+func (v *Reversed) Edges(nid int64) []graph.Edge {
+	res := make([]graph.Edge, 0)
+	if !v.g.HasNode(nid) {
+		return res
+	}
+	if g, ok := v.g.(incoming); ok {
+		for _, edge := range g.IncomingEdges(nid) {
+			res = append(res, reverse(edge))
+		}
+		return res
+	}
+	for _, node := range v.g.Nodes() {
+		for _, edge := range v.g.Edges(node.Id()) {
+			if edge.To() == nid {
+				res = append(res, reverse(edge))
+			}
+		}
+	}
+	return res
+}
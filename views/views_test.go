@@ -0,0 +1,163 @@
+package views
+
+// Copyright © 2018 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wealdtech/go-graph"
+	"github.com/wealdtech/go-graph/cycles"
+	"github.com/wealdtech/go-graph/edges"
+	"github.com/wealdtech/go-graph/exporters/dot"
+	"github.com/wealdtech/go-graph/graphs"
+	"github.com/wealdtech/go-graph/nodes"
+)
+
+func directed(t *testing.T, ids []int64, links [][2]int64) *graphs.DirectedGraph {
+	g := graphs.NewDirectedGraph()
+	for _, id := range ids {
+		assert.NoError(t, g.AddNode(nodes.NewSimpleNode(id)))
+	}
+	for _, link := range links {
+		assert.NoError(t, g.AddEdge(edges.NewDirectedEdge(link[0], link[1])))
+	}
+	return g
+}
+
+func undirected(t *testing.T, ids []int64, links [][2]int64) *graphs.UndirectedGraph {
+	g := graphs.NewUndirectedGraph()
+	for _, id := range ids {
+		assert.NoError(t, g.AddNode(nodes.NewSimpleNode(id)))
+	}
+	for _, link := range links {
+		assert.NoError(t, g.AddEdge(edges.NewUndirectedEdge(link[0], link[1])))
+	}
+	return g
+}
+
+func ids(nodes []graph.Node) []int64 {
+	res := make([]int64, len(nodes))
+	for i, node := range nodes {
+		res[i] = node.Id()
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i] < res[j] })
+	return res
+}
+
+func TestInducedSubgraph(t *testing.T) {
+	g := undirected(t, []int64{1, 2, 3, 4}, [][2]int64{{1, 2}, {2, 3}, {3, 4}, {1, 3}})
+	g.Node(1).SetAttribute("colour", "red")
+	v := InducedSubgraph(g, []int64{1, 2, 3, 5})
+	var _ graph.Graph = v
+	assert.False(t, v.IsDirected())
+	assert.Equal(t, []int64{1, 2, 3}, ids(v.Nodes()))
+	assert.True(t, v.HasEdge(3, 1))
+	assert.False(t, v.HasEdge(3, 4))
+	assert.Nil(t, v.Edge(3, 4))
+	assert.Len(t, v.Edges(3), 2)
+	assert.Equal(t, []int64{1, 2, 3}, ids(v.ConnectedNodes(1, 5)))
+	assert.Equal(t, 3, cycles.Girth(v))
+	assert.Equal(t, `graph g {
+  1 [ colour="red" ];
+  1 -- 2;
+  1 -- 3;
+  2;
+  2 -- 3;
+  3;
+}`, string(dot.Marshal(v)))
+
+	// Changes to the graph show through
+	g.RemoveEdge(1, 3)
+	assert.NoError(t, g.AddNode(nodes.NewSimpleNode(5)))
+	assert.NoError(t, g.AddEdge(edges.NewUndirectedEdge(3, 5)))
+	g.RemoveNode(2)
+	assert.Equal(t, []int64{1, 3, 5}, ids(v.Nodes()))
+	assert.Equal(t, 0, cycles.Girth(v))
+	assert.Equal(t, `graph g {
+  1 [ colour="red" ];
+  3;
+  3 -- 5;
+  5;
+}`, string(dot.Marshal(v)))
+}
+
+func TestFilteredView(t *testing.T) {
+	g := directed(t, []int64{1, 2, 3, 4}, [][2]int64{{1, 2}, {2, 3}, {3, 4}, {4, 1}})
+	g.Node(4).SetAttribute("status", "down")
+	g.Edge(2, 3).SetAttribute("weight", 10)
+	up := func(node graph.Node) bool { return node.Attribute("status") != "down" }
+	light := func(edge graph.Edge) bool { return edge.Attribute("weight") == nil }
+
+	v := FilteredView(g, up, light)
+	assert.True(t, v.IsDirected())
+	assert.Equal(t, `digraph g {
+  1;
+  1 -> 2;
+  2;
+  3;
+}`, string(dot.Marshal(v)))
+	assert.Equal(t, []int64{1, 2}, ids(v.ConnectedNodes(1, 3)))
+	assert.Nil(t, v.Node(4))
+	assert.False(t, v.HasNode(4))
+
+	// Filters see attribute changes
+	g.Node(4).SetAttribute("status", "up")
+	g.Edge(2, 3).SetAttribute("weight", nil)
+	assert.Equal(t, 4, cycles.Girth(v))
+	assert.Equal(t, []int64{1, 2, 3, 4}, ids(FilteredView(g, nil, nil).Nodes()))
+}
+
+func TestReverseView(t *testing.T) {
+	g := directed(t, []int64{1, 2, 3}, [][2]int64{{1, 2}, {1, 3}, {2, 3}})
+	g.Edge(1, 2).SetAttribute("label", "a")
+	v, err := ReverseView(g)
+	assert.NoError(t, err)
+	assert.True(t, v.IsDirected())
+	assert.True(t, v.HasEdge(2, 1))
+	assert.False(t, v.HasEdge(1, 2))
+	assert.Equal(t, "a", v.Edge(2, 1).Attribute("label"))
+	assert.Equal(t, []int64{1, 2, 3}, ids(v.ConnectedNodes(3, 2)))
+	assert.Equal(t, []int64{1, 2}, ids(v.ConnectedNodes(2, 2)))
+	assert.Equal(t, `digraph g {
+  1;
+  2;
+  2 -> 1 [ label="a" ];
+  3;
+  3 -> 1;
+  3 -> 2;
+}`, string(dot.Marshal(v)))
+
+	// Attributes set through the view land on the graph
+	v.Edge(3, 1).SetAttribute("label", "b")
+	assert.Equal(t, "b", g.Edge(1, 3).Attribute("label"))
+
+	// Views of the same edge are equal, and report attribute changes
+	assert.True(t, v.Edge(3, 1) == v.Edge(3, 1))
+	assert.True(t, v.Edge(2, 1) == v.Edges(2)[0])
+	o, ok := v.Edge(3, 1).(graph.Observable)
+	assert.True(t, ok)
+	changed := 0
+	stop := o.Observe(func(key, old, new interface{}) { changed++ })
+	g.Edge(1, 3).SetAttribute("label", "c")
+	stop()
+	assert.Equal(t, 1, changed)
+
+	g.RemoveEdge(2, 3)
+	assert.Len(t, v.Edges(3), 1)
+
+	_, err = ReverseView(undirected(t, nil, nil))
+	assert.EqualError(t, err, "Reverse view requires a directed graph")
+}
@@ -0,0 +1,72 @@
+package graph
+
+// Copyright © 2018 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Change is a kind of change to the structure of a graph
+type Change int
+
+const (
+	// NodeAdded is the addition of a node
+	NodeAdded Change = iota
+	// NodeRemoved is the removal of a node, reported after the removal of
+	// each of its edges
+	NodeRemoved
+	// EdgeAdded is the addition of an edge
+	EdgeAdded
+	// EdgeRemoved is the removal of an edge
+	EdgeRemoved
+	// DefaultsChanged is the replacement of the graph, node or edge defaults
+	DefaultsChanged
+)
+
+// GraphObserver is told of a change to a graph once it has been made.
+// Changes to nodes give the node's ID as both from and to, and changes to
+// defaults give zero for both
+type GraphObserver func(change Change, from, to int64)
+
+// Watchable is implemented by graphs that report changes to their nodes,
+// edges and defaults.  Changes made directly to the maps returned for the
+// defaults are not reported, and changes to attributes are reported by
+// nodes and edges that are Observable
+type Watchable interface {
+	// Watch adds an observer, returning a function that removes it
+	Watch(GraphObserver) func()
+}
+
+// Watchers is a set of graph observers to notify.  The zero value is an
+// empty set
+type Watchers struct {
+	next      int
+	observers map[int]GraphObserver
+}
+
+// Watch adds an observer, returning a function that removes it
+func (w *Watchers) Watch(observer GraphObserver) func() {
+	if w.observers == nil {
+		w.observers = make(map[int]GraphObserver)
+	}
+	id := w.next
+	w.next++
+	w.observers[id] = observer
+	return func() {
+		delete(w.observers, id)
+	}
+}
+
+// Notify tells the observers of a change to a graph
+func (w *Watchers) Notify(change Change, from, to int64) {
+	for _, observer := range w.observers {
+		observer(change, from, to)
+	}
+}
@@ -0,0 +1,50 @@
+package graph
+
+// Copyright © 2018 Weald Technology Trading
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// WeightFunc provides the weight of an edge
+type WeightFunc func(Edge) float64
+
+// UnitWeight gives every edge a weight of 1
+func UnitWeight(edge Edge) float64 {
+	return 1
+}
+
+// AttributeWeight returns a WeightFunc that obtains the weight of an edge
+// from the given attribute.  Edges without a numeric value for the
+// attribute have a weight of 1
+func AttributeWeight(key interface{}) WeightFunc {
+	return func(edge Edge) float64 {
+		switch v := edge.Attribute(key).(type) {
+		case float64:
+			return v
+		case float32:
+			return float64(v)
+		case int:
+			return float64(v)
+		case int64:
+			return float64(v)
+		case int32:
+			return float64(v)
+		case uint:
+			return float64(v)
+		case uint64:
+			return float64(v)
+		case uint32:
+			return float64(v)
+		default:
+			return 1
+		}
+	}
+}